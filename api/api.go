@@ -2,14 +2,24 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
 
 	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/grpcserver"
 	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/outbox"
+	"github.com/Alonso-Arias/test-cleverit/reminder"
+	"github.com/Alonso-Arias/test-cleverit/security"
 	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/Alonso-Arias/test-cleverit/services/webhook"
+	"github.com/Alonso-Arias/test-cleverit/storage"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
 	"github.com/labstack/echo/v4"
-	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
 var loggerf = log.LoggerJSON().WithField("package", "main")
@@ -29,15 +39,72 @@ var loggerf = log.LoggerJSON().WithField("package", "main")
 // @host localhost:1323
 // @BasePath /api/v1
 func main() {
-	e := echo.New()
-	e.POST("/api/v1/task", taskPost)
-	e.GET("/api/v1/task/findAll", findAllTasksGet)
-	e.GET("/api/v1/task/:id", taskGet)
-	e.PUT("/api/v1/task", taskPut)
-	e.DELETE("/api/v1/task/:id", taskDelete)
-	e.GET("/swagger/*", echoSwagger.WrapHandler)
-	e.Logger.Fatal(e.Start(":1323"))
+	if len(os.Args) > 2 && os.Args[1] == "policy" && os.Args[2] == "check" {
+		os.Exit(policyCheck(os.Args[3:], os.Stdout))
+	}
+
+	shutdown, err := tracing.Init(context.Background(), tracing.ConfigFromEnv())
+	if err != nil {
+		loggerf.WithError(err).Fatal("Failed to init tracing")
+	}
+
+	if _, err := routeRoles(security.Enforcer(), routes); err != nil {
+		loggerf.WithError(err).Fatal("Failed to authorize routes")
+	}
+	storage.Default()
+
+	reminderCfg := reminder.ConfigFromEnv()
+	notifier, err := reminder.NewNotifier(reminderCfg)
+	if err != nil {
+		loggerf.WithError(err).Fatal("Failed to init reminder notifier")
+	}
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	if notifier != nil {
+		go reminder.NewScheduler(reminderCfg, notifier).Run(ctx)
+	}
+	webhook.NewDispatcher(webhook.ConfigFromEnv()).Start(ctx, events.Default())
+
+	outboxCfg := outbox.ConfigFromEnv()
+	sinks, err := outbox.NewSinks(outboxCfg)
+	if err != nil {
+		loggerf.WithError(err).Fatal("Failed to init outbox sinks")
+	}
+	go outbox.NewRelay(outboxCfg, sinks).Run(ctx)
+
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50051"
+	}
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		loggerf.WithError(err).Fatal("Failed to listen for gRPC")
+	}
+	grpcServer := grpcserver.New()
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			loggerf.WithError(err).Error("gRPC server stopped")
+		}
+	}()
+
+	e := newRouter()
+	err = e.Start(":1323")
+	grpcServer.GracefulStop()
+	stopWorkers()
+	outbox.Close(sinks)
+	shutdown(context.Background())
+	e.Logger.Fatal(err)
+
+}
 
+// newRouter - echo with the middlewares and routes of the API
+func newRouter() *echo.Echo {
+	e := echo.New()
+	e.Use(tracing.Middleware())
+	e.Use(metrics.Middleware())
+	e.Use(TimeZone)
+	e.Use(Authenticate)
+	register(e, routes)
+	return e
 }
 
 // PermissionValidator - filters users and validates if they have permissions for execute the API.
@@ -58,13 +125,31 @@ func main() {
 // @ID findAlltasksGet
 // @Accept  json
 // @Produce  json
+// @Param state query string false "state"
+// @Param tz query string false "IANA time zone of the dates, also read from the Time-Zone header"
+// @Param priority query string false "LOW, MEDIUM, HIGH or URGENT"
+// @Param label query string false "label name"
+// @Param dueFrom query string false "due date from (RFC 3339)"
+// @Param dueTo query string false "due date to (RFC 3339)"
+// @Param sort query string false "id, priority, -priority, due_date or -due_date"
+// @Param limit query int false "page size (max 500), every task when missing"
+// @Param offset query int false "tasks to skip"
 // @Success 200  {object} task.FindAllTasksResponse
 // @Failure 404 {object}  errors.CustomError
 // @Failure 500 {object}  errors.CustomError
-// @Router /tasks/findAll [get]
+// @Router /task/findAll [get]
 func findAllTasksGet(c echo.Context) error {
 
-	res, err := task.TaskService{}.FindAllTasks(context.TODO())
+	log := loggerf.WithField("func", "findAllTasksGet")
+
+	req := task.FindAllTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.FindAllTasks(c.Request().Context(), req)
 	if ce, ok := err.(errs.CustomError); ok {
 		return c.JSON(ce.Code, err)
 	} else if err != nil {
@@ -82,6 +167,7 @@ func findAllTasksGet(c echo.Context) error {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id"
+// @Param tz query string false "IANA time zone of the dates, also read from the Time-Zone header"
 // @Success 200  {object} task.GetTaskResponse
 // @Failure 404 {object}  errors.CustomError
 // @Failure 500 {object}  errors.CustomError
@@ -98,7 +184,7 @@ func taskGet(c echo.Context) error {
 		Id: int32(idInt),
 	}
 
-	res, err := task.TaskService{}.GetTask(context.TODO(), req)
+	res, err := task.TaskService{}.GetTask(c.Request().Context(), req)
 	if ce, ok := err.(errs.CustomError); ok {
 		return c.JSON(ce.Code, err)
 	} else if err != nil {
@@ -132,7 +218,7 @@ func taskDelete(c echo.Context) error {
 		Id: int32(idInt),
 	}
 
-	res, err := task.TaskService{}.DeleteTask(context.TODO(), req)
+	res, err := task.TaskService{}.DeleteTask(c.Request().Context(), req)
 	if ce, ok := err.(errs.CustomError); ok {
 		return c.JSON(ce.Code, err)
 	} else if err != nil {
@@ -160,12 +246,12 @@ func taskPut(c echo.Context) error {
 
 	req := task.UpdateTaskRequest{}
 
-	if err := c.Bind(req); err != nil {
+	if err := c.Bind(&req); err != nil {
 		log.WithError(err).Error("Binding error")
 		return c.JSON(http.StatusBadRequest, err)
 	}
 
-	res, err := task.TaskService{}.UpdateTask(context.TODO(), req)
+	res, err := task.TaskService{}.UpdateTask(c.Request().Context(), req)
 	if ce, ok := err.(errs.CustomError); ok {
 		return c.JSON(ce.Code, err)
 	} else if err != nil {
@@ -198,7 +284,255 @@ func taskPost(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, err)
 	}
 
-	res, err := task.TaskService{}.SaveTask(context.TODO(), req)
+	res, err := task.TaskService{}.SaveTask(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// bulk save tasks
+// @Summary save tasks in bulk
+// @tags task
+// @Description guarda varias tareas en una única transacción
+// @ID tasksBulkPost
+// @Accept  json
+// @Produce  json
+// @Param BulkSaveTasksRequest body task.BulkSaveTasksRequest true "tasks"
+// @Success 200  {object} task.BulkTasksResponse
+// @Success 207  {object} task.BulkTasksResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/bulk [post]
+func tasksBulkPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksBulkPost")
+
+	req := task.BulkSaveTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.BulkSaveTasks(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(bulkStatus(res), res)
+}
+
+// bulk update tasks
+// @Summary update the state of tasks in bulk
+// @tags task
+// @Description cambia el estado de varias tareas en una única transacción
+// @ID tasksBulkPut
+// @Accept  json
+// @Produce  json
+// @Param BulkUpdateTasksRequest body task.BulkUpdateTasksRequest true "ids and state"
+// @Success 200  {object} task.BulkTasksResponse
+// @Success 207  {object} task.BulkTasksResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/bulk [put]
+func tasksBulkPut(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksBulkPut")
+
+	req := task.BulkUpdateTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.BulkUpdateTasks(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(bulkStatus(res), res)
+}
+
+// bulk delete tasks
+// @Summary delete tasks in bulk
+// @tags task
+// @Description elimina varias tareas en una única transacción
+// @ID tasksBulkDelete
+// @Accept  json
+// @Produce  json
+// @Param BulkDeleteTasksRequest body task.BulkDeleteTasksRequest true "ids"
+// @Success 200  {object} task.BulkTasksResponse
+// @Success 207  {object} task.BulkTasksResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/bulk [delete]
+func tasksBulkDelete(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksBulkDelete")
+
+	req := task.BulkDeleteTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.BulkDeleteTasks(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(bulkStatus(res), res)
+}
+
+// bulkStatus - 200 when every item succeeded, 207 Multi-Status otherwise
+func bulkStatus(res task.BulkTasksResponse) int {
+	if res.Failed > 0 {
+		return http.StatusMultiStatus
+	}
+	return http.StatusOK
+}
+
+// export tasks
+// @Summary export tasks
+// @tags task
+// @Description exporta las tareas que cumplen los filtros en formato csv, json o ndjson
+// @ID tasksExportGet
+// @Produce  text/csv
+// @Produce  json
+// @Produce  application/x-ndjson
+// @Param format query string true "csv, json or ndjson"
+// @Param state query string false "state"
+// @Param tz query string false "IANA time zone of the dates, also read from the Time-Zone header"
+// @Param priority query string false "LOW, MEDIUM, HIGH or URGENT"
+// @Param label query string false "label name"
+// @Param dueFrom query string false "due date from (RFC 3339)"
+// @Param dueTo query string false "due date to (RFC 3339)"
+// @Param sort query string false "id, priority, -priority, due_date or -due_date"
+// @Success 200
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/export [get]
+func tasksExportGet(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksExportGet")
+
+	req := task.ExportTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, task.ContentType(req.Format))
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=tasks."+req.Format)
+
+	err := task.TaskService{}.ExportTasks(c.Request().Context(), req, c.Response())
+	if err == nil {
+		return nil
+	}
+
+	// Una vez iniciada la respuesta los errores sólo pueden registrarse.
+	if c.Response().Committed {
+		log.WithError(err).Error("Export interrupted")
+		return nil
+	}
+
+	c.Response().Header().Del(echo.HeaderContentType)
+	c.Response().Header().Del(echo.HeaderContentDisposition)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	}
+	return c.JSON(http.StatusInternalServerError, err)
+}
+
+// import tasks
+// @Summary import tasks
+// @tags task
+// @Description importa tareas en formato csv, json o ndjson validando cada fila
+// @ID tasksImportPost
+// @Accept  text/csv
+// @Accept  json
+// @Accept  application/x-ndjson
+// @Produce  json
+// @Param format query string false "csv, json or ndjson (defaults to the Content-Type)"
+// @Param dryRun query bool false "validate only"
+// @Param allOrNothing query bool false "import nothing if a row fails"
+// @Success 200  {object} task.ImportTasksResponse
+// @Success 207  {object} task.ImportTasksResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 413 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/import [post]
+func tasksImportPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksImportPost")
+
+	req := task.ImportTasksRequest{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	if req.Format == "" {
+		req.Format = task.FormatFromContentType(c.Request().Header.Get(echo.HeaderContentType))
+	}
+
+	// oversized imports are cut while they are decoded
+	r := c.Request()
+	r.Body = http.MaxBytesReader(c.Response(), r.Body, task.MaxImportSize)
+
+	res, err := task.TaskService{}.ImportTasks(r.Context(), req, r.Body)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	if res.Failed > 0 {
+		return c.JSON(http.StatusMultiStatus, res)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// search tasks
+// @Summary search tasks
+// @tags task
+// @Description busca tareas por palabras clave en título y descripción, ordenadas por relevancia
+// @ID tasksSearchGet
+// @Accept  json
+// @Produce  json
+// @Param q query string true "keywords"
+// @Param limit query int false "max results (default 20, max 100)"
+// @Success 200  {object} task.SearchTasksResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/search [get]
+func tasksSearchGet(c echo.Context) error {
+
+	log := loggerf.WithField("func", "tasksSearchGet")
+
+	req := task.SearchTasksRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.SearchTasks(c.Request().Context(), req)
 	if ce, ok := err.(errs.CustomError); ok {
 		return c.JSON(ce.Code, err)
 	} else if err != nil {
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/apikey"
+	"github.com/labstack/echo/v4"
+)
+
+// find all api keys
+// @Summary Find all api keys
+// @tags apikey
+// @Description obtiene las claves de API del centro, sin su parte secreta, con su último uso
+// @ID findAllApiKeysGet
+// @Accept  json
+// @Produce  json
+// @Success 200  {object} apikey.FindAllApiKeysResponse
+// @Failure 500 {object}  errors.CustomError
+// @Router /apikey/findAll [get]
+func findAllApiKeysGet(c echo.Context) error {
+
+	res, err := apikey.ApiKeyService{}.FindAllApiKeys(c.Request().Context())
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// issue api key
+// @Summary issue api key
+// @tags apikey
+// @Description crea una clave de API con los roles de sus scopes (tasks:read, tasks:admin) y la devuelve por única vez
+// @ID apiKeyPost
+// @Accept  json
+// @Produce  json
+// @Param IssueApiKeyRequest body apikey.IssueApiKeyRequest true "api key"
+// @Success 200  {object} apikey.IssueApiKeyResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /apikey [post]
+func apiKeyPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "apiKeyPost")
+
+	req := apikey.IssueApiKeyRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := apikey.ApiKeyService{}.IssueApiKey(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// revoke api key
+// @Summary revoke api key by id
+// @tags apikey
+// @Description revoca una clave de API, que deja de aceptarse
+// @ID apiKeyDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} apikey.RevokeApiKeyResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /apikey/{id} [delete]
+func apiKeyDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := apikey.ApiKeyService{}.RevokeApiKey(c.Request().Context(), apikey.RevokeApiKeyRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
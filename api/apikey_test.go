@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/pkg/client"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/apikey"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKey_Authentication(t *testing.T) {
+	srv := serve(t)
+
+	token, _ := security.IssueToken(model.AuthenticatedUser{Email: "admin@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_1"}}}, time.Hour)
+
+	call := func(method, path, authorization, body string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authorization)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("fails to call %s %s: %v", method, path, err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	res := call(http.MethodPost, "/api/v1/apikey", "Bearer "+token, `{"apiKey": {"name": "batch", "scopes": ["tasks:read"]}}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	issued := apikey.IssueApiKeyResponse{}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&issued))
+
+	// the key has the roles of its scopes
+	_, err := client.New(client.Config{BaseURL: srv.URL, ApiKey: issued.Key}).FindAllTasks(context.Background(), client.TaskFilter{})
+	assert.NoError(t, err)
+	res = call(http.MethodGet, "/api/v1/apikey/findAll", "ApiKey "+issued.Key, "")
+	assert.Equal(t, errs.Unauthorized.Code, res.StatusCode)
+
+	res = call(http.MethodDelete, "/api/v1/apikey/"+strconv.Itoa(int(issued.Id)), "Bearer "+token, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = call(http.MethodGet, "/api/v1/task/findAll", "ApiKey "+issued.Key, "")
+	ce := errs.CustomError{}
+	json.NewDecoder(res.Body).Decode(&ce)
+	assert.Equal(t, errs.RevokedApiKey.Code, res.StatusCode)
+	assert.Equal(t, errs.RevokedApiKey.InternalCode, ce.InternalCode)
+}
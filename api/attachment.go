@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/attachment"
+	"github.com/labstack/echo/v4"
+)
+
+// multipartOverhead - room for the multipart boundaries and headers on top of
+// the attachment size limit
+const multipartOverhead = 1 << 20
+
+// list task attachments
+// @Summary list task attachments
+// @tags attachment
+// @Description obtiene los datos de los adjuntos de una tarea
+// @ID taskAttachmentsGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Success 200  {object} attachment.ListAttachmentsResponse
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/attachments [get]
+func taskAttachmentsGet(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := attachment.AttachmentService{}.ListAttachments(c.Request().Context(), attachment.ListAttachmentsRequest{TaskId: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// upload task attachment
+// @Summary upload task attachment
+// @tags attachment
+// @Description adjunta un archivo a una tarea, con límites de tamaño y tipo de contenido
+// @ID taskAttachmentPost
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param file formData file true "file"
+// @Success 200  {object} attachment.SaveAttachmentResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 413 {object}  errors.CustomError
+// @Failure 415 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/attachments [post]
+func taskAttachmentPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskAttachmentPost")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	// oversized uploads are cut before being spooled to disk
+	r := c.Request()
+	r.Body = http.MaxBytesReader(c.Response(), r.Body, attachment.MaxSize+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return c.JSON(errs.AttachmentTooLarge.Code, errs.AttachmentTooLarge)
+	} else if err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, errs.BadRequest.SetMessage("Missing multipart file field"))
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	defer f.Close()
+
+	contentType := fh.Header.Get(echo.HeaderContentType)
+	if contentType == "" || contentType == echo.MIMEOctetStream {
+		if t := mime.TypeByExtension(filepath.Ext(fh.Filename)); t != "" {
+			contentType = t
+		}
+	}
+
+	req := attachment.SaveAttachmentRequest{
+		TaskId:      int32(idInt),
+		FileName:    fh.Filename,
+		ContentType: contentType,
+		Size:        fh.Size,
+	}
+
+	res, err := attachment.AttachmentService{}.SaveAttachment(r.Context(), req, f)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// download task attachment
+// @Summary download task attachment
+// @tags attachment
+// @Description descarga el contenido de un adjunto
+// @ID taskAttachmentGet
+// @Produce  octet-stream
+// @Param id path string true "Task id"
+// @Param attachmentId path string true "Attachment id"
+// @Success 200
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/attachments/{attachmentId} [get]
+func taskAttachmentGet(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	attachmentIdInt, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := attachment.GetAttachmentRequest{TaskId: int32(idInt), Id: int32(attachmentIdInt)}
+
+	res, err := attachment.AttachmentService{}.GetAttachment(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	defer res.Content.Close()
+
+	h := c.Response().Header()
+	h.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": res.Attachment.FileName}))
+	h.Set(echo.HeaderContentLength, strconv.FormatInt(res.Attachment.Size, 10))
+	h.Set(echo.HeaderXContentTypeOptions, "nosniff")
+
+	return c.Stream(http.StatusOK, res.Attachment.ContentType, res.Content)
+}
+
+// delete task attachment
+// @Summary delete task attachment
+// @tags attachment
+// @Description elimina un adjunto y su contenido
+// @ID taskAttachmentDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param attachmentId path string true "Attachment id"
+// @Success 200  {object} attachment.DeleteAttachmentResponse
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/attachments/{attachmentId} [delete]
+func taskAttachmentDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	attachmentIdInt, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := attachment.DeleteAttachmentRequest{TaskId: int32(idInt), Id: int32(attachmentIdInt)}
+
+	res, err := attachment.AttachmentService{}.DeleteAttachment(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
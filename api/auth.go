@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/apikey"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/labstack/echo/v4"
+)
+
+// accessTokenParam - alternative to the Authorization header for clients
+// that cannot set headers (EventSource, browser WebSocket), read only on the
+// routes with QueryToken
+const accessTokenParam = "access_token"
+
+// Authenticate - reads the user token from "Authorization: Bearer ..." or,
+// on the routes with QueryToken, ?access_token=, or the API key from
+// "Authorization: ApiKey ...", and puts its user in the request context.
+// Requests without a token go on anonymously; an invalid or expired token or
+// an invalid or revoked key is rejected.
+func Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		h := c.Request().Header.Get(echo.HeaderAuthorization)
+		token := ""
+		if queryTokenRoutes[c.Request().Method+" "+c.Path()] {
+			token = c.QueryParam(accessTokenParam)
+		}
+		if strings.HasPrefix(h, "Bearer ") {
+			token = strings.TrimPrefix(h, "Bearer ")
+		}
+
+		var au model.AuthenticatedUser
+		var err error
+		switch {
+		case strings.HasPrefix(h, "ApiKey "):
+			au, err = apikey.ApiKeyService{}.Authenticate(c.Request().Context(), strings.TrimPrefix(h, "ApiKey "))
+		case token != "":
+			au, err = security.ParseToken(token)
+		default:
+			return next(c)
+		}
+
+		if ce, ok := err.(errs.CustomError); ok {
+			return c.JSON(ce.Code, ce)
+		} else if err != nil {
+			return c.JSON(http.StatusInternalServerError, errs.InternalError)
+		}
+
+		c.SetRequest(c.Request().WithContext(security.WithUser(c.Request().Context(), au)))
+		return next(c)
+	}
+}
+
+// Authorize - lets the request through only when the authenticated user has
+// a role allowed by the access policy for its method and path
+func Authorize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		au, ok := security.UserFromContext(c.Request().Context())
+		if !ok {
+			return c.JSON(errs.Unauthorized.Code, errs.Unauthorized)
+		}
+		if !security.IsAuthorized(au, c.Request().Method, c.Request().URL.Path) {
+			ce := errs.Unauthorized.SetMessage("Without privileges for this function")
+			return c.JSON(ce.Code, ce)
+		}
+		return next(c)
+	}
+}
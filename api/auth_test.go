@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate_AccessTokenOnlyOnEvents(t *testing.T) {
+	srv := serve(t)
+
+	token, _ := security.IssueToken(model.AuthenticatedUser{Email: "admin@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_1"}}}, time.Hour)
+
+	get := func(path string) int {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path+"?access_token="+token, nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("fails to call GET %s: %v", path, err)
+		}
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/v1/task/events"))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/v1/task/findAll"))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/v1/task/1"))
+}
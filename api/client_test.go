@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/pkg/client"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/storage"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	testenv.BasePath()
+	security.TokenSecret = []byte("test-secret")
+	os.Exit(m.Run())
+}
+
+// serve - starts the API router on sqlite and local storage
+func serve(t *testing.T) *httptest.Server {
+	testenv.SQLite(t)
+	s, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("fails to create storage: %v", err)
+	}
+	storage.SetDefault(s)
+
+	srv := httptest.NewServer(newRouter())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// clientAs - client with the token of a user with role
+func clientAs(t *testing.T, srv *httptest.Server, role string) *client.Client {
+	token, err := security.IssueToken(model.AuthenticatedUser{Email: role + "@example.com", CenterCode: "C1", Roles: []model.Role{{Code: role}}}, time.Hour)
+	if err != nil {
+		t.Fatalf("fails to issue token: %v", err)
+	}
+	return client.New(client.Config{BaseURL: srv.URL, Token: token, TimeZone: "America/Santiago"})
+}
+
+func TestClient_Tasks(t *testing.T) {
+	c := clientAs(t, serve(t), "ROL_1")
+	ctx := context.Background()
+
+	saved, err := c.SaveTask(ctx, client.SaveTaskRequest{Task: model.Task{
+		Title: "sdk", Description: "from the client", State: "PENDING", DueDate: "2030-01-01T10:00:00",
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got, err := c.GetTask(ctx, saved.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "2030-01-01T10:00:00-03:00", got.Task.DueDate)
+
+	got.Task.State = "IN_PROGRESS"
+	_, err = c.UpdateTask(ctx, client.UpdateTaskRequest{Task: got.Task})
+	assert.NoError(t, err)
+
+	page, err := c.FindAllTasks(ctx, client.TaskFilter{State: "IN_PROGRESS", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.NotZero(t, page.Total)
+
+	blocker, err := c.SaveTask(ctx, client.SaveTaskRequest{Task: model.Task{Title: "blocker", Description: "blocker", State: "PENDING", DueDate: "2030-01-01T10:00:00"}})
+	assert.NoError(t, err)
+	assert.NoError(t, c.AddBlocker(ctx, saved.Id, blocker.Id))
+	graph, err := c.GetTaskGraph(ctx, saved.Id)
+	assert.NoError(t, err)
+	assert.Len(t, graph.Blockers, 1)
+	assert.NoError(t, c.RemoveBlocker(ctx, saved.Id, blocker.Id))
+
+	bulk, err := c.BulkUpdateTasks(ctx, client.BulkUpdateTasksRequest{Ids: []int32{blocker.Id, 999999}, State: "COMPLETED"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bulk.Failed)
+	assert.Equal(t, errs.TasksNotFound.InternalCode, bulk.Results[1].Error.InternalCode)
+
+	var csv bytes.Buffer
+	assert.NoError(t, c.ExportTasks(ctx, client.TaskFilter{State: "IN_PROGRESS"}, "csv", &csv))
+	assert.Contains(t, csv.String(), "sdk")
+
+	imported, err := c.ImportTasks(ctx, client.ImportOptions{DryRun: true}, "application/json",
+		strings.NewReader(`[{"title":"imported","description":"imported","state":"PENDING"}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported.Total)
+
+	assert.NoError(t, c.DeleteTask(ctx, saved.Id))
+	_, err = c.GetTask(ctx, saved.Id)
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	_, err = c.FindAllTasks(ctx, client.TaskFilter{Sort: "nope"})
+	assert.Equal(t, 400, err.(errs.CustomError).Code)
+}
+
+func TestClient_CommentsAndAttachments(t *testing.T) {
+	srv := serve(t)
+	c, reader := clientAs(t, srv, "ROL_1"), clientAs(t, srv, "ROL_2")
+	ctx := context.Background()
+
+	saved, err := c.SaveTask(ctx, client.SaveTaskRequest{Task: model.Task{Title: "files", Description: "files", State: "PENDING", DueDate: "2030-01-01T10:00:00"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	comment, err := c.SaveComment(ctx, saved.Id, "first")
+	assert.NoError(t, err)
+	assert.NoError(t, c.UpdateComment(ctx, saved.Id, comment.Id, "edited"))
+	assert.Equal(t, errs.CommentNotAuthor, reader.DeleteComment(ctx, saved.Id, comment.Id))
+	comments, err := reader.ListComments(ctx, saved.Id, 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, comments.Comments, 1) {
+		assert.Equal(t, "edited", comments.Comments[0].Body)
+	}
+	assert.NoError(t, c.DeleteComment(ctx, saved.Id, comment.Id))
+
+	up, err := c.UploadAttachment(ctx, saved.Id, "notes.txt", "", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", up.Attachment.ContentType)
+
+	list, err := reader.ListAttachments(ctx, saved.Id)
+	assert.NoError(t, err)
+	assert.Len(t, list.Attachments, 1)
+
+	var content bytes.Buffer
+	assert.NoError(t, reader.DownloadAttachment(ctx, saved.Id, up.Attachment.Id, &content))
+	assert.Equal(t, "hello", content.String())
+
+	// ROL_2 may not delete attachments
+	err = reader.DeleteAttachment(ctx, saved.Id, up.Attachment.Id)
+	assert.Equal(t, errs.Unauthorized.SetMessage("Without privileges for this function"), err)
+	assert.NoError(t, c.DeleteAttachment(ctx, saved.Id, up.Attachment.Id))
+
+	anonymous := client.New(client.Config{BaseURL: srv.URL})
+	_, err = anonymous.SaveComment(ctx, saved.Id, "hi")
+	assert.Equal(t, errs.Unauthorized, err)
+}
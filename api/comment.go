@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/comment"
+	"github.com/labstack/echo/v4"
+)
+
+// list task comments
+// @Summary list task comments
+// @tags comment
+// @Description obtiene una página de los comentarios de una tarea, del más antiguo al más reciente
+// @ID taskCommentsGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param page query int false "page, starting at 1"
+// @Param size query int false "comments per page (default 20, max 100)"
+// @Success 200  {object} comment.ListCommentsResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/comments [get]
+func taskCommentsGet(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskCommentsGet")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := comment.ListCommentsRequest{}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.TaskId = int32(idInt)
+
+	res, err := comment.CommentService{}.ListComments(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// add task comment
+// @Summary add task comment
+// @tags comment
+// @Description agrega un comentario a una tarea en nombre del usuario autenticado
+// @ID taskCommentPost
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param SaveCommentRequest body comment.SaveCommentRequest true "comment"
+// @Success 200  {object} comment.SaveCommentResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/comments [post]
+func taskCommentPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskCommentPost")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := comment.SaveCommentRequest{}
+
+	if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.TaskId = int32(idInt)
+
+	res, err := comment.CommentService{}.SaveComment(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// edit task comment
+// @Summary edit task comment
+// @tags comment
+// @Description cambia el texto de un comentario; solo puede hacerlo su autor
+// @ID taskCommentPut
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param commentId path string true "Comment id"
+// @Param UpdateCommentRequest body comment.UpdateCommentRequest true "comment"
+// @Success 200  {object} comment.UpdateCommentResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Failure 403 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/comments/{commentId} [put]
+func taskCommentPut(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskCommentPut")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	commentIdInt, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := comment.UpdateCommentRequest{}
+
+	if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.TaskId = int32(idInt)
+	req.Comment.Id = int32(commentIdInt)
+
+	res, err := comment.CommentService{}.UpdateComment(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// delete task comment
+// @Summary delete task comment
+// @tags comment
+// @Description elimina un comentario; solo puede hacerlo su autor
+// @ID taskCommentDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Task id"
+// @Param commentId path string true "Comment id"
+// @Success 200  {object} comment.DeleteCommentResponse
+// @Failure 401 {object}  errors.CustomError
+// @Failure 403 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/comments/{commentId} [delete]
+func taskCommentDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	commentIdInt, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := comment.DeleteCommentRequest{TaskId: int32(idInt), Id: int32(commentIdInt)}
+
+	res, err := comment.CommentService{}.DeleteComment(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Alonso-Arias/test-cleverit/graph"
+	"github.com/labstack/echo/v4"
+)
+
+// graphqlLimits - complexity and depth bounds of the GraphQL queries
+var graphqlLimits = graph.LimitsFromEnv()
+
+// graphqlHandler - runs a GraphQL request sent as a JSON body on POST or as
+// query, operationName and variables parameters on GET. Resolution errors
+// come in the errors of the response, as GraphQL does, with status 200.
+func graphqlHandler(c echo.Context) error {
+
+	log := loggerf.WithField("func", "graphqlHandler")
+
+	req := graph.Request{}
+
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+		if v := c.QueryParam("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				log.WithError(err).Error("Binding error")
+				return c.JSON(http.StatusBadRequest, err)
+			}
+		}
+	} else if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, graph.Execute(c.Request().Context(), req, graphqlLimits))
+}
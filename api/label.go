@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/label"
+	"github.com/labstack/echo/v4"
+)
+
+// find all labels
+// @Summary Find all labels
+// @tags label
+// @Description obtiene todas las etiquetas
+// @ID findAllLabelsGet
+// @Accept  json
+// @Produce  json
+// @Success 200  {object} label.FindAllLabelsResponse
+// @Failure 500 {object}  errors.CustomError
+// @Router /label/findAll [get]
+func findAllLabelsGet(c echo.Context) error {
+
+	res, err := label.LabelService{}.FindAllLabels(c.Request().Context())
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// get label
+// @Summary get label by id
+// @tags label
+// @Description obtiene una etiqueta por id
+// @ID labelGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} label.GetLabelResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /label/{id} [get]
+func labelGet(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := label.LabelService{}.GetLabel(c.Request().Context(), label.GetLabelRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// save label
+// @Summary save label
+// @tags label
+// @Description guarda una etiqueta
+// @ID labelPost
+// @Accept  json
+// @Produce  json
+// @Param SaveLabelRequest body label.SaveLabelRequest true "label"
+// @Success 200  {object} label.SaveLabelResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /label [post]
+func labelPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "labelPost")
+
+	req := label.SaveLabelRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := label.LabelService{}.SaveLabel(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// update label
+// @Summary update label
+// @tags label
+// @Description actualiza una etiqueta
+// @ID labelPut
+// @Accept  json
+// @Produce  json
+// @Param UpdateLabelRequest body label.UpdateLabelRequest true "label"
+// @Success 200  {object} label.UpdateLabelResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /label [put]
+func labelPut(c echo.Context) error {
+
+	log := loggerf.WithField("func", "labelPut")
+
+	req := label.UpdateLabelRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := label.LabelService{}.UpdateLabel(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// delete label
+// @Summary delete label by id
+// @tags label
+// @Description elimina una etiqueta y la quita de sus tareas
+// @ID labelDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} label.DeleteLabelResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /label/{id} [delete]
+func labelDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := label.LabelService{}.DeleteLabel(c.Request().Context(), label.DeleteLabelRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/user"
+	"github.com/labstack/echo/v4"
+)
+
+// login
+// @Summary login
+// @tags auth
+// @Description comprueba el email y la contraseña de un usuario y devuelve un token para "Authorization: Bearer ..."
+// @ID loginPost
+// @Accept  json
+// @Produce  json
+// @Param LoginRequest body user.LoginRequest true "credenciales"
+// @Success 200  {object} user.LoginResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Failure 403 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /login [post]
+func loginPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "loginPost")
+
+	req := user.LoginRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := user.UserService{}.Login(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
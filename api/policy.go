@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/security/policytest"
+)
+
+// policyCheck - `api policy check [-model f] [-policy f] [-cases f]`: checks
+// the policy against the routes of the API, writing the gaps to w. Returns
+// the exit code, 1 when there is some gap.
+func policyCheck(args []string, w io.Writer) int {
+
+	modelPath, policyPath, casesPath := policytest.Files()
+
+	fs := flag.NewFlagSet("policy check", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.StringVar(&modelPath, "model", modelPath, "casbin model")
+	fs.StringVar(&policyPath, "policy", policyPath, "casbin policy")
+	fs.StringVar(&casesPath, "cases", casesPath, "CSV of role, path, method and allow or deny, none when empty")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	e, err := security.LoadEnforcer(modelPath, policyPath)
+	if err != nil {
+		fmt.Fprintf(w, "fails to load the policy: %v\n", err)
+		return 1
+	}
+
+	cases := []security.PolicyCase{}
+	if casesPath != "" {
+		if cases, err = security.LoadPolicyCases(casesPath); err != nil {
+			fmt.Fprintf(w, "fails to load the policy cases: %v\n", err)
+			return 1
+		}
+	}
+
+	protected := protectedRoutes(routes)
+	report, err := security.CheckPolicy(e, protected, cases)
+	if err != nil {
+		fmt.Fprintf(w, "fails to check the policy: %v\n", err)
+		return 1
+	}
+
+	report.Write(w)
+	if !report.OK() {
+		return 1
+	}
+
+	fmt.Fprintf(w, "policy ok: %d routes, %d policies, %d task rules, %d cases\n", len(protected), len(e.GetPolicy()), len(e.GetNamedPolicy("p2")), len(cases))
+	return 0
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/security/policytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	policytest.Check(t, protectedRoutes(routes))
+}
+
+func TestPolicyCheck(t *testing.T) {
+	var out bytes.Buffer
+	assert.Equal(t, 0, policyCheck(nil, &out), out.String())
+	assert.Contains(t, out.String(), "policy ok")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/labstack/echo/v4"
+)
+
+// list task occurrences
+// @Summary list upcoming occurrences
+// @tags task
+// @Description calcula las próximas fechas de un task recurrente sin crearlas
+// @ID taskOccurrencesGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Param limit query int false "max occurrences (default 10, max 100)"
+// @Success 200  {object} task.ListOccurrencesResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/occurrences [get]
+func taskOccurrencesGet(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskOccurrencesGet")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := task.ListOccurrencesRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.Id = int32(idInt)
+
+	res, err := task.TaskService{}.ListOccurrences(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
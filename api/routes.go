@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/casbin/casbin/v2"
+	"github.com/labstack/echo/v4"
+	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/swaggo/swag"
+)
+
+// basePath - prefix of the REST endpoints, the base path of the swagger doc
+const basePath = "/api/v1"
+
+// route - an endpoint of the API. The router, the authorization of every
+// request and the swagger paths are built from routes, and the API does not
+// start while a protected route has no casbin rule.
+type route struct {
+	Method string
+	// Path - echo syntax, :param and *
+	Path    string
+	Handler echo.HandlerFunc
+	// Public - served without authentication and without casbin rule
+	Public bool
+	// QueryToken - also takes the user token from ?access_token=
+	QueryToken bool
+	Tag        string
+	Summary    string
+}
+
+// routes - every endpoint of the API; a route served before another one
+// with the same prefix goes first
+var routes = []route{
+	{Method: http.MethodPost, Path: basePath + "/task", Handler: taskPost, Tag: "task", Summary: "save task"},
+	{Method: http.MethodPost, Path: basePath + "/task/bulk", Handler: tasksBulkPost, Tag: "task", Summary: "save tasks in bulk"},
+	{Method: http.MethodPut, Path: basePath + "/task/bulk", Handler: tasksBulkPut, Tag: "task", Summary: "update the state of tasks in bulk"},
+	{Method: http.MethodDelete, Path: basePath + "/task/bulk", Handler: tasksBulkDelete, Tag: "task", Summary: "delete tasks in bulk"},
+	{Method: http.MethodGet, Path: basePath + "/task/findAll", Handler: findAllTasksGet, Tag: "tasks", Summary: "Find all tasks"},
+	{Method: http.MethodGet, Path: basePath + "/task/export", Handler: tasksExportGet, Tag: "task", Summary: "export tasks"},
+	{Method: http.MethodGet, Path: basePath + "/task/search", Handler: tasksSearchGet, Tag: "task", Summary: "search tasks"},
+	{Method: http.MethodGet, Path: basePath + "/task/events", Handler: taskEventsGet, QueryToken: true, Tag: "task", Summary: "stream task events"},
+	{Method: http.MethodGet, Path: basePath + "/task/events/ws", Handler: taskEventsWs, QueryToken: true, Tag: "task", Summary: "stream task events over WebSocket"},
+	{Method: http.MethodPost, Path: basePath + "/task/import", Handler: tasksImportPost, Tag: "task", Summary: "import tasks"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id", Handler: taskGet, Tag: "task", Summary: "get task by id"},
+	{Method: http.MethodPut, Path: basePath + "/task", Handler: taskPut, Tag: "task", Summary: "update task by id"},
+	{Method: http.MethodDelete, Path: basePath + "/task/:id", Handler: taskDelete, Tag: "task", Summary: "delete task by id"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id/graph", Handler: taskGraphGet, Tag: "task", Summary: "get task dependency graph"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id/occurrences", Handler: taskOccurrencesGet, Tag: "task", Summary: "list upcoming occurrences"},
+	{Method: http.MethodPut, Path: basePath + "/task/:id/parent", Handler: taskParentPut, Tag: "task", Summary: "set task parent"},
+	{Method: http.MethodPost, Path: basePath + "/task/:id/blockers", Handler: taskBlockerPost, Tag: "task", Summary: "add task blocker"},
+	{Method: http.MethodDelete, Path: basePath + "/task/:id/blockers/:blockerId", Handler: taskBlockerDelete, Tag: "task", Summary: "remove task blocker"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id/comments", Handler: taskCommentsGet, Tag: "comment", Summary: "list task comments"},
+	{Method: http.MethodPost, Path: basePath + "/task/:id/comments", Handler: taskCommentPost, Tag: "comment", Summary: "add task comment"},
+	{Method: http.MethodPut, Path: basePath + "/task/:id/comments/:commentId", Handler: taskCommentPut, Tag: "comment", Summary: "edit task comment"},
+	{Method: http.MethodDelete, Path: basePath + "/task/:id/comments/:commentId", Handler: taskCommentDelete, Tag: "comment", Summary: "delete task comment"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id/attachments", Handler: taskAttachmentsGet, Tag: "attachment", Summary: "list task attachments"},
+	{Method: http.MethodPost, Path: basePath + "/task/:id/attachments", Handler: taskAttachmentPost, Tag: "attachment", Summary: "upload task attachment"},
+	{Method: http.MethodGet, Path: basePath + "/task/:id/attachments/:attachmentId", Handler: taskAttachmentGet, Tag: "attachment", Summary: "download task attachment"},
+	{Method: http.MethodDelete, Path: basePath + "/task/:id/attachments/:attachmentId", Handler: taskAttachmentDelete, Tag: "attachment", Summary: "delete task attachment"},
+	{Method: http.MethodPost, Path: basePath + "/label", Handler: labelPost, Tag: "label", Summary: "save label"},
+	{Method: http.MethodPut, Path: basePath + "/label", Handler: labelPut, Tag: "label", Summary: "update label"},
+	{Method: http.MethodGet, Path: basePath + "/label/findAll", Handler: findAllLabelsGet, Tag: "label", Summary: "Find all labels"},
+	{Method: http.MethodGet, Path: basePath + "/label/:id", Handler: labelGet, Tag: "label", Summary: "get label by id"},
+	{Method: http.MethodDelete, Path: basePath + "/label/:id", Handler: labelDelete, Tag: "label", Summary: "delete label by id"},
+	{Method: http.MethodPost, Path: basePath + "/webhook", Handler: webhookPost, Tag: "webhook", Summary: "save webhook"},
+	{Method: http.MethodPut, Path: basePath + "/webhook", Handler: webhookPut, Tag: "webhook", Summary: "update webhook"},
+	{Method: http.MethodGet, Path: basePath + "/webhook/findAll", Handler: findAllWebhooksGet, Tag: "webhook", Summary: "Find all webhooks"},
+	{Method: http.MethodGet, Path: basePath + "/webhook/:id", Handler: webhookGet, Tag: "webhook", Summary: "get webhook by id"},
+	{Method: http.MethodDelete, Path: basePath + "/webhook/:id", Handler: webhookDelete, Tag: "webhook", Summary: "delete webhook by id"},
+	{Method: http.MethodGet, Path: basePath + "/webhook/:id/deliveries", Handler: webhookDeliveriesGet, Tag: "webhook", Summary: "list webhook deliveries"},
+	{Method: http.MethodPost, Path: basePath + "/apikey", Handler: apiKeyPost, Tag: "apikey", Summary: "issue api key"},
+	{Method: http.MethodGet, Path: basePath + "/apikey/findAll", Handler: findAllApiKeysGet, Tag: "apikey", Summary: "Find all api keys"},
+	{Method: http.MethodDelete, Path: basePath + "/apikey/:id", Handler: apiKeyDelete, Tag: "apikey", Summary: "revoke api key by id"},
+	{Method: http.MethodGet, Path: "/graphql", Handler: graphqlHandler, Tag: "graphql", Summary: "GraphQL query"},
+	{Method: http.MethodPost, Path: "/graphql", Handler: graphqlHandler, Tag: "graphql", Summary: "GraphQL query or mutation"},
+	{Method: http.MethodPost, Path: basePath + "/login", Handler: loginPost, Public: true, Tag: "auth", Summary: "login"},
+	{Method: http.MethodGet, Path: "/swagger/*", Handler: echoSwagger.WrapHandler, Public: true},
+	{Method: http.MethodGet, Path: "/metrics", Handler: echo.WrapHandler(metrics.Handler()), Public: true},
+}
+
+func init() {
+	// /swagger/doc.json serves the paths of the routes table
+	swag.Register(swag.Name, swaggerDoc{})
+}
+
+// register - adds the routes to e, the protected ones behind Authorize
+func register(e *echo.Echo, routes []route) {
+	for _, r := range routes {
+		if r.Public {
+			e.Add(r.Method, r.Path, r.Handler)
+		} else {
+			e.Add(r.Method, r.Path, r.Handler, Authorize)
+		}
+	}
+}
+
+// queryTokenRoutes - method and path of the routes with QueryToken
+var queryTokenRoutes = func() map[string]bool {
+	out := map[string]bool{}
+	for _, r := range routes {
+		if r.QueryToken {
+			out[r.Method+" "+r.Path] = true
+		}
+	}
+	return out
+}()
+
+// protectedRoutes - the routes that need a casbin rule
+func protectedRoutes(routes []route) []security.Route {
+	out := []security.Route{}
+	for _, r := range routes {
+		if !r.Public {
+			out = append(out, security.Route{Method: r.Method, Path: r.Path})
+		}
+	}
+	return out
+}
+
+// routeRoles - roles allowed on each protected route by the policy of e,
+// keyed by method and path. Fails with the routes no role is allowed on.
+func routeRoles(e *casbin.Enforcer, routes []route) (map[string][]string, error) {
+
+	out := map[string][]string{}
+	uncovered := []string{}
+
+	for _, r := range protectedRoutes(routes) {
+		roles, err := security.AllowedRoles(e, r)
+		if err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 {
+			uncovered = append(uncovered, r.String())
+		}
+		out[r.String()] = roles
+	}
+
+	if len(uncovered) > 0 {
+		return nil, fmt.Errorf("routes without authorization rule: %s", strings.Join(uncovered, ", "))
+	}
+
+	return out, nil
+}
+
+var echoParam = regexp.MustCompile(`:([^/]+)`)
+
+// swaggerDoc - swagger 2.0 doc of the routes under basePath, each protected
+// operation lists in x-roles the roles allowed on it
+type swaggerDoc struct{}
+
+func (swaggerDoc) ReadDoc() string {
+
+	roles, err := routeRoles(security.Enforcer(), routes)
+	if err != nil {
+		loggerf.WithError(err).Error("routes without authorization rule")
+	}
+
+	paths := map[string]map[string]interface{}{}
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, basePath+"/") {
+			continue
+		}
+
+		path := echoParam.ReplaceAllString(strings.TrimPrefix(r.Path, basePath), "{$1}")
+		params := []map[string]interface{}{}
+		for _, m := range echoParam.FindAllStringSubmatch(r.Path, -1) {
+			params = append(params, map[string]interface{}{"name": m[1], "in": "path", "required": true, "type": "string"})
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		operation := map[string]interface{}{
+			"tags":       []string{r.Tag},
+			"summary":    r.Summary,
+			"parameters": params,
+			"responses":  map[string]interface{}{"200": map[string]string{"description": "OK"}},
+		}
+		if !r.Public {
+			operation["x-roles"] = roles[security.Route{Method: r.Method, Path: r.Path}.String()]
+		}
+		paths[path][strings.ToLower(r.Method)] = operation
+	}
+
+	doc, _ := json.Marshal(map[string]interface{}{
+		"swagger":  "2.0",
+		"info":     map[string]string{"title": "Swagger Example API", "version": "1.0"},
+		"host":     "localhost:1323",
+		"basePath": basePath,
+		"paths":    paths,
+	})
+	return string(doc)
+}
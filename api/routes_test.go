@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoutes_Annotations - the @Router annotations of the handlers are the
+// paths of the routes table
+func TestRoutes_Annotations(t *testing.T) {
+
+	files, _ := filepath.Glob("*.go")
+	annotation := regexp.MustCompile(`@Router (\S+) \[(\w+)\]`)
+
+	annotated := map[string]bool{}
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		assert.NoError(t, err)
+		for _, m := range annotation.FindAllStringSubmatch(string(src), -1) {
+			annotated[strings.ToUpper(m[2])+" "+m[1]] = true
+		}
+	}
+
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, basePath+"/") {
+			continue
+		}
+		key := r.Method + " " + echoParam.ReplaceAllString(strings.TrimPrefix(r.Path, basePath), "{$1}")
+		assert.True(t, annotated[key], "route %s has no @Router annotation", key)
+		delete(annotated, key)
+	}
+	assert.Empty(t, annotated, "annotations without route")
+}
+
+func TestRouteRoles(t *testing.T) {
+
+	roles, err := routeRoles(security.Enforcer(), routes)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ROL_1"}, roles["DELETE /api/v1/task/:id"])
+	assert.Equal(t, []string{"ROL_1", "ROL_2", "ROL_READ"}, roles["GET /api/v1/task/findAll"])
+
+	policy := filepath.Join(t.TempDir(), "policy.csv")
+	os.WriteFile(policy, []byte("p, ROL_1, *, /api/v1/task/*, GET\n"), 0o600)
+	e, err := security.LoadEnforcer(os.Getenv("BASE_PATH")+"/security/casbin_model.conf", policy)
+	assert.NoError(t, err)
+
+	_, err = routeRoles(e, routes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "POST /api/v1/task")
+	}
+}
+
+func TestRouter_SwaggerAndAuthorization(t *testing.T) {
+	srv := httptest.NewServer(newRouter())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/swagger/doc.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	doc := struct {
+		BasePath string                                       `json:"basePath"`
+		Paths    map[string]map[string]map[string]interface{} `json:"paths"`
+	}{}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&doc))
+	assert.Equal(t, "/api/v1", doc.BasePath)
+	assert.Equal(t, []interface{}{"ROL_1", "ROL_2", "ROL_READ"}, doc.Paths["/task/findAll"]["get"]["x-roles"])
+	assert.Contains(t, doc.Paths, "/task/{id}/comments/{commentId}")
+	assert.NotContains(t, doc.Paths, "/tasks/findAll")
+
+	// every protected route asks for a user
+	res, err = http.Post(srv.URL+"/api/v1/task", "application/json", strings.NewReader(`{}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, errs.Unauthorized.Code, res.StatusCode)
+		res.Body.Close()
+	}
+
+	res, err = http.Get(srv.URL + "/metrics")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		res.Body.Close()
+	}
+}
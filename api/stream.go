@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/stream"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// heartbeatInterval - time between SSE comments that keep idle proxies from
+// closing the connection
+const heartbeatInterval = 15 * time.Second
+
+// stream task events (SSE)
+// @Summary stream task events
+// @tags task
+// @Description envía los cambios de tareas como Server-Sent Events; el id de cada evento es su seq y se reanuda con Last-Event-ID, con un evento stream.resync primero si se perdieron eventos ya borrados
+// @ID taskEventsGet
+// @Produce  text/event-stream
+// @Param state query string false "comma-separated states, e.g. PENDING,IN_PROGRESS"
+// @Param Last-Event-ID header int false "seq of the last event received"
+// @Param lastEventId query int false "seq of the last event received, alternative to the header"
+// @Param access_token query string false "user token, for clients that cannot set the Authorization header"
+// @Success 200  {object} events.Event
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Router /task/events [get]
+func taskEventsGet(c echo.Context) error {
+
+	filter, lastSeq, err := streamRequest(c)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	}
+
+	ctx := c.Request().Context()
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "retry: 3000\n\n")
+	res.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	ch := stream.Open(ctx, events.Default(), lastSeq, filter)
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+			res.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(res, ": ping\n\n")
+			res.Flush()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// stream task events (WebSocket)
+// @Summary stream task events over WebSocket
+// @tags task
+// @Description envía los cambios de tareas como mensajes JSON por WebSocket; se reanuda con lastEventId, con un evento stream.resync primero si se perdieron eventos ya borrados
+// @ID taskEventsWs
+// @Param state query string false "comma-separated states, e.g. PENDING,IN_PROGRESS"
+// @Param lastEventId query int false "seq of the last event received"
+// @Param access_token query string false "user token, for clients that cannot set the Authorization header"
+// @Success 101  {object} events.Event
+// @Failure 400 {object}  errors.CustomError
+// @Failure 401 {object}  errors.CustomError
+// @Router /task/events/ws [get]
+func taskEventsWs(c echo.Context) error {
+
+	filter, lastSeq, err := streamRequest(c)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	}
+
+	// the caller is authenticated by token, so the origin is not checked
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		ctx, cancel := context.WithCancel(c.Request().Context())
+		defer cancel()
+
+		// the client only sends to close; reading detects it
+		go func() {
+			var msg string
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+			cancel()
+		}()
+
+		for e := range stream.Open(ctx, events.Default(), lastSeq, filter) {
+			if err := websocket.JSON.Send(ws, e); err != nil {
+				return
+			}
+		}
+	}}
+
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// streamRequest - the authorized caller's filter and the seq to resume from
+func streamRequest(c echo.Context) (stream.Filter, int64, error) {
+
+	au, ok := security.UserFromContext(c.Request().Context())
+	if !ok {
+		return stream.Filter{}, 0, errs.Unauthorized
+	}
+	if !security.IsAuthorized(au, http.MethodGet, c.Request().URL.Path) {
+		return stream.Filter{}, 0, errs.Unauthorized.SetMessage("Without privileges for this function")
+	}
+
+	filter := stream.Filter{
+		// only the events of the tasks of its center the caller may read
+		Allow: func(e events.Event) bool {
+			return stream.Center(e) == au.CenterCode &&
+				security.IsAuthorized(au, http.MethodGet, fmt.Sprintf("/api/v1/task/%d", e.TaskId))
+		},
+	}
+
+	if v := c.QueryParam("state"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			switch s {
+			case enums.PendingTaskStatus, enums.InProgressTaskStatus, enums.CompletedTaskStatus:
+				filter.States = append(filter.States, s)
+			default:
+				return stream.Filter{}, 0, errs.TaskStateInvalid
+			}
+		}
+	}
+
+	last := c.Request().Header.Get("Last-Event-ID")
+	if last == "" {
+		last = c.QueryParam("lastEventId")
+	}
+	var lastSeq int64
+	if last != "" {
+		var err error
+		if lastSeq, err = strconv.ParseInt(last, 10, 64); err != nil || lastSeq < 0 {
+			return stream.Filter{}, 0, errs.BadRequest.SetMessage("Invalid Last-Event-ID")
+		}
+	}
+
+	return filter, lastSeq, nil
+}
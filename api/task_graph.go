@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/labstack/echo/v4"
+)
+
+// get task graph
+// @Summary get task dependency graph
+// @tags task
+// @Description obtiene la tarea padre, las subtareas, los bloqueos y las tareas bloqueadas de un task
+// @ID taskGraphGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} task.GetTaskGraphResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/graph [get]
+func taskGraphGet(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := task.TaskService{}.GetTaskGraph(c.Request().Context(), task.GetTaskGraphRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// set task parent
+// @Summary set task parent
+// @tags task
+// @Description convierte un task en subtarea de otro; parent_id 0 lo deja sin padre
+// @ID taskParentPut
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Param SetParentRequest body task.SetParentRequest true "parent"
+// @Success 200  {object} task.SetParentResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 409 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/parent [put]
+func taskParentPut(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskParentPut")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := task.SetParentRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.Id = int32(idInt)
+
+	res, err := task.TaskService{}.SetParent(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// add task blocker
+// @Summary add task blocker
+// @tags task
+// @Description indica que blocker_id bloquea al task
+// @ID taskBlockerPost
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Param AddBlockerRequest body task.AddBlockerRequest true "blocker"
+// @Success 200  {object} task.AddBlockerResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 409 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/blockers [post]
+func taskBlockerPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "taskBlockerPost")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := task.AddBlockerRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.Id = int32(idInt)
+
+	res, err := task.TaskService{}.AddBlocker(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// remove task blocker
+// @Summary remove task blocker
+// @tags task
+// @Description elimina el bloqueo de blockerId sobre el task
+// @ID taskBlockerDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Param blockerId path string true "Blocker Id"
+// @Success 200  {object} task.RemoveBlockerResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /task/{id}/blockers/{blockerId} [delete]
+func taskBlockerDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	blockerInt, err := strconv.Atoi(c.Param("blockerId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := task.RemoveBlockerRequest{Id: int32(idInt), BlockerId: int32(blockerInt)}
+
+	res, err := task.TaskService{}.RemoveBlocker(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
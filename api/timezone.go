@@ -0,0 +1,36 @@
+package main
+
+import (
+	// zone database embedded for hosts without tzdata
+	_ "time/tzdata"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/labstack/echo/v4"
+)
+
+// timeZoneHeader - alternative to the tz query param, e.g. "Time-Zone: Europe/Madrid"
+const timeZoneHeader = "Time-Zone"
+
+// TimeZone - reads the caller's IANA time zone from ?tz= or the Time-Zone
+// header, used to render due dates and to read dates without offset.
+func TimeZone(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.QueryParam("tz")
+		if name == "" {
+			name = c.Request().Header.Get(timeZoneHeader)
+		}
+		if name == "" {
+			return next(c)
+		}
+
+		loc, err := task.LoadLocation(name)
+		if err != nil {
+			ce := err.(errs.CustomError)
+			return c.JSON(ce.Code, ce)
+		}
+
+		c.SetRequest(c.Request().WithContext(task.WithLocation(c.Request().Context(), loc)))
+		return next(c)
+	}
+}
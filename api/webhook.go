@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/webhook"
+	"github.com/labstack/echo/v4"
+)
+
+// find all webhooks
+// @Summary Find all webhooks
+// @tags webhook
+// @Description obtiene todos los webhooks, sin sus secretos
+// @ID findAllWebhooksGet
+// @Accept  json
+// @Produce  json
+// @Success 200  {object} webhook.FindAllWebhooksResponse
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook/findAll [get]
+func findAllWebhooksGet(c echo.Context) error {
+
+	res, err := webhook.WebhookService{}.FindAllWebhooks(c.Request().Context())
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// get webhook
+// @Summary get webhook by id
+// @tags webhook
+// @Description obtiene un webhook por id, sin su secreto
+// @ID webhookGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} webhook.GetWebhookResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook/{id} [get]
+func webhookGet(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := webhook.WebhookService{}.GetWebhook(c.Request().Context(), webhook.GetWebhookRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// save webhook
+// @Summary save webhook
+// @tags webhook
+// @Description crea un webhook y devuelve el secreto con el que se firman sus entregas
+// @ID webhookPost
+// @Accept  json
+// @Produce  json
+// @Param SaveWebhookRequest body webhook.SaveWebhookRequest true "webhook"
+// @Success 200  {object} webhook.SaveWebhookResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook [post]
+func webhookPost(c echo.Context) error {
+
+	log := loggerf.WithField("func", "webhookPost")
+
+	req := webhook.SaveWebhookRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := webhook.WebhookService{}.SaveWebhook(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// update webhook
+// @Summary update webhook
+// @tags webhook
+// @Description actualiza un webhook; el secreto solo cambia si se indica
+// @ID webhookPut
+// @Accept  json
+// @Produce  json
+// @Param UpdateWebhookRequest body webhook.UpdateWebhookRequest true "webhook"
+// @Success 200  {object} webhook.UpdateWebhookResponse
+// @Failure 400 {object}  errors.CustomError
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook [put]
+func webhookPut(c echo.Context) error {
+
+	log := loggerf.WithField("func", "webhookPut")
+
+	req := webhook.UpdateWebhookRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := webhook.WebhookService{}.UpdateWebhook(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// delete webhook
+// @Summary delete webhook by id
+// @tags webhook
+// @Description elimina un webhook y su registro de entregas
+// @ID webhookDelete
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Success 200  {object} webhook.DeleteWebhookResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook/{id} [delete]
+func webhookDelete(c echo.Context) error {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	res, err := webhook.WebhookService{}.DeleteWebhook(c.Request().Context(), webhook.DeleteWebhookRequest{Id: int32(idInt)})
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// list webhook deliveries
+// @Summary list webhook deliveries
+// @tags webhook
+// @Description obtiene los últimos intentos de entrega de un webhook
+// @ID webhookDeliveriesGet
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id"
+// @Param limit query int false "max deliveries (default 50, max 500)"
+// @Success 200  {object} webhook.ListDeliveriesResponse
+// @Failure 404 {object}  errors.CustomError
+// @Failure 500 {object}  errors.CustomError
+// @Router /webhook/{id}/deliveries [get]
+func webhookDeliveriesGet(c echo.Context) error {
+
+	log := loggerf.WithField("func", "webhookDeliveriesGet")
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	req := webhook.ListDeliveriesRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		log.WithError(err).Error("Binding error")
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	req.Id = int32(idInt)
+
+	res, err := webhook.WebhookService{}.ListDeliveries(c.Request().Context(), req)
+	if ce, ok := err.(errs.CustomError); ok {
+		return c.JSON(ce.Code, err)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -1,28 +1,152 @@
 package base
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+var loggerf = log.LoggerJSON().WithField("package", "base")
+
+// Supported database drivers, selected with DB_DRIVER.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// defaultSQLiteDSN is an in-memory database shared by every connection.
+const defaultSQLiteDSN = "file:test-cleverit?mode=memory&cache=shared"
+
 var db *gorm.DB
 
 func init() {
-	var err error
+	driver := os.Getenv("DB_DRIVER")
 	dsn := os.Getenv("MYSQL_CONNECTION")
 
-	db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if driver == DriverSQLite {
+		dsn = os.Getenv("SQLITE_PATH")
+	}
+
+	if err := Connect(driver, dsn); err != nil {
+		loggerf.WithError(err).Error("Error connecting to database")
+	}
+
+}
+
+// Connect opens the database for driver (mysql by default) and replaces the
+// connection returned by GetDB. SQLite databases get their schema created from
+// the GORM models, MySQL uses db/scripts.
+func Connect(driver string, dsn string) error {
+	var dialector gorm.Dialector
+
+	switch driver {
+	case DriverMySQL, "":
+		dialector = mysql.Open(mysqlDSN(dsn))
+	case DriverSQLite:
+		if dsn == "" {
+			dsn = defaultSQLiteDSN
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return fmt.Errorf("unknown database driver %q", driver)
+	}
+
+	conn, err := gorm.Open(dialector, &gorm.Config{})
+	db = conn
 
 	if err != nil {
-		fmt.Errorf("Error connecting to database : error=%v", err)
+		return err
+	}
+
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		loggerf.WithError(err).Error("Error registering metrics plugin")
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		loggerf.WithError(err).Error("Error registering tracing plugin")
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if driver == DriverSQLite {
+			// A single connection avoids "database is locked" errors.
+			sqlDB.SetMaxOpenConns(1)
+		}
+		if err := metrics.RegisterDB(sqlDB, "tasks"); err != nil {
+			loggerf.WithError(err).Error("Error registering database pool metrics")
+		}
 	}
 
+	if driver == DriverSQLite {
+		return db.AutoMigrate(model.All()...)
+	}
+
+	return nil
+}
+
+// Migrate creates or updates the schema of the GORM models on the current
+// connection, whatever its driver
+func Migrate(ctx context.Context) error {
+	return DB(ctx).AutoMigrate(model.All()...)
+}
+
+// mysqlDSN makes the driver scan DATETIME columns into time.Time and read and
+// write them as UTC, whatever the DSN says.
+func mysqlDSN(dsn string) string {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		// gorm reports the invalid DSN when opening it
+		return dsn
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	return cfg.FormatDSN()
 }
 
 // GetDB gets connection to DB with Gorm
 func GetDB() *gorm.DB {
 	return db
 }
+
+type txKey struct{}
+
+// Transaction runs fn inside a transaction. DAOs called with the context
+// passed to fn join the transaction; a nested call opens a savepoint.
+func Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DB(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// DB gets the transaction started by Transaction on ctx, or the connection
+// to DB when there is none, bound to ctx
+func DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx whose DAO queries only see the data of
+// the center tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant gets the center set by WithTenant on ctx, false when the queries
+// of ctx are not scoped, like those of the background workers
+func Tenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
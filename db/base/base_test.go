@@ -2,6 +2,7 @@ package base
 
 import (
 	"log"
+	"strings"
 	"time"
 
 	"testing"
@@ -28,3 +29,26 @@ func TestGetTime(t *testing.T) {
 	log.Println("Hora  : ", savetrxTime)
 
 }
+
+func TestConnect_SQLite(t *testing.T) {
+
+	if err := Connect(DriverSQLite, ""); err != nil {
+		t.Fatalf("fails to connect to sqlite: %v", err)
+	}
+
+	result := []m.Task{}
+
+	if err := GetDB().Find(&result).Error; err != nil {
+		t.Fatalf("fails to query tasks: %v", err)
+	}
+
+}
+
+func TestMySQLDSN_UTC(t *testing.T) {
+
+	dsn := mysqlDSN("root:123456@tcp(localhost:3306)/TEST?loc=Local")
+
+	if !strings.Contains(dsn, "parseTime=true") || strings.Contains(dsn, "loc=Local") {
+		t.Fatalf("unexpected dsn %q", dsn)
+	}
+}
@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// ApiKeyDAO - ApiKey dao interface
+type ApiKeyDAO interface {
+	FindAll(ctx context.Context) ([]model.ApiKey, error)
+	Get(ctx context.Context, id int32) (model.ApiKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (model.ApiKey, error)
+	Save(ctx context.Context, key *model.ApiKey) error
+	Revoke(ctx context.Context, id int32, at time.Time) error
+	Touch(ctx context.Context, id int32, at time.Time) error
+}
+
+var _ ApiKeyDAO = (*ApiKeyDAOImpl)(nil)
+
+// ApiKeyDAOImpl - ApiKey dao implementation. With a tenant in ctx only the
+// keys of its center are seen.
+type ApiKeyDAOImpl struct {
+}
+
+// NewApiKeyDAO - gets an ApiKeyDAOImpl instance
+func NewApiKeyDAO() *ApiKeyDAOImpl {
+	return &ApiKeyDAOImpl{}
+}
+
+// FindAll - gets every key ordered by id
+func (kd *ApiKeyDAOImpl) FindAll(ctx context.Context) ([]model.ApiKey, error) {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "FindAll")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.FindAll")
+
+	keys := []model.ApiKey{}
+	err := db.Scopes(centerScope(ctx, "center_code")).Order("id").Find(&keys).Error
+	if err != nil {
+		log.WithError(err).Error("get ApiKeys fails")
+		return []model.ApiKey{}, err
+	}
+
+	return keys, nil
+
+}
+
+// Get -
+func (kd *ApiKeyDAOImpl) Get(ctx context.Context, id int32) (model.ApiKey, error) {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.Get")
+
+	key := model.ApiKey{}
+	err := db.Scopes(centerScope(ctx, "center_code")).Where("ID = ?", id).First(&key).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get ApiKey fails")
+	}
+
+	return key, err
+
+}
+
+// GetByPrefix - gets the key whose public part is prefix
+func (kd *ApiKeyDAOImpl) GetByPrefix(ctx context.Context, prefix string) (model.ApiKey, error) {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "GetByPrefix")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.GetByPrefix")
+
+	key := model.ApiKey{}
+	err := db.Where("prefix = ?", prefix).First(&key).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get ApiKey by prefix fails")
+	}
+
+	return key, err
+
+}
+
+// Save - creates key and sets its generated Id
+func (kd *ApiKeyDAOImpl) Save(ctx context.Context, key *model.ApiKey) error {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.Save")
+
+	if err := db.Create(key).Error; err != nil {
+		log.WithError(err).Error("save ApiKey fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Revoke - records at as the revocation time of the key with id, it stops
+// being accepted. A revoked key keeps its first revocation time.
+func (kd *ApiKeyDAOImpl) Revoke(ctx context.Context, id int32, at time.Time) error {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "Revoke")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.Revoke")
+
+	err := db.Model(&model.ApiKey{}).
+		Scopes(centerScope(ctx, "center_code")).
+		Where("ID = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+	if err != nil {
+		log.WithError(err).Error("revoke ApiKey fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Touch - records at as the last use of the key with id
+func (kd *ApiKeyDAOImpl) Touch(ctx context.Context, id int32, at time.Time) error {
+
+	log := loggerf.WithField("struct", "ApiKeyDAOImpl").WithField("function", "Touch")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "ApiKeyDAOImpl.Touch")
+
+	err := db.Model(&model.ApiKey{}).Where("ID = ?", id).Update("last_used_at", at).Error
+	if err != nil {
+		log.WithError(err).Error("touch ApiKey fails")
+		return err
+	}
+
+	return nil
+
+}
@@ -0,0 +1,148 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// LabelDAO - Label dao interface
+type LabelDAO interface {
+	FindAll(ctx context.Context) ([]model.Label, error)
+	Get(ctx context.Context, id int32) (model.Label, error)
+	GetByNames(ctx context.Context, names []string) ([]model.Label, error)
+	Save(ctx context.Context, label *model.Label) error
+	Update(ctx context.Context, label model.Label) error
+	Delete(ctx context.Context, id int32) error
+}
+
+var _ LabelDAO = (*LabelDAOImpl)(nil)
+
+// LabelDAOImpl - Label dao implementation
+type LabelDAOImpl struct {
+}
+
+// NewLabelDAO - gets an LabelDAOImpl instance
+func NewLabelDAO() *LabelDAOImpl {
+	return &LabelDAOImpl{}
+}
+
+// FindAll - gets every label ordered by name
+func (ld *LabelDAOImpl) FindAll(ctx context.Context) ([]model.Label, error) {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "FindAll")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.FindAll")
+
+	labels := []model.Label{}
+	err := db.Order("name").Find(&labels).Error
+
+	if err != nil {
+		log.WithError(err).Error("get Labels fails")
+		return []model.Label{}, err
+	}
+
+	return labels, nil
+
+}
+
+// Get -
+func (ld *LabelDAOImpl) Get(ctx context.Context, id int32) (model.Label, error) {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.Get")
+
+	label := model.Label{}
+	err := db.Where("ID = ?", id).First(&label).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get Label fails")
+	}
+
+	return label, err
+
+}
+
+// GetByNames - gets the labels with the given names, missing names are ignored
+func (ld *LabelDAOImpl) GetByNames(ctx context.Context, names []string) ([]model.Label, error) {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "GetByNames")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.GetByNames")
+
+	labels := []model.Label{}
+	if len(names) == 0 {
+		return labels, nil
+	}
+
+	err := db.Where("name IN ?", names).Find(&labels).Error
+	if err != nil {
+		log.WithError(err).Error("get Labels fails")
+		return []model.Label{}, err
+	}
+
+	return labels, nil
+
+}
+
+// Save - creates label and sets its generated Id
+func (ld *LabelDAOImpl) Save(ctx context.Context, label *model.Label) error {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.Save")
+
+	if err := db.Create(label).Error; err != nil {
+		log.WithError(err).Debug("save Label fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Update - empty values keep the current column value
+func (ld *LabelDAOImpl) Update(ctx context.Context, label model.Label) error {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "Update")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.Update")
+
+	err := db.Model(&model.Label{}).Where("ID = ?", label.Id).Updates(model.Label{Name: label.Name, Color: label.Color}).Error
+	if err != nil {
+		log.WithError(err).Debug("update Label fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Delete - deletes the label and detaches it from every task
+func (ld *LabelDAOImpl) Delete(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "LabelDAOImpl").WithField("function", "Delete")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "LabelDAOImpl.Delete")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("ID = ?", id).Delete(&model.Label{}).Error
+	})
+
+	if err != nil {
+		log.WithError(err).Error("delete Label fails")
+		return err
+	}
+
+	return nil
+
+}
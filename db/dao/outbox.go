@@ -0,0 +1,253 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// OutboxDAO - OutboxEvent dao interface
+type OutboxDAO interface {
+	Save(ctx context.Context, event *model.OutboxEvent) error
+	FindPending(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	FindPublishedAfter(ctx context.Context, seq int64, limit int) ([]model.OutboxEvent, error)
+	FirstSeqAfter(ctx context.Context, seq int64) (int64, error)
+	AssignSeq(ctx context.Context, id int64) (int64, error)
+	MarkDelivered(ctx context.Context, id int64, delivered string) error
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, cause string) error
+	MarkDead(ctx context.Context, id int64, at time.Time) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+var _ OutboxDAO = (*OutboxDAOImpl)(nil)
+
+// OutboxDAOImpl - OutboxEvent dao implementation
+type OutboxDAOImpl struct {
+}
+
+// NewOutboxDAO - gets an OutboxDAOImpl instance
+func NewOutboxDAO() *OutboxDAOImpl {
+	return &OutboxDAOImpl{}
+}
+
+// Save - writes event, inside the transaction of ctx when there is one
+func (od *OutboxDAOImpl) Save(ctx context.Context, event *model.OutboxEvent) error {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.Save")
+
+	if err := db.Create(event).Error; err != nil {
+		log.WithError(err).Error("save OutboxEvent fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// FindPending - gets up to limit events not published yet and not dead,
+// those with a Seq first, then the oldest
+func (od *OutboxDAOImpl) FindPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "FindPending")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.FindPending")
+
+	pending := []model.OutboxEvent{}
+	err := db.Where("published_at IS NULL AND dead_at IS NULL").Order("seq IS NULL, seq, id").Limit(limit).Find(&pending).Error
+	if err != nil {
+		log.WithError(err).Error("get pending OutboxEvents fails")
+		return []model.OutboxEvent{}, err
+	}
+
+	return pending, nil
+
+}
+
+// FindPublishedAfter - gets up to limit published events with a Seq greater
+// than seq, in Seq order
+func (od *OutboxDAOImpl) FindPublishedAfter(ctx context.Context, seq int64, limit int) ([]model.OutboxEvent, error) {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "FindPublishedAfter")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.FindPublishedAfter")
+
+	published := []model.OutboxEvent{}
+	err := db.Where("seq > ? AND published_at IS NOT NULL", seq).Order("seq").Limit(limit).Find(&published).Error
+	if err != nil {
+		log.WithError(err).Error("get published OutboxEvents fails")
+		return []model.OutboxEvent{}, err
+	}
+
+	return published, nil
+
+}
+
+// FirstSeqAfter - the lowest Seq greater than seq still in the outbox, 0
+// when there is none. Above seq+1 the events in between were purged.
+func (od *OutboxDAOImpl) FirstSeqAfter(ctx context.Context, seq int64) (int64, error) {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "FirstSeqAfter")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.FirstSeqAfter")
+
+	var first *int64
+	err := db.Model(&model.OutboxEvent{}).Where("seq > ?", seq).Select("MIN(seq)").Scan(&first).Error
+	if err != nil {
+		log.WithError(err).Error("get first OutboxEvent seq fails")
+		return 0, err
+	}
+	if first == nil {
+		return 0, nil
+	}
+
+	return *first, nil
+
+}
+
+// AssignSeq - gives the event with id the next Seq, once, and returns its
+// Seq. The relay calls it right before publishing, so the Seq follows the
+// order of publication.
+func (od *OutboxDAOImpl) AssignSeq(ctx context.Context, id int64) (int64, error) {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "AssignSeq")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.AssignSeq")
+
+	var seq int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		row := model.OutboxEvent{}
+		if err := tx.Select("id", "seq").Where("id = ?", id).First(&row).Error; err != nil {
+			return err
+		}
+		if row.Seq != nil {
+			seq = *row.Seq
+			return nil
+		}
+		var last *int64
+		if err := tx.Model(&model.OutboxEvent{}).Select("MAX(seq)").Scan(&last).Error; err != nil {
+			return err
+		}
+		seq = 1
+		if last != nil {
+			seq = *last + 1
+		}
+		return tx.Model(&model.OutboxEvent{}).Where("id = ? AND seq IS NULL", id).Update("seq", seq).Error
+	})
+	if err != nil {
+		log.WithError(err).Error("assign OutboxEvent seq fails")
+		return 0, err
+	}
+
+	return seq, nil
+
+}
+
+// MarkDelivered - records the comma-separated sinks that accepted the event
+func (od *OutboxDAOImpl) MarkDelivered(ctx context.Context, id int64, delivered string) error {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "MarkDelivered")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.MarkDelivered")
+
+	err := db.Model(&model.OutboxEvent{}).Where("id = ?", id).Update("delivered", delivered).Error
+	if err != nil {
+		log.WithError(err).Error("mark OutboxEvent delivered fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// MarkPublished - records that the event with id reached every sink
+func (od *OutboxDAOImpl) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "MarkPublished")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.MarkPublished")
+
+	err := db.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published_at": at,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	}).Error
+	if err != nil {
+		log.WithError(err).Error("mark OutboxEvent published fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// MarkFailed - records a failed attempt, the event stays pending
+func (od *OutboxDAOImpl) MarkFailed(ctx context.Context, id int64, cause string) error {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "MarkFailed")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.MarkFailed")
+
+	err := db.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": cause,
+	}).Error
+	if err != nil {
+		log.WithError(err).Error("mark OutboxEvent failed fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// MarkDead - takes the event out of the pending ones after its last attempt,
+// it is kept with its last error
+func (od *OutboxDAOImpl) MarkDead(ctx context.Context, id int64, at time.Time) error {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "MarkDead")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.MarkDead")
+
+	err := db.Model(&model.OutboxEvent{}).Where("id = ?", id).Update("dead_at", at).Error
+	if err != nil {
+		log.WithError(err).Error("mark OutboxEvent dead fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// DeletePublished - deletes the events published before the given time but
+// the one with the highest Seq, which AssignSeq continues from
+func (od *OutboxDAOImpl) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+
+	log := loggerf.WithField("struct", "OutboxDAOImpl").WithField("function", "DeletePublished")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "OutboxDAOImpl.DeletePublished")
+
+	var last *int64
+	if err := db.Model(&model.OutboxEvent{}).Select("MAX(seq)").Scan(&last).Error; err != nil {
+		log.WithError(err).Error("get last OutboxEvent seq fails")
+		return 0, err
+	}
+	if last == nil {
+		return 0, nil
+	}
+
+	tx := db.Where("published_at < ? AND seq < ?", before, *last).Delete(&model.OutboxEvent{})
+	if tx.Error != nil {
+		log.WithError(tx.Error).Error("delete published OutboxEvents fails")
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+
+}
@@ -2,24 +2,168 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Alonso-Arias/test-cleverit/db/base"
 	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
 	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var loggerf = log.LoggerJSON().WithField("package", "dao")
 
 // TaskDAO - Task dao interface
 type TaskDAO interface {
-	FindAll(ctx context.Context) (model.Task, error)
-	Get(ctx context.Context, sku string) (model.Task, error)
+	FindAll(ctx context.Context) ([]model.Task, error)
+	Find(ctx context.Context, filter TaskFilter) ([]model.Task, error)
+	Count(ctx context.Context, filter TaskFilter) (int64, error)
+	Stream(ctx context.Context, filter TaskFilter, fn func(model.Task) error) error
+	Get(ctx context.Context, id int32) (model.Task, error)
+	Lock(ctx context.Context, id int32) (model.Task, error)
 	Delete(ctx context.Context, id int32) error
 	Update(ctx context.Context, task model.Task) error
-	Save(ctx context.Context, task model.Task) error
+	Save(ctx context.Context, task *model.Task) error
+	GetByIds(ctx context.Context, ids []int32) ([]model.Task, error)
+	ReplaceLabels(ctx context.Context, id int32, labels []model.Label) error
+	ClearRecurrence(ctx context.Context, id int32) error
+	FullTextSearch(ctx context.Context, query string, limit int) ([]TaskScore, error)
+	SaveAll(ctx context.Context, tasks []model.Task, allOrNothing bool) ([]BulkResult, error)
+	UpdateStateAll(ctx context.Context, ids []int32, state string, allOrNothing bool, check TaskCheck) ([]BulkResult, error)
+	DeleteAll(ctx context.Context, ids []int32, allOrNothing bool, check TaskCheck) ([]BulkResult, error)
+	Children(ctx context.Context, id int32) ([]model.Task, error)
+	SetParent(ctx context.Context, id int32, parentId *int32) error
+	Blockers(ctx context.Context, id int32) ([]model.Task, error)
+	Blocking(ctx context.Context, id int32) ([]model.Task, error)
+	AddBlocker(ctx context.Context, id int32, blockerId int32) error
+	RemoveBlocker(ctx context.Context, id int32, blockerId int32) error
+	IncompleteDependencies(ctx context.Context, id int32) (subtasks int64, blockers int64, err error)
+	Completable(ctx context.Context, id int32) error
+	MarkOverdue(ctx context.Context, now time.Time) ([]model.Task, error)
+	MarkDueSoon(ctx context.Context, now time.Time, until time.Time) ([]model.Task, error)
+	ResetReminders(ctx context.Context, id int32) error
 }
 
+var _ TaskDAO = (*TaskDAOImpl)(nil)
+
+// ErrBulkRolledBack - reported for items that succeeded but were rolled back
+// because another item of an all-or-nothing bulk operation failed
+var ErrBulkRolledBack = errors.New("rolled back by all-or-nothing bulk operation")
+
+// ErrTaskWithoutCenter - returned when a task would be created without a
+// center, no tenant could ever see it
+var ErrTaskWithoutCenter = errors.New("task without center")
+
+// BulkResult - outcome of one item of a bulk operation
+type BulkResult struct {
+	Id            int32
+	PreviousState string
+	Err           error
+}
+
+// TaskCheck - check of the bulk operations on each task, read under a row
+// lock inside the transaction before it changes; an error fails the item
+type TaskCheck func(task model.Task) error
+
+// TaskScore - relevance of a task for a full-text query
+type TaskScore struct {
+	Id    int32
+	Score float64
+}
+
+// TaskFilter - criteria used to find tasks, zero values are ignored
+type TaskFilter struct {
+	State    string
+	Priority string
+	Label    string
+	DueFrom  time.Time
+	DueTo    time.Time
+	// Sort is one of the TaskSorts keys, id by default
+	Sort string
+	// Limit is the maximum number of tasks, 0 means every task; Offset
+	// skips the first tasks
+	Limit  int
+	Offset int
+}
+
+// priorityRank orders priorities from lowest to highest
+var priorityRank = func() string {
+	expr := "CASE priority"
+	for i, p := range enums.TaskPriorities {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", p, i+1)
+	}
+	return expr + " ELSE 0 END"
+}()
+
+// TaskSorts - supported TaskFilter.Sort values, "-" means descending
+var TaskSorts = map[string]string{
+	"id":        "id",
+	"priority":  priorityRank + ", id",
+	"-priority": priorityRank + " DESC, id",
+	"due_date":  "due_date, id",
+	"-due_date": "due_date DESC, id",
+}
+
+func (f TaskFilter) where(db *gorm.DB) *gorm.DB {
+	if f.State != "" {
+		db = db.Where("state = ?", f.State)
+	}
+	if f.Priority != "" {
+		db = db.Where("priority = ?", f.Priority)
+	}
+	if f.Label != "" {
+		labeled := db.Session(&gorm.Session{NewDB: true}).
+			Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Where("labels.name = ?", f.Label)
+		db = db.Where("id IN (?)", labeled)
+	}
+	if !f.DueFrom.IsZero() {
+		db = db.Where("due_date >= ?", f.DueFrom)
+	}
+	if !f.DueTo.IsZero() {
+		db = db.Where("due_date <= ?", f.DueTo)
+	}
+	return db
+}
+
+func (f TaskFilter) apply(db *gorm.DB) *gorm.DB {
+	order, ok := TaskSorts[f.Sort]
+	if !ok {
+		order = TaskSorts["id"]
+	}
+	db = f.where(db).Order(order)
+	if f.Limit > 0 {
+		db = db.Limit(f.Limit).Offset(f.Offset)
+	}
+	return db
+}
+
+// tenantScope - limits the task queries of ctx to the tasks of its tenant,
+// see base.WithTenant. Queries without tenant see every task.
+func tenantScope(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return centerScope(ctx, "tasks.center_code")
+}
+
+// centerScope - limits the queries of ctx to the rows whose column is the
+// center of its tenant
+func centerScope(ctx context.Context, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenant, ok := base.Tenant(ctx); ok {
+			return db.Where(column+" = ?", tenant)
+		}
+		return db
+	}
+}
+
+// streamBatchSize - tasks loaded per query by Stream
+const streamBatchSize = 200
+
 // TaskDAOImpl - Task dao implementation
 type TaskDAOImpl struct {
 }
@@ -31,13 +175,18 @@ func NewTaskDAO() *TaskDAOImpl {
 
 // FindAll -
 func (pd *TaskDAOImpl) FindAll(ctx context.Context) ([]model.Task, error) {
+	return pd.Find(ctx, TaskFilter{})
+}
+
+// Find - gets the tasks matching filter
+func (pd *TaskDAOImpl) Find(ctx context.Context, filter TaskFilter) ([]model.Task, error) {
 
-	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "FindAll")
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Find")
 
-	db := base.GetDB()
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Find")
 
 	tasks := []model.Task{}
-	err := db.Find(&tasks).Error
+	err := filter.apply(db.Scopes(tenantScope(ctx)).Preload("Labels")).Find(&tasks).Error
 
 	if err != nil {
 		log.WithError(err).Error("get Tasks fails")
@@ -46,21 +195,136 @@ func (pd *TaskDAOImpl) FindAll(ctx context.Context) ([]model.Task, error) {
 		return []model.Task{}, gorm.ErrRecordNotFound
 	}
 
-	log.Debugf("%v", tasks)
+	log.WithField("tasks", tasks).Debug("tasks found")
 
 	return tasks, nil
 
 }
 
+// Count - counts the tasks matching filter, ignoring Limit and Offset
+func (pd *TaskDAOImpl) Count(ctx context.Context, filter TaskFilter) (int64, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Count")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Count")
+
+	var total int64
+	if err := filter.where(db.Model(&model.Task{}).Scopes(tenantScope(ctx))).Count(&total).Error; err != nil {
+		log.WithError(err).Error("count Tasks fails")
+		return 0, err
+	}
+
+	return total, nil
+
+}
+
+// Stream - calls fn for every task matching filter, in the order of
+// filter.Sort, loading streamBatchSize tasks at a time. Each batch starts
+// after the last task read (keyset paging), so changes made meanwhile do not
+// skip or repeat tasks. Iteration stops at the first error returned by fn.
+func (pd *TaskDAOImpl) Stream(ctx context.Context, filter TaskFilter, fn func(model.Task) error) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Stream")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Stream")
+
+	order, ok := TaskSorts[filter.Sort]
+	if !ok {
+		order = TaskSorts["id"]
+	}
+
+	err := func() error {
+		var last *model.Task
+		read := 0
+		for filter.Limit == 0 || read < filter.Limit {
+			size := streamBatchSize
+			if filter.Limit > 0 && filter.Limit-read < size {
+				size = filter.Limit - read
+			}
+
+			tx := filter.where(db.Scopes(tenantScope(ctx)).Preload("Labels")).Order(order)
+			if last != nil {
+				tx = streamAfter(tx, filter.Sort, *last)
+			} else if filter.Limit > 0 {
+				tx = tx.Offset(filter.Offset)
+			}
+
+			batch := []model.Task{}
+			if err := tx.Limit(size).Find(&batch).Error; err != nil {
+				return err
+			}
+			for _, task := range batch {
+				if err := fn(task); err != nil {
+					return err
+				}
+			}
+
+			if len(batch) < size {
+				return nil
+			}
+			read += len(batch)
+			last = &batch[len(batch)-1]
+		}
+		return nil
+	}()
+
+	if err != nil {
+		log.WithError(err).Error("stream Tasks fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// streamKey - leading sort expression of a TaskSorts key, its direction and
+// the value of a task for it; ties are ordered by id
+type streamKey struct {
+	expr  string
+	desc  bool
+	value func(model.Task) interface{}
+}
+
+var streamKeys = map[string]streamKey{
+	"priority":  {priorityRank, false, func(t model.Task) interface{} { return priorityOf(t.Priority) }},
+	"-priority": {priorityRank, true, func(t model.Task) interface{} { return priorityOf(t.Priority) }},
+	"due_date":  {"due_date", false, func(t model.Task) interface{} { return t.DueDate }},
+	"-due_date": {"due_date", true, func(t model.Task) interface{} { return t.DueDate }},
+}
+
+// streamAfter - keeps the tasks that come after last in the order of sort
+func streamAfter(db *gorm.DB, sort string, last model.Task) *gorm.DB {
+	key, ok := streamKeys[sort]
+	if !ok {
+		return db.Where("id > ?", last.Id)
+	}
+	op := ">"
+	if key.desc {
+		op = "<"
+	}
+	v := key.value(last)
+	return db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id > ?))", key.expr, op, key.expr), v, v, last.Id)
+}
+
+// priorityOf - value of priorityRank for priority
+func priorityOf(priority string) int {
+	for i, p := range enums.TaskPriorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
 // FindAll -
 func (pd *TaskDAOImpl) Get(ctx context.Context, id int32) (model.Task, error) {
 
 	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Get")
 
-	db := base.GetDB()
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Get")
 
 	task := model.Task{}
-	err := db.Where("ID = ?", id).FirstOrInit(&task).Error
+	err := db.Scopes(tenantScope(ctx)).Preload("Labels").Where("ID = ?", id).FirstOrInit(&task).Error
 
 	if err != nil {
 		log.WithError(err).Error("get Tasks fails")
@@ -69,25 +333,148 @@ func (pd *TaskDAOImpl) Get(ctx context.Context, id int32) (model.Task, error) {
 		return model.Task{}, gorm.ErrRecordNotFound
 	}
 
-	log.Debugf("%v", task)
+	log.WithField("task", task).Debug("task found")
 
 	return task, nil
 
 }
 
+// Lock - gets the task with id, without its labels, and locks its row until
+// the transaction of ctx ends
+func (pd *TaskDAOImpl) Lock(ctx context.Context, id int32) (model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Lock")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Lock")
+
+	task := model.Task{}
+	err := db.Scopes(tenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).Where("ID = ?", id).First(&task).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			log.WithError(err).Error("lock Task fails")
+		}
+		return model.Task{}, err
+	}
+
+	return task, nil
+
+}
+
+// ClearRecurrence - makes the task with id a one-off task, Update keeps the
+// recurrence when it is empty
+func (pd *TaskDAOImpl) ClearRecurrence(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "ClearRecurrence")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.ClearRecurrence")
+
+	err := db.Model(&model.Task{}).Scopes(tenantScope(ctx)).Where("ID = ?", id).
+		Updates(map[string]interface{}{"recurrence": "", "time_zone": ""}).Error
+	if err != nil {
+		log.WithError(err).Error("clear Task recurrence fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// ReplaceLabels - sets labels as the only labels of the task with id
+func (pd *TaskDAOImpl) ReplaceLabels(ctx context.Context, id int32, labels []model.Label) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "ReplaceLabels")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.ReplaceLabels")
+
+	// the association ignores scopes, the task is looked up first
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(tenantScope(ctx)).Select("id").Where("ID = ?", id).First(&model.Task{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Task{Id: id}).Association("Labels").Replace(labels)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return err
+	} else if err != nil {
+		log.WithError(err).Error("replace Task labels fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// GetByIds - gets the tasks with the given ids, in no particular order
+func (pd *TaskDAOImpl) GetByIds(ctx context.Context, ids []int32) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "GetByIds")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.GetByIds")
+
+	tasks := []model.Task{}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	err := db.Scopes(tenantScope(ctx)).Preload("Labels").Where("ID IN ?", ids).Find(&tasks).Error
+	if err != nil {
+		log.WithError(err).Error("get Tasks fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// FullTextSearch - ranks tasks with the MySQL FULLTEXT index on title and
+// description (natural language mode)
+func (pd *TaskDAOImpl) FullTextSearch(ctx context.Context, query string, limit int) ([]TaskScore, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "FullTextSearch")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.FullTextSearch")
+
+	match := "MATCH(title, description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	scores := []TaskScore{}
+	err := db.Model(&model.Task{}).
+		Scopes(tenantScope(ctx)).
+		Select("id, "+match+" AS score", query).
+		Where(match, query).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&scores).Error
+
+	if err != nil {
+		log.WithError(err).Error("full-text search fails")
+		return []TaskScore{}, err
+	}
+
+	return scores, nil
+
+}
+
 // FindAll -
 func (pd *TaskDAOImpl) Delete(ctx context.Context, id int32) error {
 
 	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Delete")
 
-	db := base.GetDB()
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Delete")
 
 	// inits tx
 	err := db.Transaction(func(tx *gorm.DB) error {
 
-		task := model.Task{}
+		task := model.Task{Id: id}
+
+		if err := tx.Scopes(tenantScope(ctx)).Select("id").Where("ID = ?", id).First(&model.Task{}).Error; err != nil {
+			return err
+		}
+
+		if err := detach(tx, id); err != nil {
+			return err
+		}
 
-		err := db.Where("ID = ?", id).Delete(&task).Error
+		err := tx.Select("Labels").Delete(&task).Error
 		if err != nil {
 			log.WithError(err).Error("problems with deleting Task")
 			return err
@@ -96,7 +483,9 @@ func (pd *TaskDAOImpl) Delete(ctx context.Context, id int32) error {
 		return nil
 	})
 
-	if err != nil {
+	if err == gorm.ErrRecordNotFound {
+		return err
+	} else if err != nil {
 		log.WithError(err).Error("fails to delete order")
 		return err
 	}
@@ -111,35 +500,69 @@ func (pd *TaskDAOImpl) Update(ctx context.Context, task model.Task) error {
 
 	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Update")
 
-	db := base.GetDB()
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Update")
+
+	// empty values keep the current column value
+	updates := map[string]interface{}{}
+	if task.Title != "" {
+		updates["title"] = task.Title
+	}
+	if task.Description != "" {
+		updates["description"] = task.Description
+	}
+	if !task.DueDate.IsZero() {
+		updates["due_date"] = task.DueDate
+	}
+	if task.State != "" {
+		updates["state"] = task.State
+	}
+	if task.Priority != "" {
+		updates["priority"] = task.Priority
+	}
+	if task.Recurrence != "" {
+		updates["recurrence"] = task.Recurrence
+	}
+	if task.TimeZone != "" {
+		updates["time_zone"] = task.TimeZone
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
 
-	tx := db.Model(&task).
+	tx := db.Model(&model.Task{}).
+		Scopes(tenantScope(ctx)).
 		Where("ID = ?", task.Id).
-		Updates(map[string]interface{}{
-			"title":       gorm.Expr("IF(? = '', title, ?)", task.Title, task.Title),
-			"description": gorm.Expr("IF(? = '', description, ?)", task.Description, task.Description),
-			"due_date":    gorm.Expr("IF(? = '', due_date, ?)", task.DueDate, task.DueDate),
-			"state":       gorm.Expr("IF(? = '', state, ?)", task.State, task.State),
-		})
+		Updates(updates)
 
 	if tx.Error != nil {
-		log.Debugf("%v", tx.Error)
+		log.WithError(tx.Error).Debug("update Task fails")
 		return tx.Error
 	}
 
 	return nil
 }
 
-func (pd *TaskDAOImpl) Save(ctx context.Context, task model.Task) error {
+// Save - creates task and sets its generated Id. With a tenant in ctx the
+// task is created in its center, otherwise task must carry its center.
+func (pd *TaskDAOImpl) Save(ctx context.Context, task *model.Task) error {
 
 	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Save")
 
-	db := base.GetDB()
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Save")
+
+	if tenant, ok := base.Tenant(ctx); ok {
+		task.CenterCode = tenant
+	}
+	if task.CenterCode == "" {
+		log.Debug("save Task without center")
+		return ErrTaskWithoutCenter
+	}
 
-	err := db.Create(&task)
+	err := db.Create(task)
 
 	if err.Error != nil {
-		log.Debugf("%v", err.Error)
+		log.WithError(err.Error).Debug("save Task fails")
 		return err.Error
 	}
 
@@ -148,3 +571,141 @@ func (pd *TaskDAOImpl) Save(ctx context.Context, task model.Task) error {
 	return nil
 
 }
+
+// SaveAll - creates every task in a single transaction. When allOrNothing is
+// false each item runs in its own savepoint so a failing item does not undo
+// the others. With a tenant in ctx the tasks are created in its center,
+// otherwise each task must carry its center.
+func (pd *TaskDAOImpl) SaveAll(ctx context.Context, tasks []model.Task, allOrNothing bool) ([]BulkResult, error) {
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.SaveAll")
+
+	if tenant, ok := base.Tenant(ctx); ok {
+		for i := range tasks {
+			tasks[i].CenterCode = tenant
+		}
+	}
+
+	return bulk(db, "SaveAll", len(tasks), allOrNothing, func(tx *gorm.DB, i int) (BulkResult, error) {
+		task := tasks[i]
+		if task.CenterCode == "" {
+			return BulkResult{}, ErrTaskWithoutCenter
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return BulkResult{}, err
+		}
+		return BulkResult{Id: task.Id}, nil
+	})
+
+}
+
+// UpdateStateAll - sets the state of every task in ids in a single
+// transaction, after check when it is not nil
+func (pd *TaskDAOImpl) UpdateStateAll(ctx context.Context, ids []int32, state string, allOrNothing bool, check TaskCheck) ([]BulkResult, error) {
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.UpdateStateAll")
+
+	return bulk(db, "UpdateStateAll", len(ids), allOrNothing, func(tx *gorm.DB, i int) (BulkResult, error) {
+		task := model.Task{}
+		if err := tx.Scopes(tenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).Where("ID = ?", ids[i]).First(&task).Error; err != nil {
+			return BulkResult{Id: ids[i]}, err
+		}
+		if check != nil {
+			if err := check(task); err != nil {
+				return BulkResult{Id: ids[i]}, err
+			}
+		}
+		if state == enums.CompletedTaskStatus && task.State != enums.CompletedTaskStatus {
+			if err := completable(tx, ids[i]); err != nil {
+				return BulkResult{Id: ids[i]}, err
+			}
+		}
+		previous := task.State
+		if err := tx.Model(&task).Where("ID = ?", ids[i]).Update("state", state).Error; err != nil {
+			return BulkResult{Id: ids[i]}, err
+		}
+		return BulkResult{Id: ids[i], PreviousState: previous}, nil
+	})
+
+}
+
+// DeleteAll - deletes every task in ids in a single transaction, after check
+// when it is not nil
+func (pd *TaskDAOImpl) DeleteAll(ctx context.Context, ids []int32, allOrNothing bool, check TaskCheck) ([]BulkResult, error) {
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.DeleteAll")
+
+	return bulk(db, "DeleteAll", len(ids), allOrNothing, func(tx *gorm.DB, i int) (BulkResult, error) {
+		task := model.Task{}
+		if err := tx.Scopes(tenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).Where("ID = ?", ids[i]).First(&task).Error; err != nil {
+			return BulkResult{Id: ids[i]}, err
+		}
+		if check != nil {
+			if err := check(task); err != nil {
+				return BulkResult{Id: ids[i]}, err
+			}
+		}
+		if err := detach(tx, ids[i]); err != nil {
+			return BulkResult{Id: ids[i]}, err
+		}
+		if err := tx.Select("Labels").Delete(&model.Task{Id: ids[i]}).Error; err != nil {
+			return BulkResult{Id: ids[i]}, err
+		}
+		return BulkResult{Id: ids[i], PreviousState: task.State}, nil
+	})
+
+}
+
+// bulk - runs fn for n items inside one transaction and collects per-item
+// results. In all-or-nothing mode the first failure rolls the whole
+// transaction back and every other item is reported as ErrBulkRolledBack.
+func bulk(db *gorm.DB, function string, n int, allOrNothing bool, fn func(tx *gorm.DB, i int) (BulkResult, error)) ([]BulkResult, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", function)
+
+	results := make([]BulkResult, n)
+	failed := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < n; i++ {
+			var r BulkResult
+			var err error
+			if allOrNothing {
+				r, err = fn(tx, i)
+			} else {
+				err = tx.Transaction(func(itx *gorm.DB) error {
+					var ierr error
+					r, ierr = fn(itx, i)
+					return ierr
+				})
+			}
+			r.Err = err
+			results[i] = r
+
+			if err != nil {
+				failed = true
+				log.WithError(err).WithField("index", i).Debug("bulk item fails")
+				if allOrNothing {
+					return ErrBulkRolledBack
+				}
+			}
+		}
+		return nil
+	})
+
+	if err == ErrBulkRolledBack {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = ErrBulkRolledBack
+			}
+		}
+		return results, nil
+	} else if err != nil {
+		log.WithError(err).Error("bulk transaction fails")
+		return nil, err
+	}
+
+	log.WithField("items", n).WithField("failed", failed).Info("bulk operation done")
+
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// TaskAttachmentDAO - TaskAttachment dao interface
+type TaskAttachmentDAO interface {
+	FindByTask(ctx context.Context, taskId int32) ([]model.TaskAttachment, error)
+	Get(ctx context.Context, taskId, id int32) (model.TaskAttachment, error)
+	Save(ctx context.Context, attachment *model.TaskAttachment) error
+	Delete(ctx context.Context, id int32) error
+}
+
+var _ TaskAttachmentDAO = (*TaskAttachmentDAOImpl)(nil)
+
+// TaskAttachmentDAOImpl - TaskAttachment dao implementation
+type TaskAttachmentDAOImpl struct {
+}
+
+// NewTaskAttachmentDAO - gets an TaskAttachmentDAOImpl instance
+func NewTaskAttachmentDAO() *TaskAttachmentDAOImpl {
+	return &TaskAttachmentDAOImpl{}
+}
+
+// FindByTask - gets the attachments of a task, oldest first
+func (ad *TaskAttachmentDAOImpl) FindByTask(ctx context.Context, taskId int32) ([]model.TaskAttachment, error) {
+
+	log := loggerf.WithField("struct", "TaskAttachmentDAOImpl").WithField("function", "FindByTask")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskAttachmentDAOImpl.FindByTask")
+
+	attachments := []model.TaskAttachment{}
+	err := db.Where("task_id = ?", taskId).Order("id").Find(&attachments).Error
+	if err != nil {
+		log.WithError(err).Error("get TaskAttachments fails")
+		return []model.TaskAttachment{}, err
+	}
+
+	return attachments, nil
+
+}
+
+// Get - gets an attachment of the task with taskId
+func (ad *TaskAttachmentDAOImpl) Get(ctx context.Context, taskId, id int32) (model.TaskAttachment, error) {
+
+	log := loggerf.WithField("struct", "TaskAttachmentDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskAttachmentDAOImpl.Get")
+
+	attachment := model.TaskAttachment{}
+	err := db.Where("ID = ? AND task_id = ?", id, taskId).First(&attachment).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get TaskAttachment fails")
+	}
+
+	return attachment, err
+
+}
+
+// Save - creates attachment and sets its generated Id
+func (ad *TaskAttachmentDAOImpl) Save(ctx context.Context, attachment *model.TaskAttachment) error {
+
+	log := loggerf.WithField("struct", "TaskAttachmentDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskAttachmentDAOImpl.Save")
+
+	if err := db.Create(attachment).Error; err != nil {
+		log.WithError(err).Error("save TaskAttachment fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Delete -
+func (ad *TaskAttachmentDAOImpl) Delete(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "TaskAttachmentDAOImpl").WithField("function", "Delete")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskAttachmentDAOImpl.Delete")
+
+	if err := db.Where("ID = ?", id).Delete(&model.TaskAttachment{}).Error; err != nil {
+		log.WithError(err).Error("delete TaskAttachment fails")
+		return err
+	}
+
+	return nil
+
+}
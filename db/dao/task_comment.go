@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// TaskCommentDAO - TaskComment dao interface
+type TaskCommentDAO interface {
+	FindByTask(ctx context.Context, taskId int32, offset, limit int) ([]model.TaskComment, int64, error)
+	Get(ctx context.Context, taskId, id int32) (model.TaskComment, error)
+	Save(ctx context.Context, comment *model.TaskComment) error
+	Update(ctx context.Context, comment model.TaskComment) error
+	Delete(ctx context.Context, id int32) error
+}
+
+var _ TaskCommentDAO = (*TaskCommentDAOImpl)(nil)
+
+// TaskCommentDAOImpl - TaskComment dao implementation
+type TaskCommentDAOImpl struct {
+}
+
+// NewTaskCommentDAO - gets an TaskCommentDAOImpl instance
+func NewTaskCommentDAO() *TaskCommentDAOImpl {
+	return &TaskCommentDAOImpl{}
+}
+
+// FindByTask - gets a page of the comments of a task, oldest first, and the
+// total number of comments of the task
+func (cd *TaskCommentDAOImpl) FindByTask(ctx context.Context, taskId int32, offset, limit int) ([]model.TaskComment, int64, error) {
+
+	log := loggerf.WithField("struct", "TaskCommentDAOImpl").WithField("function", "FindByTask")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskCommentDAOImpl.FindByTask")
+
+	var total int64
+	if err := db.Model(&model.TaskComment{}).Where("task_id = ?", taskId).Count(&total).Error; err != nil {
+		log.WithError(err).Error("count TaskComments fails")
+		return []model.TaskComment{}, 0, err
+	}
+
+	comments := []model.TaskComment{}
+	err := db.Where("task_id = ?", taskId).Order("id").Offset(offset).Limit(limit).Find(&comments).Error
+	if err != nil {
+		log.WithError(err).Error("get TaskComments fails")
+		return []model.TaskComment{}, 0, err
+	}
+
+	return comments, total, nil
+
+}
+
+// Get - gets a comment of the task with taskId
+func (cd *TaskCommentDAOImpl) Get(ctx context.Context, taskId, id int32) (model.TaskComment, error) {
+
+	log := loggerf.WithField("struct", "TaskCommentDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskCommentDAOImpl.Get")
+
+	comment := model.TaskComment{}
+	err := db.Where("ID = ? AND task_id = ?", id, taskId).First(&comment).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get TaskComment fails")
+	}
+
+	return comment, err
+
+}
+
+// Save - creates comment and sets its generated Id
+func (cd *TaskCommentDAOImpl) Save(ctx context.Context, comment *model.TaskComment) error {
+
+	log := loggerf.WithField("struct", "TaskCommentDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskCommentDAOImpl.Save")
+
+	if err := db.Create(comment).Error; err != nil {
+		log.WithError(err).Error("save TaskComment fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Update - replaces the body of a comment
+func (cd *TaskCommentDAOImpl) Update(ctx context.Context, comment model.TaskComment) error {
+
+	log := loggerf.WithField("struct", "TaskCommentDAOImpl").WithField("function", "Update")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskCommentDAOImpl.Update")
+
+	err := db.Model(&model.TaskComment{}).Where("ID = ?", comment.Id).Update("body", comment.Body).Error
+	if err != nil {
+		log.WithError(err).Error("update TaskComment fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Delete -
+func (cd *TaskCommentDAOImpl) Delete(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "TaskCommentDAOImpl").WithField("function", "Delete")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskCommentDAOImpl.Delete")
+
+	if err := db.Where("ID = ?", id).Delete(&model.TaskComment{}).Error; err != nil {
+		log.WithError(err).Error("delete TaskComment fails")
+		return err
+	}
+
+	return nil
+
+}
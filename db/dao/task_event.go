@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+)
+
+// TaskEventDAO - TaskEvent dao interface
+type TaskEventDAO interface {
+	Save(ctx context.Context, event *model.TaskEvent) error
+	FindByTask(ctx context.Context, taskId int32, limit int) ([]model.TaskEvent, error)
+}
+
+var _ TaskEventDAO = (*TaskEventDAOImpl)(nil)
+
+// TaskEventDAOImpl - TaskEvent dao implementation
+type TaskEventDAOImpl struct {
+}
+
+// NewTaskEventDAO - gets a TaskEventDAOImpl instance
+func NewTaskEventDAO() *TaskEventDAOImpl {
+	return &TaskEventDAOImpl{}
+}
+
+// Save - writes event, inside the transaction of ctx when there is one
+func (ed *TaskEventDAOImpl) Save(ctx context.Context, event *model.TaskEvent) error {
+
+	log := loggerf.WithField("struct", "TaskEventDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskEventDAOImpl.Save")
+
+	if err := db.Create(event).Error; err != nil {
+		log.WithError(err).Error("save TaskEvent fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// FindByTask - gets the last limit events of a task, newest first
+func (ed *TaskEventDAOImpl) FindByTask(ctx context.Context, taskId int32, limit int) ([]model.TaskEvent, error) {
+
+	log := loggerf.WithField("struct", "TaskEventDAOImpl").WithField("function", "FindByTask")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskEventDAOImpl.FindByTask")
+
+	events := []model.TaskEvent{}
+	err := db.Where("task_id = ?", taskId).Order("id DESC").Limit(limit).Find(&events).Error
+	if err != nil {
+		log.WithError(err).Error("get task TaskEvents fails")
+		return []model.TaskEvent{}, err
+	}
+
+	return events, nil
+
+}
@@ -0,0 +1,310 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors of the task graph, checked inside the transaction that changes it.
+var (
+	ErrTaskCycle          = errors.New("task dependency cycle")
+	ErrIncompleteSubtasks = errors.New("task has incomplete subtasks")
+	ErrTaskBlocked        = errors.New("task has incomplete blockers")
+)
+
+// tenantEdges - limits the dependency edge queries of ctx to the edges
+// whose tasks are tasks of its tenant, see tenantScope
+func tenantEdges(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if _, ok := base.Tenant(ctx); !ok {
+			return db
+		}
+		tasks := db.Session(&gorm.Session{NewDB: true}).Model(&model.Task{}).Scopes(tenantScope(ctx)).Select("id")
+		return db.Where("task_id IN (?) AND blocked_by_id IN (?)", tasks, tasks)
+	}
+}
+
+// Children - gets the subtasks of the task with id
+func (pd *TaskDAOImpl) Children(ctx context.Context, id int32) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Children")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Children")
+
+	tasks := []model.Task{}
+	err := db.Scopes(tenantScope(ctx)).Preload("Labels").Where("parent_id = ?", id).Order("id").Find(&tasks).Error
+	if err != nil {
+		log.WithError(err).Error("get Task children fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// SetParent - makes the task with id a subtask of parentId, nil detaches it.
+// Returns ErrTaskCycle when id is parentId or one of its ancestors.
+func (pd *TaskDAOImpl) SetParent(ctx context.Context, id int32, parentId *int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "SetParent")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.SetParent")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		// walks up from the new parent, the chain must not reach id
+		for next := parentId; next != nil; {
+			if *next == id {
+				return ErrTaskCycle
+			}
+			parent := model.Task{}
+			if err := tx.Scopes(tenantScope(ctx)).Select("id", "parent_id").Where("ID = ?", *next).First(&parent).Error; err != nil {
+				return err
+			}
+			next = parent.ParentId
+		}
+
+		return tx.Model(&model.Task{}).Scopes(tenantScope(ctx)).Where("ID = ?", id).Update("parent_id", parentId).Error
+	})
+
+	if err != nil && err != ErrTaskCycle && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("set Task parent fails")
+	}
+
+	return err
+
+}
+
+// Blockers - gets the tasks that block the task with id
+func (pd *TaskDAOImpl) Blockers(ctx context.Context, id int32) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Blockers")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Blockers")
+
+	blockers := db.Session(&gorm.Session{NewDB: true}).
+		Model(&model.TaskDependency{}).
+		Select("blocked_by_id").
+		Where("task_id = ?", id)
+
+	tasks := []model.Task{}
+	err := db.Scopes(tenantScope(ctx)).Preload("Labels").Where("id IN (?)", blockers).Order("id").Find(&tasks).Error
+	if err != nil {
+		log.WithError(err).Error("get Task blockers fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// Blocking - gets the tasks blocked by the task with id
+func (pd *TaskDAOImpl) Blocking(ctx context.Context, id int32) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Blocking")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Blocking")
+
+	blocked := db.Session(&gorm.Session{NewDB: true}).
+		Model(&model.TaskDependency{}).
+		Select("task_id").
+		Where("blocked_by_id = ?", id)
+
+	tasks := []model.Task{}
+	err := db.Scopes(tenantScope(ctx)).Preload("Labels").Where("id IN (?)", blocked).Order("id").Find(&tasks).Error
+	if err != nil {
+		log.WithError(err).Error("get blocked Tasks fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// AddBlocker - records that blockerId blocks id. Adding an existing edge is a
+// no-op; returns ErrTaskCycle when blockerId is already blocked by id.
+func (pd *TaskDAOImpl) AddBlocker(ctx context.Context, id int32, blockerId int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "AddBlocker")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.AddBlocker")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		var found int64
+		if err := tx.Model(&model.Task{}).Scopes(tenantScope(ctx)).Where("id IN ?", []int32{id, blockerId}).Count(&found).Error; err != nil {
+			return err
+		} else if found < 2 && id != blockerId {
+			return gorm.ErrRecordNotFound
+		}
+
+		// breadth-first over the blockers of blockerId, id must not be found
+		visited := map[int32]bool{blockerId: true}
+		frontier := []int32{blockerId}
+		for len(frontier) > 0 {
+			if visited[id] {
+				return ErrTaskCycle
+			}
+			next := []int32{}
+			if err := tx.Model(&model.TaskDependency{}).Scopes(tenantEdges(ctx)).Where("task_id IN ?", frontier).Pluck("blocked_by_id", &next).Error; err != nil {
+				return err
+			}
+			frontier = frontier[:0]
+			for _, n := range next {
+				if !visited[n] {
+					visited[n] = true
+					frontier = append(frontier, n)
+				}
+			}
+		}
+		if visited[id] {
+			return ErrTaskCycle
+		}
+
+		edge := model.TaskDependency{TaskId: id, BlockedById: blockerId}
+		return tx.Where(edge).FirstOrCreate(&edge).Error
+	})
+
+	if err != nil && err != ErrTaskCycle && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("add Task blocker fails")
+	}
+
+	return err
+
+}
+
+// RemoveBlocker - removes the edge, gorm.ErrRecordNotFound if it does not exist
+func (pd *TaskDAOImpl) RemoveBlocker(ctx context.Context, id int32, blockerId int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "RemoveBlocker")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.RemoveBlocker")
+
+	tx := db.Scopes(tenantEdges(ctx)).Where("task_id = ? AND blocked_by_id = ?", id, blockerId).Delete(&model.TaskDependency{})
+	if tx.Error != nil {
+		log.WithError(tx.Error).Error("remove Task blocker fails")
+		return tx.Error
+	} else if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+
+}
+
+// IncompleteDependencies - counts the subtasks and blockers of the task with
+// id that are not completed
+func (pd *TaskDAOImpl) IncompleteDependencies(ctx context.Context, id int32) (int64, int64, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "IncompleteDependencies")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.IncompleteDependencies")
+
+	subtasks, blockers, err := incompleteDependencies(db.Session(&gorm.Session{}), id)
+	if err != nil {
+		log.WithError(err).Error("count incomplete dependencies fails")
+	}
+
+	return subtasks, blockers, err
+
+}
+
+// incompleteDependencies - db must be a session or transaction, it is used
+// for more than one query
+func incompleteDependencies(db *gorm.DB, id int32) (subtasks int64, blockers int64, err error) {
+
+	err = db.Model(&model.Task{}).
+		Where("parent_id = ? AND state <> ?", id, enums.CompletedTaskStatus).
+		Count(&subtasks).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blockerIds := db.Session(&gorm.Session{NewDB: true}).
+		Model(&model.TaskDependency{}).
+		Select("blocked_by_id").
+		Where("task_id = ?", id)
+
+	err = db.Model(&model.Task{}).
+		Where("id IN (?) AND state <> ?", blockerIds, enums.CompletedTaskStatus).
+		Count(&blockers).Error
+
+	return subtasks, blockers, err
+}
+
+// Completable - ErrIncompleteSubtasks or ErrTaskBlocked when the task with id
+// cannot be completed yet. Inside a transaction its subtasks and blockers
+// stay locked until it ends, so none is reopened before the task completes.
+func (pd *TaskDAOImpl) Completable(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "Completable")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.Completable")
+
+	err := completable(db.Session(&gorm.Session{}), id)
+	if err != nil && err != ErrIncompleteSubtasks && err != ErrTaskBlocked {
+		log.WithError(err).Error("check completable Task fails")
+	}
+
+	return err
+
+}
+
+// completable - ErrIncompleteSubtasks or ErrTaskBlocked when the task with id
+// cannot be completed yet; locks its subtasks and blockers in tx
+func completable(tx *gorm.DB, id int32) error {
+
+	blockerIds := tx.Session(&gorm.Session{NewDB: true}).
+		Model(&model.TaskDependency{}).
+		Select("blocked_by_id").
+		Where("task_id = ?", id)
+
+	locked := []int32{}
+	err := tx.Model(&model.Task{}).
+		Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("parent_id = ? OR id IN (?)", id, blockerIds).
+		Pluck("id", &locked).Error
+	if err != nil {
+		return err
+	}
+
+	subtasks, blockers, err := incompleteDependencies(tx, id)
+	switch {
+	case err != nil:
+		return err
+	case subtasks > 0:
+		return ErrIncompleteSubtasks
+	case blockers > 0:
+		return ErrTaskBlocked
+	}
+
+	return nil
+}
+
+// detach - removes every dependency edge, comment and attachment record of
+// the task with id and turns its subtasks into top-level tasks, before
+// deleting it
+func detach(tx *gorm.DB, id int32) error {
+
+	err := tx.Where("task_id = ? OR blocked_by_id = ?", id, id).Delete(&model.TaskDependency{}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Where("task_id = ?", id).Delete(&model.TaskComment{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("task_id = ?", id).Delete(&model.TaskAttachment{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&model.Task{}).Where("parent_id = ?", id).Update("parent_id", nil).Error
+}
@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// MarkOverdue - flags the pending tasks whose due date is before now and that
+// were not flagged yet, and gets them
+func (pd *TaskDAOImpl) MarkOverdue(ctx context.Context, now time.Time) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "MarkOverdue")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.MarkOverdue")
+
+	tasks, err := mark(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(tenantScope(ctx)).Where("state <> ? AND due_date < ? AND overdue = ?", enums.CompletedTaskStatus, now, false)
+	}, map[string]interface{}{"overdue": true})
+
+	if err != nil {
+		log.WithError(err).Error("mark overdue Tasks fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// MarkDueSoon - records now as the reminder time of the pending tasks due
+// between now and until that were not reminded yet, and gets them
+func (pd *TaskDAOImpl) MarkDueSoon(ctx context.Context, now time.Time, until time.Time) ([]model.Task, error) {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "MarkDueSoon")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.MarkDueSoon")
+
+	tasks, err := mark(db, func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(tenantScope(ctx)).Where("state <> ? AND due_date >= ? AND due_date < ? AND reminded_at IS NULL", enums.CompletedTaskStatus, now, until)
+	}, map[string]interface{}{"reminded_at": now})
+
+	if err != nil {
+		log.WithError(err).Error("mark due soon Tasks fails")
+		return []model.Task{}, err
+	}
+
+	return tasks, nil
+
+}
+
+// ResetReminders - clears the overdue flag and the reminder time, so a task
+// whose due date moved is evaluated again
+func (pd *TaskDAOImpl) ResetReminders(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "TaskDAOImpl").WithField("function", "ResetReminders")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "TaskDAOImpl.ResetReminders")
+
+	err := db.Model(&model.Task{}).Scopes(tenantScope(ctx)).Where("ID = ?", id).
+		Updates(map[string]interface{}{"overdue": false, "reminded_at": nil}).Error
+	if err != nil {
+		log.WithError(err).Error("reset Task reminders fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// mark - selects the tasks matching where and applies updates to exactly those
+// tasks in one transaction
+func mark(db *gorm.DB, where func(tx *gorm.DB) *gorm.DB, updates map[string]interface{}) ([]model.Task, error) {
+
+	tasks := []model.Task{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		if err := where(tx.Session(&gorm.Session{})).Order("due_date, id").Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		ids := make([]int32, 0, len(tasks))
+		for _, t := range tasks {
+			ids = append(ids, t.Id)
+		}
+
+		return tx.Model(&model.Task{}).Where("id IN ?", ids).Updates(updates).Error
+	})
+
+	return tasks, err
+}
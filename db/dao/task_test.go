@@ -7,6 +7,7 @@ import (
 
 	"github.com/apex/log"
 
+	"github.com/Alonso-Arias/test-cleverit/db/base"
 	"github.com/Alonso-Arias/test-cleverit/db/model"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,7 +26,7 @@ func TestSave_OK(t *testing.T) {
 		assert.FailNowf(t, "fails", "fails to gets exam: %v", err)
 	}
 
-	err = TaskDao.Save(context.TODO(), model.Task{Id: 999, Title: "Test", Description: "Test", DueDate: dateFormated, State: ""})
+	err = TaskDao.Save(context.TODO(), &model.Task{Id: 999, Title: "Test", Description: "Test", DueDate: dateFormated, State: "", CenterCode: "C1"})
 
 	if err != nil {
 		assert.FailNowf(t, "fails", "fails to update Task: %v", err)
@@ -33,6 +34,15 @@ func TestSave_OK(t *testing.T) {
 
 }
 
+func TestSave_WithoutCenter(t *testing.T) {
+
+	err := TaskDao.Save(context.TODO(), &model.Task{Title: "Test", Description: "Test", DueDate: time.Now(), State: "PENDING"})
+	assert.Equal(t, ErrTaskWithoutCenter, err)
+
+	err = TaskDao.Save(base.WithTenant(context.TODO(), ""), &model.Task{Title: "Test", Description: "Test", DueDate: time.Now(), State: "PENDING", CenterCode: "C1"})
+	assert.Equal(t, ErrTaskWithoutCenter, err)
+}
+
 func TestFindAll_OK(t *testing.T) {
 
 	result, err := TaskDao.FindAll(context.TODO())
@@ -66,3 +76,115 @@ func TestUpdate_OK(t *testing.T) {
 	}
 
 }
+
+// newTestTasks - saves n pending tasks for the test, deleted when it ends
+func newTestTasks(t *testing.T, n int) []int32 {
+
+	ids := []int32{}
+	for i := 0; i < n; i++ {
+		task := model.Task{Title: "Bulk", Description: "Bulk", DueDate: time.Now().Truncate(time.Second), State: "PENDING", CenterCode: "C1"}
+		if err := TaskDao.Save(context.TODO(), &task); err != nil {
+			assert.FailNowf(t, "fails", "fails to save Task: %v", err)
+		}
+		ids = append(ids, task.Id)
+	}
+	cleanupTasks(t, ids)
+
+	return ids
+}
+
+// cleanupTasks - deletes the tasks with ids when the test ends
+func cleanupTasks(t *testing.T, ids []int32) {
+	t.Cleanup(func() {
+		if len(ids) > 0 {
+			base.DB(context.TODO()).Delete(&model.Task{}, ids)
+		}
+	})
+}
+
+// resultOf - gets the result of the item id of a bulk operation
+func resultOf(t *testing.T, results []BulkResult, id int32) BulkResult {
+	for _, r := range results {
+		if r.Id == id {
+			return r
+		}
+	}
+	assert.FailNowf(t, "fails", "no result for Task %d", id)
+	return BulkResult{}
+}
+
+func TestSaveAll_OK(t *testing.T) {
+
+	dueDate := time.Now().Truncate(time.Second)
+
+	results, err := TaskDao.SaveAll(context.TODO(), []model.Task{
+		{Title: "Bulk 1", Description: "Bulk", DueDate: dueDate, State: "PENDING", CenterCode: "C1"},
+		{Title: "Bulk 2", Description: "Bulk", DueDate: dueDate, State: "PENDING", CenterCode: "C1"},
+	}, true)
+
+	if err != nil {
+		assert.FailNowf(t, "fails", "fails to save Tasks: %v", err)
+	}
+
+	ids := []int32{}
+	for _, r := range results {
+		ids = append(ids, r.Id)
+	}
+	cleanupTasks(t, ids)
+
+	assert.Len(t, results, 2)
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+		assert.NotZero(t, r.Id)
+	}
+
+}
+
+func TestUpdateStateAll_PartialFailure(t *testing.T) {
+
+	id := newTestTasks(t, 1)[0]
+
+	results, err := TaskDao.UpdateStateAll(context.TODO(), []int32{id, -1}, "IN_PROGRESS", false, nil)
+
+	if err != nil {
+		assert.FailNowf(t, "fails", "fails to update Tasks: %v", err)
+	}
+
+	assert.NoError(t, resultOf(t, results, id).Err)
+	assert.Error(t, resultOf(t, results, -1).Err)
+
+	task, err := TaskDao.Get(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "IN_PROGRESS", task.State)
+
+}
+
+func TestDeleteAll_AllOrNothing(t *testing.T) {
+
+	id := newTestTasks(t, 1)[0]
+
+	results, err := TaskDao.DeleteAll(context.TODO(), []int32{id, -1}, true, nil)
+
+	if err != nil {
+		assert.FailNowf(t, "fails", "fails to delete Tasks: %v", err)
+	}
+
+	assert.ErrorIs(t, resultOf(t, results, id).Err, ErrBulkRolledBack)
+	assert.Error(t, resultOf(t, results, -1).Err)
+
+	_, err = TaskDao.Get(context.TODO(), id)
+	assert.NoError(t, err)
+
+}
+
+func TestFullTextSearch_OK(t *testing.T) {
+
+	result, err := TaskDao.FullTextSearch(context.TODO(), "Testing2", 10)
+
+	if err != nil {
+		assert.FailNowf(t, "fails", "fails to search Tasks: %v", err)
+	}
+
+	t.Logf("Result : %v", result)
+
+}
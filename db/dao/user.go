@@ -0,0 +1,273 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// UserDAO - User dao interface
+type UserDAO interface {
+	Get(ctx context.Context, email string) (model.User, error)
+	Save(ctx context.Context, user *model.User) error
+	UpdateStatus(ctx context.Context, email, status string, attempts int) error
+	FailLogin(ctx context.Context, email string, max int, until time.Time) error
+	ResetLogins(ctx context.Context, email string) error
+	UpdatePassword(ctx context.Context, email, password string) error
+	Roles(ctx context.Context, email string) ([]model.Role, error)
+	AddRole(ctx context.Context, email, roleCode string) error
+	RemoveRole(ctx context.Context, email, roleCode string) error
+}
+
+var _ UserDAO = (*UserDAOImpl)(nil)
+
+// UserDAOImpl - User dao implementation
+type UserDAOImpl struct {
+}
+
+// NewUserDAO - gets an UserDAOImpl instance
+func NewUserDAO() *UserDAOImpl {
+	return &UserDAOImpl{}
+}
+
+// Get - gets the user by email
+func (ud *UserDAOImpl) Get(ctx context.Context, email string) (model.User, error) {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.Get")
+
+	user := model.User{}
+	err := db.Where("email = ?", email).First(&user).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get User fails")
+	}
+
+	return user, err
+
+}
+
+// Save - creates user
+func (ud *UserDAOImpl) Save(ctx context.Context, user *model.User) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.Save")
+
+	if err := db.Create(user).Error; err != nil {
+		log.WithError(err).Debug("save User fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// UpdateStatus - sets the status and the failed login attempts of the user,
+// and lifts the lock of failed logins
+func (ud *UserDAOImpl) UpdateStatus(ctx context.Context, email, status string, attempts int) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "UpdateStatus")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.UpdateStatus")
+
+	err := db.Model(&model.User{}).Where("email = ?", email).
+		Updates(map[string]interface{}{"status": status, "attempts": attempts, "locked_until": nil}).Error
+	if err != nil {
+		log.WithError(err).Error("update User status fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// FailLogin - counts a failed login of the user in the database, so parallel
+// logins are all counted, and locks the user until the given time once max
+// failed logins are reached
+func (ud *UserDAOImpl) FailLogin(ctx context.Context, email string, max int, until time.Time) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "FailLogin")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.FailLogin")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("email = ?", email).
+			Update("attempts", gorm.Expr("attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("email = ? AND attempts >= ?", email, max).
+			Updates(map[string]interface{}{"attempts": 0, "locked_until": until}).Error
+	})
+	if err != nil {
+		log.WithError(err).Error("count User failed login fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// ResetLogins - clears the failed logins of the user after a successful one
+func (ud *UserDAOImpl) ResetLogins(ctx context.Context, email string) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "ResetLogins")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.ResetLogins")
+
+	err := db.Model(&model.User{}).Where("email = ?", email).
+		Updates(map[string]interface{}{"attempts": 0, "locked_until": nil}).Error
+	if err != nil {
+		log.WithError(err).Error("reset User failed logins fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// UpdatePassword - sets the password hash of the user
+func (ud *UserDAOImpl) UpdatePassword(ctx context.Context, email, password string) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "UpdatePassword")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.UpdatePassword")
+
+	err := db.Model(&model.User{}).Where("email = ?", email).Update("password", password).Error
+	if err != nil {
+		log.WithError(err).Error("update User password fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Roles - gets the roles assigned to the user ordered by code
+func (ud *UserDAOImpl) Roles(ctx context.Context, email string) ([]model.Role, error) {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "Roles")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.Roles")
+
+	roles := []model.Role{}
+	err := db.Joins("JOIN user_roles ON user_roles.role_code = roles.code").
+		Where("user_roles.email = ?", email).Order("roles.code").Find(&roles).Error
+
+	if err != nil {
+		log.WithError(err).Error("get User roles fails")
+		return []model.Role{}, err
+	}
+
+	return roles, nil
+
+}
+
+// AddRole - assigns the role to the user, assigning it twice is no error
+func (ud *UserDAOImpl) AddRole(ctx context.Context, email, roleCode string) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "AddRole")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.AddRole")
+
+	err := db.Where(model.UserRole{Email: email, RoleCode: roleCode}).FirstOrCreate(&model.UserRole{}).Error
+	if err != nil {
+		log.WithError(err).Error("add User role fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// RemoveRole - revokes the role of the user
+func (ud *UserDAOImpl) RemoveRole(ctx context.Context, email, roleCode string) error {
+
+	log := loggerf.WithField("struct", "UserDAOImpl").WithField("function", "RemoveRole")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "UserDAOImpl.RemoveRole")
+
+	err := db.Where("email = ? AND role_code = ?", email, roleCode).Delete(&model.UserRole{}).Error
+	if err != nil {
+		log.WithError(err).Error("remove User role fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// RoleDAO - Role dao interface
+type RoleDAO interface {
+	FindAll(ctx context.Context) ([]model.Role, error)
+	Get(ctx context.Context, code string) (model.Role, error)
+	Save(ctx context.Context, role model.Role) error
+}
+
+var _ RoleDAO = (*RoleDAOImpl)(nil)
+
+// RoleDAOImpl - Role dao implementation
+type RoleDAOImpl struct {
+}
+
+// NewRoleDAO - gets an RoleDAOImpl instance
+func NewRoleDAO() *RoleDAOImpl {
+	return &RoleDAOImpl{}
+}
+
+// FindAll - gets every role ordered by code
+func (rd *RoleDAOImpl) FindAll(ctx context.Context) ([]model.Role, error) {
+
+	log := loggerf.WithField("struct", "RoleDAOImpl").WithField("function", "FindAll")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "RoleDAOImpl.FindAll")
+
+	roles := []model.Role{}
+	if err := db.Order("code").Find(&roles).Error; err != nil {
+		log.WithError(err).Error("get Roles fails")
+		return []model.Role{}, err
+	}
+
+	return roles, nil
+
+}
+
+// Get -
+func (rd *RoleDAOImpl) Get(ctx context.Context, code string) (model.Role, error) {
+
+	log := loggerf.WithField("struct", "RoleDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "RoleDAOImpl.Get")
+
+	role := model.Role{}
+	err := db.Where("code = ?", code).First(&role).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get Role fails")
+	}
+
+	return role, err
+
+}
+
+// Save - creates the role or updates its name and description
+func (rd *RoleDAOImpl) Save(ctx context.Context, role model.Role) error {
+
+	log := loggerf.WithField("struct", "RoleDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "RoleDAOImpl.Save")
+
+	if err := db.Save(&role).Error; err != nil {
+		log.WithError(err).Error("save Role fails")
+		return err
+	}
+
+	return nil
+
+}
@@ -0,0 +1,210 @@
+package dao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"gorm.io/gorm"
+)
+
+// WebhookDAO - Webhook dao interface
+type WebhookDAO interface {
+	FindAll(ctx context.Context) ([]model.Webhook, error)
+	FindActive(ctx context.Context, eventType string) ([]model.Webhook, error)
+	Get(ctx context.Context, id int32) (model.Webhook, error)
+	Save(ctx context.Context, webhook *model.Webhook) error
+	Update(ctx context.Context, webhook model.Webhook) error
+	Delete(ctx context.Context, id int32) error
+	SaveDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, webhookId int32, limit int) ([]model.WebhookDelivery, error)
+}
+
+var _ WebhookDAO = (*WebhookDAOImpl)(nil)
+
+// WebhookDAOImpl - Webhook dao implementation
+type WebhookDAOImpl struct {
+}
+
+// NewWebhookDAO - gets an WebhookDAOImpl instance
+func NewWebhookDAO() *WebhookDAOImpl {
+	return &WebhookDAOImpl{}
+}
+
+// FindAll - gets the webhooks of the tenant ordered by id
+func (wd *WebhookDAOImpl) FindAll(ctx context.Context) ([]model.Webhook, error) {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "FindAll")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.FindAll")
+
+	webhooks := []model.Webhook{}
+	err := db.Scopes(centerScope(ctx, "center_code")).Order("id").Find(&webhooks).Error
+	if err != nil {
+		log.WithError(err).Error("get Webhooks fails")
+		return []model.Webhook{}, err
+	}
+
+	return webhooks, nil
+
+}
+
+// FindActive - gets the enabled webhooks of the tenant subscribed to eventType
+func (wd *WebhookDAOImpl) FindActive(ctx context.Context, eventType string) ([]model.Webhook, error) {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "FindActive")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.FindActive")
+
+	webhooks := []model.Webhook{}
+	err := db.Scopes(centerScope(ctx, "center_code")).Where("disabled = ?", false).Order("id").Find(&webhooks).Error
+	if err != nil {
+		log.WithError(err).Error("get active Webhooks fails")
+		return []model.Webhook{}, err
+	}
+
+	// the list is short and the filter on a comma-separated column is simpler here
+	active := []model.Webhook{}
+	for _, w := range webhooks {
+		if Subscribed(w, eventType) {
+			active = append(active, w)
+		}
+	}
+
+	return active, nil
+
+}
+
+// Subscribed - true when webhook w receives events of eventType
+func Subscribed(w model.Webhook, eventType string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Get -
+func (wd *WebhookDAOImpl) Get(ctx context.Context, id int32) (model.Webhook, error) {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "Get")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.Get")
+
+	webhook := model.Webhook{}
+	err := db.Scopes(centerScope(ctx, "center_code")).Where("ID = ?", id).First(&webhook).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("get Webhook fails")
+	}
+
+	return webhook, err
+
+}
+
+// Save - creates webhook and sets its generated Id
+func (wd *WebhookDAOImpl) Save(ctx context.Context, webhook *model.Webhook) error {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "Save")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.Save")
+
+	if tenant, ok := base.Tenant(ctx); ok {
+		webhook.CenterCode = tenant
+	}
+
+	if err := db.Create(webhook).Error; err != nil {
+		log.WithError(err).Debug("save Webhook fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Update - replaces url, events and disabled; an empty secret keeps the current one
+func (wd *WebhookDAOImpl) Update(ctx context.Context, webhook model.Webhook) error {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "Update")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.Update")
+
+	columns := []string{"url", "events", "disabled"}
+	if webhook.Secret != "" {
+		columns = append(columns, "secret")
+	}
+
+	err := db.Model(&model.Webhook{}).Scopes(centerScope(ctx, "center_code")).Where("ID = ?", webhook.Id).Select(columns).Updates(webhook).Error
+	if err != nil {
+		log.WithError(err).Debug("update Webhook fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// Delete - deletes the webhook and its delivery log
+func (wd *WebhookDAOImpl) Delete(ctx context.Context, id int32) error {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "Delete")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.Delete")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Scopes(centerScope(ctx, "center_code")).Where("ID = ?", id).Delete(&model.Webhook{}).Error
+	})
+
+	if err != nil {
+		log.WithError(err).Error("delete Webhook fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// SaveDelivery - records a delivery attempt
+func (wd *WebhookDAOImpl) SaveDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "SaveDelivery")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.SaveDelivery")
+
+	if err := db.Create(delivery).Error; err != nil {
+		log.WithError(err).Error("save WebhookDelivery fails")
+		return err
+	}
+
+	return nil
+
+}
+
+// FindDeliveries - gets the last limit delivery attempts of a webhook, newest first
+func (wd *WebhookDAOImpl) FindDeliveries(ctx context.Context, webhookId int32, limit int) ([]model.WebhookDelivery, error) {
+
+	log := loggerf.WithField("struct", "WebhookDAOImpl").WithField("function", "FindDeliveries")
+
+	db := base.DB(ctx).Set(metrics.DAOMethodKey, "WebhookDAOImpl.FindDeliveries")
+
+	deliveries := []model.WebhookDelivery{}
+	err := db.Where("webhook_id = ?", webhookId).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		log.WithError(err).Error("get WebhookDeliveries fails")
+		return []model.WebhookDelivery{}, err
+	}
+
+	return deliveries, nil
+
+}
@@ -8,4 +8,161 @@ type Task struct {
 	Description string
 	DueDate     time.Time
 	State       string
+	Priority    string
+	ParentId    *int32 `gorm:"index"`
+	Recurrence  string
+	// TimeZone - IANA time zone the recurrence is evaluated in, the one of the
+	// request that created the series
+	TimeZone   string `gorm:"size:64"`
+	Overdue    bool
+	RemindedAt *time.Time
+	// Owner - email of the user who created the task
+	Owner string `gorm:"size:100;index"`
+	// CenterCode - center of the tenant the task belongs to
+	CenterCode string  `gorm:"size:45;index"`
+	Labels     []Label `gorm:"many2many:task_labels;constraint:OnDelete:CASCADE"`
+}
+
+// TaskDependency - TaskId cannot be completed until BlockedById is completed
+type TaskDependency struct {
+	TaskId      int32 `gorm:"primaryKey;autoIncrement:false"`
+	BlockedById int32 `gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+type Label struct {
+	Id    int32
+	Name  string `gorm:"uniqueIndex;size:45"`
+	Color string
+}
+
+// TaskComment - comment on a task, Author is the email of the user who wrote it
+type TaskComment struct {
+	Id        int32
+	TaskId    int32  `gorm:"index"`
+	Author    string `gorm:"size:100"`
+	Body      string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TaskAttachment - metadata of a file attached to a task, whose content is in
+// the blob storage under StorageKey
+type TaskAttachment struct {
+	Id          int32
+	TaskId      int32  `gorm:"index"`
+	FileName    string `gorm:"size:255"`
+	ContentType string `gorm:"size:100"`
+	Size        int64
+	StorageKey  string `gorm:"size:255"`
+	UploadedBy  string `gorm:"size:100"`
+	CreatedAt   time.Time
+}
+
+// Webhook - subscription to task events. Events is a comma-separated list of
+// event types, empty means every type.
+type Webhook struct {
+	Id       int32
+	Url      string `gorm:"size:500"`
+	Secret   string `gorm:"size:100"`
+	Events   string
+	Disabled bool
+	// CenterCode - center whose task events the webhook receives
+	CenterCode string `gorm:"size:45;index"`
+	CreatedAt  time.Time
+}
+
+// WebhookDelivery - one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	Id         int64
+	WebhookId  int32  `gorm:"index"`
+	EventId    string `gorm:"size:45"`
+	EventType  string `gorm:"size:45"`
+	Attempt    int
+	StatusCode int
+	Error      string
+	Success    bool
+	DurationMs int64
+	CreatedAt  time.Time
+}
+
+// OutboxEvent - task event written in the transaction of the change, relayed
+// to the event sinks once committed. PublishedAt is nil until relayed.
+type OutboxEvent struct {
+	Id          int64
+	EventId     string `gorm:"uniqueIndex;size:45"`
+	Type        string `gorm:"size:45"`
+	TaskId      int32  `gorm:"index"`
+	Payload     string
+	OccurredAt  time.Time
+	PublishedAt *time.Time `gorm:"index"`
+	// Seq - order in which the relay publishes the event, set right before
+	// its sinks get it; ids follow the inserts, not the commits
+	Seq *int64 `gorm:"uniqueIndex"`
+	// Delivered - comma-separated sinks that already accepted the event
+	Delivered string
+	Attempts  int
+	LastError string
+	// DeadAt - set when the event ran out of attempts, it is no longer relayed
+	DeadAt *time.Time `gorm:"index"`
+}
+
+// TaskEvent - history of the changes of a task, written with the outbox
+// event of the same EventId and kept after the outbox purges it.
+type TaskEvent struct {
+	Id         int64
+	EventId    string `gorm:"uniqueIndex;size:45"`
+	Type       string `gorm:"size:45"`
+	TaskId     int32  `gorm:"index"`
+	Payload    string
+	OccurredAt time.Time
+}
+
+// User - user of the API, Password is its argon2 hash. Status is ACTIVE or
+// LOCKED and Attempts counts the failed logins since the last lock or
+// successful login.
+type User struct {
+	Email      string `gorm:"primaryKey;size:100"`
+	CenterCode string `gorm:"primaryKey;size:45"`
+	FullName   string `gorm:"size:100"`
+	Password   string `gorm:"size:100"`
+	Attempts   int
+	Status     string `gorm:"size:45"`
+	// LockedUntil - set after too many failed logins, the user cannot log in
+	// until then
+	LockedUntil *time.Time
+	CreatedAt   time.Time
+}
+
+// Role - role whose permissions are given by the casbin policy
+type Role struct {
+	Code        string `gorm:"primaryKey;size:45"`
+	Name        string `gorm:"size:45"`
+	Description string `gorm:"size:100"`
+}
+
+// UserRole - role assigned to a user
+type UserRole struct {
+	Email    string `gorm:"primaryKey;size:100"`
+	RoleCode string `gorm:"primaryKey;size:45"`
+}
+
+// ApiKey - key of a service that calls the API without login, given as
+// Prefix.secret. Hash is the argon2 hash of the secret, Scopes the
+// comma-separated scopes that give its roles.
+type ApiKey struct {
+	Id         int32
+	Name       string `gorm:"size:100"`
+	Prefix     string `gorm:"size:16;uniqueIndex"`
+	Hash       string `gorm:"size:100"`
+	Scopes     string `gorm:"size:200"`
+	CenterCode string `gorm:"size:45"`
+	CreatedBy  string `gorm:"size:100"`
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// All - models whose schema is created by AutoMigrate on SQLite
+func All() []interface{} {
+	return []interface{}{&Task{}, &Label{}, &TaskDependency{}, &TaskComment{}, &TaskAttachment{}, &Webhook{}, &WebhookDelivery{}, &OutboxEvent{}, &TaskEvent{}, &User{}, &Role{}, &UserRole{}, &ApiKey{}}
 }
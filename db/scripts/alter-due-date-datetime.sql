@@ -0,0 +1,9 @@
+-- -----------------------------------------------------
+-- Cambia `due_date` de DATE a DATETIME en bases creadas antes de
+-- soportar horas y zonas horarias. Las fechas existentes quedan a las
+-- 00:00:00 UTC.
+-- -----------------------------------------------------
+USE `TEST` ;
+
+ALTER TABLE `TEST`.`tasks`
+  MODIFY COLUMN `due_date` DATETIME NULL DEFAULT NULL;
@@ -0,0 +1,19 @@
+-- -----------------------------------------------------
+-- Agrega `seq` a `outbox_events` en bases creadas cuando el stream de
+-- eventos se reanudaba por `id`. Los eventos ya publicados se numeran en el
+-- orden en que se publicaron; los pendientes reciben su `seq` del relay.
+-- Los clientes que guardaron un `Last-Event-ID` anterior reciben un
+-- `stream.resync` si ese valor ya no corresponde a un evento retenido.
+-- -----------------------------------------------------
+USE `TEST` ;
+
+ALTER TABLE `TEST`.`outbox_events`
+  ADD COLUMN `seq` BIGINT NULL DEFAULT NULL AFTER `published_at`,
+  ADD UNIQUE INDEX `SEQ_UNIQUE` (`seq` ASC);
+
+SET @seq = 0;
+
+UPDATE `TEST`.`outbox_events`
+   SET `seq` = (@seq := @seq + 1)
+ WHERE `published_at` IS NOT NULL
+ ORDER BY `published_at`, `id`;
@@ -0,0 +1,30 @@
+-- -----------------------------------------------------
+-- Agrega `center_code` a `tasks` y `webhooks` en bases creadas antes de
+-- los centros y lo completa: cada tarea queda en el centro de su `owner`
+-- cuando este pertenece a un solo centro, y el resto de las tareas y los
+-- webhooks en @default_center. Una fila con `center_code` vacío no la ve
+-- ningún usuario.
+-- -----------------------------------------------------
+USE `TEST` ;
+
+SET @default_center = 'C1';
+
+ALTER TABLE `TEST`.`tasks`
+  ADD COLUMN `center_code` VARCHAR(45) NOT NULL DEFAULT '' AFTER `owner`,
+  ADD INDEX `IDX_TASKS_CENTER` (`center_code`);
+
+ALTER TABLE `TEST`.`webhooks`
+  ADD COLUMN `center_code` VARCHAR(45) NOT NULL DEFAULT '' AFTER `disabled`,
+  ADD INDEX `IDX_WEBHOOKS_CENTER` (`center_code`);
+
+UPDATE `TEST`.`tasks` t
+  JOIN (SELECT `email`, MIN(`center_code`) AS `center_code`
+          FROM `TEST`.`users`
+         GROUP BY `email`
+        HAVING COUNT(*) = 1) u ON u.`email` = t.`owner`
+   SET t.`center_code` = u.`center_code`
+ WHERE t.`center_code` = '';
+
+UPDATE `TEST`.`tasks` SET `center_code` = @default_center WHERE `center_code` = '';
+
+UPDATE `TEST`.`webhooks` SET `center_code` = @default_center WHERE `center_code` = '';
@@ -0,0 +1,9 @@
+-- -----------------------------------------------------
+-- Agrega `locked_until` a `users` en bases creadas antes del inicio de
+-- sesión: un usuario con demasiados intentos fallidos queda bloqueado hasta
+-- esa fecha.
+-- -----------------------------------------------------
+USE `TEST` ;
+
+ALTER TABLE `TEST`.`users`
+  ADD COLUMN `locked_until` DATETIME NULL DEFAULT NULL AFTER `status`;
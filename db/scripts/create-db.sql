@@ -27,13 +27,234 @@ CREATE TABLE IF NOT EXISTS `TEST`.`tasks` (
   `id` INTEGER NOT NULL AUTO_INCREMENT,
   `title` VARCHAR(120) NOT NULL,
   `description` TEXT NULL,
-  `due_date` DATE NULL DEFAULT NULL,
+  `due_date` DATETIME NULL DEFAULT NULL,
   `state` VARCHAR(45) NOT NULL,
-  PRIMARY KEY (`id`)
+  `priority` VARCHAR(20) NOT NULL DEFAULT 'MEDIUM',
+  `parent_id` INTEGER NULL DEFAULT NULL,
+  `recurrence` VARCHAR(255) NULL DEFAULT NULL,
+  `time_zone` VARCHAR(64) NOT NULL DEFAULT '',
+  `overdue` TINYINT(1) NOT NULL DEFAULT 0,
+  `reminded_at` DATETIME NULL DEFAULT NULL,
+  `owner` VARCHAR(100) NULL DEFAULT NULL,
+  `center_code` VARCHAR(45) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  INDEX `IDX_TASKS_STATE_DUE_DATE` (`state`, `due_date`),
+  INDEX `IDX_TASKS_PRIORITY` (`priority`),
+  INDEX `IDX_TASKS_PARENT` (`parent_id`),
+  INDEX `IDX_TASKS_OWNER` (`owner`),
+  INDEX `IDX_TASKS_CENTER` (`center_code`),
+  CONSTRAINT `fk_TASKS_PARENT`
+    FOREIGN KEY (`parent_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE SET NULL
+    ON UPDATE NO ACTION,
+  FULLTEXT INDEX `FT_TASKS_TITLE_DESCRIPTION` (`title`, `description`)
 )
 ENGINE = InnoDB
 DEFAULT CHARACTER SET = utf8;
 
+-- -----------------------------------------------------
+-- Table `TEST`.`task_dependencies`
+-- task_id no puede completarse hasta completar blocked_by_id
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`task_dependencies` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`task_dependencies` (
+  `task_id` INTEGER NOT NULL,
+  `blocked_by_id` INTEGER NOT NULL,
+  PRIMARY KEY (`task_id`, `blocked_by_id`),
+  INDEX `IDX_TASK_DEPENDENCIES_BLOCKED_BY` (`blocked_by_id`),
+  CONSTRAINT `fk_TASK_DEPENDENCIES_TASK`
+    FOREIGN KEY (`task_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_TASK_DEPENDENCIES_BLOCKED_BY`
+    FOREIGN KEY (`blocked_by_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`labels`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`labels` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`labels` (
+  `id` INTEGER NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(45) NOT NULL,
+  `color` VARCHAR(20) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `NAME_UNIQUE` (`name` ASC))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`task_labels`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`task_labels` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`task_labels` (
+  `task_id` INTEGER NOT NULL,
+  `label_id` INTEGER NOT NULL,
+  PRIMARY KEY (`task_id`, `label_id`),
+  CONSTRAINT `fk_TASK_LABELS_TASK`
+    FOREIGN KEY (`task_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_TASK_LABELS_LABEL`
+    FOREIGN KEY (`label_id`)
+    REFERENCES `TEST`.`labels` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`task_comments`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`task_comments` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`task_comments` (
+  `id` INTEGER NOT NULL AUTO_INCREMENT,
+  `task_id` INTEGER NOT NULL,
+  `author` VARCHAR(100) NOT NULL,
+  `body` TEXT NOT NULL,
+  `created_at` DATETIME NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `IDX_TASK_COMMENTS_TASK` (`task_id`),
+  CONSTRAINT `fk_TASK_COMMENTS_TASK`
+    FOREIGN KEY (`task_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`task_attachments`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`task_attachments` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`task_attachments` (
+  `id` INTEGER NOT NULL AUTO_INCREMENT,
+  `task_id` INTEGER NOT NULL,
+  `file_name` VARCHAR(255) NOT NULL,
+  `content_type` VARCHAR(100) NOT NULL,
+  `size` BIGINT NOT NULL,
+  `storage_key` VARCHAR(255) NOT NULL,
+  `uploaded_by` VARCHAR(100) NULL DEFAULT NULL,
+  `created_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `IDX_TASK_ATTACHMENTS_TASK` (`task_id`),
+  CONSTRAINT `fk_TASK_ATTACHMENTS_TASK`
+    FOREIGN KEY (`task_id`)
+    REFERENCES `TEST`.`tasks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`webhooks`
+-- events: tipos separados por comas, vacío recibe todos
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`webhooks` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`webhooks` (
+  `id` INTEGER NOT NULL AUTO_INCREMENT,
+  `url` VARCHAR(500) NOT NULL,
+  `secret` VARCHAR(100) NOT NULL,
+  `events` VARCHAR(200) NULL DEFAULT NULL,
+  `disabled` TINYINT(1) NOT NULL DEFAULT 0,
+  `center_code` VARCHAR(45) NOT NULL DEFAULT '',
+  `created_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `IDX_WEBHOOKS_CENTER` (`center_code`))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`webhook_deliveries`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`webhook_deliveries` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`webhook_deliveries` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `webhook_id` INTEGER NOT NULL,
+  `event_id` VARCHAR(45) NOT NULL,
+  `event_type` VARCHAR(45) NOT NULL,
+  `attempt` INT(11) NOT NULL,
+  `status_code` INT(11) NOT NULL DEFAULT 0,
+  `error` VARCHAR(500) NULL DEFAULT NULL,
+  `success` TINYINT(1) NOT NULL,
+  `duration_ms` BIGINT NOT NULL,
+  `created_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `IDX_WEBHOOK_DELIVERIES_WEBHOOK` (`webhook_id`),
+  CONSTRAINT `fk_WEBHOOK_DELIVERIES_WEBHOOK`
+    FOREIGN KEY (`webhook_id`)
+    REFERENCES `TEST`.`webhooks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`outbox_events`
+-- eventos escritos en la transacción de cada cambio; published_at nulo
+-- mientras el relay no los publique, seq es el orden en que los publica,
+-- delivered lista los destinos que ya lo aceptaron y dead_at marca los que
+-- agotaron sus intentos
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`outbox_events` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`outbox_events` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `event_id` VARCHAR(45) NOT NULL,
+  `type` VARCHAR(45) NOT NULL,
+  `task_id` INTEGER NOT NULL,
+  `payload` TEXT NOT NULL,
+  `occurred_at` DATETIME NOT NULL,
+  `published_at` DATETIME NULL DEFAULT NULL,
+  `seq` BIGINT NULL DEFAULT NULL,
+  `delivered` VARCHAR(200) NOT NULL DEFAULT '',
+  `attempts` INT(11) NOT NULL DEFAULT 0,
+  `last_error` VARCHAR(500) NULL DEFAULT NULL,
+  `dead_at` DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `EVENT_ID_UNIQUE` (`event_id` ASC),
+  UNIQUE INDEX `SEQ_UNIQUE` (`seq` ASC),
+  INDEX `IDX_OUTBOX_EVENTS_PUBLISHED_AT` (`published_at`),
+  INDEX `IDX_OUTBOX_EVENTS_DEAD_AT` (`dead_at`),
+  INDEX `IDX_OUTBOX_EVENTS_TASK` (`task_id`))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`task_events`
+-- historial de cada tarea; se escribe con el evento del outbox del mismo
+-- event_id y se conserva cuando el outbox borra los eventos ya publicados
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`task_events` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`task_events` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `event_id` VARCHAR(45) NOT NULL,
+  `type` VARCHAR(45) NOT NULL,
+  `task_id` INTEGER NOT NULL,
+  `payload` TEXT NOT NULL,
+  `occurred_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `TASK_EVENTS_EVENT_ID_UNIQUE` (`event_id` ASC),
+  INDEX `IDX_TASK_EVENTS_TASK` (`task_id`))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
 -- -----------------------------------------------------
 -- Table `TEST`.`users`
 -- -----------------------------------------------------
@@ -46,6 +267,7 @@ CREATE TABLE IF NOT EXISTS `TEST`.`users` (
   `center_code` VARCHAR(45) NOT NULL,
   `attempts` INT(11) NOT NULL,
   `status` VARCHAR(45) NOT NULL,
+  `locked_until` DATETIME NULL DEFAULT NULL,
   `created_at` DATETIME NULL,
   PRIMARY KEY (`email`, `center_code`),
   CONSTRAINT `fk_INTERNAL_USER_CENTER1`
@@ -84,3 +306,43 @@ CREATE TABLE IF NOT EXISTS `TEST`.`permissions` (
 ENGINE = InnoDB
 DEFAULT CHARACTER SET = utf8;
 
+
+-- -----------------------------------------------------
+-- Table `TEST`.`user_roles`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`user_roles` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`user_roles` (
+  `email` VARCHAR(100) NOT NULL,
+  `role_code` VARCHAR(45) NOT NULL,
+  PRIMARY KEY (`email`, `role_code`),
+  INDEX `IDX_USER_ROLES_ROLE` (`role_code` ASC))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+-- -----------------------------------------------------
+-- Table `TEST`.`api_keys`
+-- -----------------------------------------------------
+DROP TABLE IF EXISTS `TEST`.`api_keys` ;
+
+CREATE TABLE IF NOT EXISTS `TEST`.`api_keys` (
+  `id` INTEGER NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `prefix` VARCHAR(16) NOT NULL,
+  `hash` VARCHAR(100) NOT NULL,
+  `scopes` VARCHAR(200) NOT NULL,
+  `center_code` VARCHAR(45) NOT NULL DEFAULT '',
+  `created_by` VARCHAR(100) NULL DEFAULT NULL,
+  `created_at` DATETIME NULL,
+  `last_used_at` DATETIME NULL DEFAULT NULL,
+  `revoked_at` DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `UQ_API_KEYS_PREFIX` (`prefix`),
+  INDEX `IDX_API_KEYS_CENTER` (`center_code`))
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8;
+
+INSERT INTO `TEST`.`roles` (`code`, `name`, `description`) VALUES
+  ('ROL_1', 'Administrador', 'Gestiona tareas, etiquetas y webhooks'),
+  ('ROL_2', 'Lector', 'Consulta tareas y comenta'),
+  ('ROL_READ', 'Solo lectura', 'Consulta tareas, etiquetas y eventos, sin cambios');
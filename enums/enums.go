@@ -0,0 +1,27 @@
+package enums
+
+const (
+
+	// Tasks Status
+	PendingTaskStatus    string = "PENDING"
+	InProgressTaskStatus string = "IN_PROGRESS"
+	CompletedTaskStatus  string = "COMPLETED"
+
+	// Tasks Priority
+	LowTaskPriority    string = "LOW"
+	MediumTaskPriority string = "MEDIUM"
+	HighTaskPriority   string = "HIGH"
+	UrgentTaskPriority string = "URGENT"
+
+	// Users Status
+	ActiveUserStatus string = "ACTIVE"
+	LockedUserStatus string = "LOCKED"
+)
+
+// TaskPriorities - priorities from lowest to highest
+var TaskPriorities = []string{
+	LowTaskPriority,
+	MediumTaskPriority,
+	HighTaskPriority,
+	UrgentTaskPriority,
+}
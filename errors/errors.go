@@ -28,12 +28,52 @@ var (
 	InvalidToken = CustomError{Message: "Invalid token", Code: 400, InternalCode: "INVALID_TOKEN"}
 	ExpiredToken = CustomError{Message: "Expired token", Code: 400, InternalCode: "EXPIRED_TOKEN"}
 
+	InvalidCredentials = CustomError{Message: "Invalid email or password", Code: 401, InternalCode: "INVALID_CREDENTIALS"}
+	UserLocked         = CustomError{Message: "User locked", Code: 403, InternalCode: "USER_LOCKED"}
+
+	InvalidApiKey      = CustomError{Message: "Invalid API key", Code: 400, InternalCode: "INVALID_API_KEY"}
+	RevokedApiKey      = CustomError{Message: "Revoked API key", Code: 400, InternalCode: "REVOKED_API_KEY"}
+	ApiKeyNotFound     = CustomError{Message: "API key not found", Code: 404, InternalCode: "API_KEY_NOT_FOUND"}
+	ApiKeyScopeInvalid = CustomError{Message: "API key scope invalid", Code: 400, InternalCode: "API_KEY_SCOPE_INVALID"}
+
 	LenPassPolicy   = CustomError{Message: "No contain correct length", Code: 400, InternalCode: "WRONG_PASS_LENGTH"}
 	UpperPassPolicy = CustomError{Message: "No contain upper characters", Code: 400, InternalCode: "WRONG_PASS_CONTENT_U"}
 	LowerPassPolicy = CustomError{Message: "No contain lower characters", Code: 400, InternalCode: "WRONG_PASS_CONTENT_L"}
 	DigitPassPolicy = CustomError{Message: "No contain digit", Code: 400, InternalCode: "WRONG_PASS_CONTENT_D"}
 
-	TasksNotFound     = CustomError{Message: "Tasks not found", Code: 404, InternalCode: "TASKS_NOT_FOUND"}
-	TasksAlreadySaved = CustomError{Message: "Tasks already saved", Code: 400, InternalCode: "TASKS_ALREADY_SAVED"}
-	TaskStateInvalid  = CustomError{Message: "Task state invalid", Code: 400, InternalCode: "TASK_STATE_INVALID"}
+	TasksNotFound         = CustomError{Message: "Tasks not found", Code: 404, InternalCode: "TASKS_NOT_FOUND"}
+	TasksAlreadySaved     = CustomError{Message: "Tasks already saved", Code: 400, InternalCode: "TASKS_ALREADY_SAVED"}
+	TaskStateInvalid      = CustomError{Message: "Task state invalid", Code: 400, InternalCode: "TASK_STATE_INVALID"}
+	TaskPriorityInvalid   = CustomError{Message: "Task priority invalid", Code: 400, InternalCode: "TASK_PRIORITY_INVALID"}
+	TimeZoneInvalid       = CustomError{Message: "Time zone invalid", Code: 400, InternalCode: "TIME_ZONE_INVALID"}
+	TaskRecurrenceInvalid = CustomError{Message: "Task recurrence rule invalid", Code: 400, InternalCode: "TASK_RECURRENCE_INVALID"}
+	TaskWithoutCenter     = CustomError{Message: "Task without center", Code: 400, InternalCode: "TASK_WITHOUT_CENTER"}
+
+	TaskDependencyCycle       = CustomError{Message: "Task dependency cycle", Code: 409, InternalCode: "TASK_DEPENDENCY_CYCLE"}
+	TaskHasIncompleteSubtasks = CustomError{Message: "Task has incomplete subtasks", Code: 409, InternalCode: "TASK_INCOMPLETE_SUBTASKS"}
+	TaskBlocked               = CustomError{Message: "Task is blocked by incomplete tasks", Code: 409, InternalCode: "TASK_BLOCKED"}
+
+	TaskForbidden = CustomError{Message: "Without privileges for this task", Code: 403, InternalCode: "TASK_FORBIDDEN"}
+
+	LabelNotFound      = CustomError{Message: "Label not found", Code: 404, InternalCode: "LABEL_NOT_FOUND"}
+	LabelAlreadyExists = CustomError{Message: "Label already exists", Code: 400, InternalCode: "LABEL_ALREADY_EXISTS"}
+
+	CommentNotFound  = CustomError{Message: "Comment not found", Code: 404, InternalCode: "COMMENT_NOT_FOUND"}
+	CommentNotAuthor = CustomError{Message: "Only the author can change a comment", Code: 403, InternalCode: "COMMENT_NOT_AUTHOR"}
+
+	AttachmentNotFound       = CustomError{Message: "Attachment not found", Code: 404, InternalCode: "ATTACHMENT_NOT_FOUND"}
+	AttachmentTooLarge       = CustomError{Message: "Attachment too large", Code: 413, InternalCode: "ATTACHMENT_TOO_LARGE"}
+	AttachmentTypeNotAllowed = CustomError{Message: "Attachment content type not allowed", Code: 415, InternalCode: "ATTACHMENT_TYPE_NOT_ALLOWED"}
+
+	WebhookNotFound     = CustomError{Message: "Webhook not found", Code: 404, InternalCode: "WEBHOOK_NOT_FOUND"}
+	WebhookUrlInvalid   = CustomError{Message: "Webhook url invalid", Code: 400, InternalCode: "WEBHOOK_URL_INVALID"}
+	WebhookEventInvalid = CustomError{Message: "Webhook event type invalid", Code: 400, InternalCode: "WEBHOOK_EVENT_INVALID"}
+
+	UserNotFound      = CustomError{Message: "User not found", Code: 404, InternalCode: "USER_NOT_FOUND"}
+	UserAlreadyExists = CustomError{Message: "User already exists", Code: 400, InternalCode: "USER_ALREADY_EXISTS"}
+	RoleNotFound      = CustomError{Message: "Role not found", Code: 404, InternalCode: "ROLE_NOT_FOUND"}
+
+	BatchTooLarge  = CustomError{Message: "Batch too large", Code: 400, InternalCode: "BATCH_TOO_LARGE"}
+	BulkRolledBack = CustomError{Message: "Rolled back by a failing item", Code: 409, InternalCode: "BULK_ROLLED_BACK"}
+	ImportTooLarge = CustomError{Message: "Import too large", Code: 413, InternalCode: "IMPORT_TOO_LARGE"}
 )
@@ -0,0 +1,126 @@
+// Package events defines the task lifecycle events and the in-process bus the
+// outbox relay publishes them to, for the components that react to them
+// (webhooks, streams).
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/log"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "events")
+
+// Task event types.
+const (
+	TaskCreated      = "task.created"
+	TaskUpdated      = "task.updated"
+	TaskDeleted      = "task.deleted"
+	TaskStateChanged = "task.state_changed"
+)
+
+// Types - every event type, in the order they are documented
+var Types = []string{TaskCreated, TaskUpdated, TaskDeleted, TaskStateChanged}
+
+// StreamResync - sent by the event streams, not by the bus, to a client that
+// resumes after events already purged from the outbox; the client must
+// reload the tasks. Data is {"firstMissed","lastMissed"} and Seq is
+// lastMissed, the stream goes on after it.
+const StreamResync = "stream.resync"
+
+// Event - something that happened to a task. Data is the JSON payload of the
+// type: the task for created/updated, {"from","to","task"} for state_changed
+// and {"id","state"} for deleted. Seq is the order in which the relay
+// published the event; it increases with every published event.
+type Event struct {
+	Id         string          `json:"id"`
+	Seq        int64           `json:"seq,omitempty"`
+	Type       string          `json:"type"`
+	TaskId     int32           `json:"taskId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// New - builds an event with a random id, data is marshalled to JSON
+func New(eventType string, taskId int32, data interface{}) (Event, error) {
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Id:         NewId(),
+		Type:       eventType,
+		TaskId:     taskId,
+		OccurredAt: time.Now().UTC(),
+		Data:       raw,
+	}, nil
+}
+
+// NewId - random 128-bit hex id
+func NewId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Handler - receives published events. Handlers run on the publisher's
+// goroutine and must not block; slow work belongs in a queue of their own.
+type Handler func(ctx context.Context, e Event)
+
+// Bus - in-process publish/subscribe
+type Bus struct {
+	mu       sync.RWMutex
+	next     int
+	handlers map[int]Handler
+}
+
+// NewBus - gets an empty Bus
+func NewBus() *Bus {
+	return &Bus{handlers: map[int]Handler{}}
+}
+
+// Subscribe - registers h and returns the function that removes it
+func (b *Bus) Subscribe(h Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	b.handlers[id] = h
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish - hands e to every subscriber
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+
+	loggerf.WithField("type", e.Type).WithField("taskId", e.TaskId).WithField("subscribers", len(handlers)).Debug("event published")
+}
+
+var defaultBus = NewBus()
+
+// Default - the process-wide bus fed by the outbox relay
+func Default() *Bus {
+	return defaultBus
+}
@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Alonso-Arias/test-cleverit/services/comment"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits - bounds of the queries accepted by Execute
+type Limits struct {
+	// MaxComplexity - highest cost of an operation, where every field costs
+	// 1 and the fields under a list count once per requested element
+	MaxComplexity int
+	// MaxDepth - deepest nesting of fields
+	MaxDepth int
+}
+
+// LimitsFromEnv - reads GRAPHQL_MAX_COMPLEXITY (default 1000) and
+// GRAPHQL_MAX_DEPTH (default 8)
+func LimitsFromEnv() Limits {
+	return Limits{
+		MaxComplexity: envInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+		MaxDepth:      envInt("GRAPHQL_MAX_DEPTH", 8),
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// listArg - argument bounding the elements of a list field and its default
+type listArg struct {
+	name string
+	def  int
+}
+
+// lists - list fields of the schema, whose selections are paid per element
+var lists = map[string]listArg{
+	"tasks":    {"limit", DefaultTasksLimit},
+	"comments": {"size", comment.DefaultPageSize},
+	"history":  {"limit", task.DefaultHistoryLimit},
+}
+
+// cost - walks the selections of an operation adding up their complexity
+type cost struct {
+	limits    Limits
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+// Check - fails when the selected operation of doc goes over the limits
+func (l Limits) Check(doc *ast.Document, operationName string, variables map[string]interface{}) error {
+
+	c := cost{limits: l, fragments: map[string]*ast.FragmentDefinition{}, variables: variables, visiting: map[string]bool{}}
+
+	var op *ast.OperationDefinition
+	for _, d := range doc.Definitions {
+		switch d := d.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				op = d
+			}
+		}
+	}
+	if op == nil {
+		return nil
+	}
+
+	total, err := c.selections(op.SelectionSet, 1)
+	if err != nil {
+		return err
+	}
+	if total > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d is over the limit of %d", total, l.MaxComplexity)
+	}
+
+	return nil
+}
+
+func (c cost) selections(set *ast.SelectionSet, depth int) (int, error) {
+
+	if set == nil {
+		return 0, nil
+	}
+	if depth > c.limits.MaxDepth {
+		return 0, fmt.Errorf("query depth is over the limit of %d", c.limits.MaxDepth)
+	}
+
+	total := 0
+	for _, s := range set.Selections {
+		var n int
+		var err error
+		switch s := s.(type) {
+		case *ast.Field:
+			if n, err = c.selections(s.SelectionSet, depth+1); err == nil {
+				n = 1 + min(c.elements(s), c.limits.MaxComplexity+1)*n
+			}
+		case *ast.InlineFragment:
+			n, err = c.selections(s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			f, ok := c.fragments[name]
+			if !ok || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			n, err = c.selections(f.SelectionSet, depth)
+			delete(c.visiting, name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += n
+		// stops early on huge queries instead of overflowing
+		if total > c.limits.MaxComplexity {
+			return total, nil
+		}
+	}
+
+	return total, nil
+}
+
+// elements - number of elements the field asks for, 1 when it is no list
+func (c cost) elements(f *ast.Field) int {
+
+	l, ok := lists[f.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	for _, a := range f.Arguments {
+		if a.Name.Value != l.name {
+			continue
+		}
+		switch v := a.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n := number(c.variables[v.Name.Value]); n > 0 {
+				return n
+			}
+		}
+	}
+
+	return l.def
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// number - int of a variable decoded from JSON
+func number(v interface{}) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/label"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	testenv.BasePath()
+	os.Exit(m.Run())
+}
+
+func as(role string) context.Context {
+	return security.WithUser(context.Background(), model.AuthenticatedUser{Email: role + "@example.com", CenterCode: "C1", Roles: []model.Role{{Code: role}}})
+}
+
+// run - executes query with the default limits and decodes its data into out
+func run(t *testing.T, ctx context.Context, query string, variables map[string]interface{}, out interface{}) *graphql.Result {
+	res := Execute(ctx, Request{Query: query, Variables: variables}, Limits{MaxComplexity: 1000, MaxDepth: 8})
+	if out != nil && res.Data != nil {
+		b, _ := json.Marshal(res.Data)
+		if err := json.Unmarshal(b, out); err != nil {
+			t.Fatalf("fails to decode data: %v", err)
+		}
+	}
+	return res
+}
+
+func TestExecute_TaskRoundTrip(t *testing.T) {
+	testenv.SQLite(t)
+	ctx := as("ROL_1")
+
+	var created struct {
+		CreateTask struct{ Id int } `json:"createTask"`
+	}
+	res := run(t, ctx, `mutation($in: TaskInput!) { createTask(input: $in) { id } }`, map[string]interface{}{
+		"in": map[string]interface{}{"title": "graphql", "description": "one round trip", "state": "PENDING", "dueDate": "2030-01-01T10:00:00Z", "labels": []interface{}{}},
+	}, &created)
+	if !assert.Empty(t, res.Errors) {
+		return
+	}
+	id := created.CreateTask.Id
+
+	var moved struct {
+		TransitionTask struct{ State string } `json:"transitionTask"`
+	}
+	res = run(t, ctx, `mutation($id: Int!) { transitionTask(id: $id, state: "IN_PROGRESS") { state } }`, map[string]interface{}{"id": id}, &moved)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, "IN_PROGRESS", moved.TransitionTask.State)
+
+	var got struct {
+		Task struct {
+			Title    string
+			Labels   []string
+			Comments struct{ Total int }
+			History  []struct{ Type string }
+		}
+	}
+	res = run(t, ctx, `query($id: Int!) {
+		task(id: $id) { title labels comments { total } history(limit: 5) { type } }
+	}`, map[string]interface{}{"id": id}, &got)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, "graphql", got.Task.Title)
+	assert.Equal(t, []string{}, got.Task.Labels)
+	assert.Zero(t, got.Task.Comments.Total)
+	assert.NotEmpty(t, got.Task.History)
+
+	var page struct {
+		Tasks struct {
+			Items []struct{ Id int }
+			Total int
+		}
+	}
+	res = run(t, ctx, `{ tasks(filter: {state: "IN_PROGRESS"}, limit: 1) { items { id } total } }`, nil, &page)
+	assert.Empty(t, res.Errors)
+	assert.Len(t, page.Tasks.Items, 1)
+	assert.NotZero(t, page.Tasks.Total)
+
+	res = run(t, ctx, `mutation($id: Int!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id}, nil)
+	assert.Empty(t, res.Errors)
+
+	res = run(t, ctx, `query($id: Int!) { task(id: $id) { id } }`, map[string]interface{}{"id": id}, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Equal(t, "TASKS_NOT_FOUND", res.Errors[0].Extensions["internalCode"])
+	}
+}
+
+func TestExecute_UpdateKeepsLabels(t *testing.T) {
+	testenv.SQLite(t)
+	ctx := as("ROL_1")
+
+	_, err := label.LabelService{}.SaveLabel(ctx, label.SaveLabelRequest{Label: model.Label{Name: "graphql-keep"}})
+	assert.NoError(t, err)
+
+	var created struct {
+		CreateTask struct{ Id int } `json:"createTask"`
+	}
+	res := run(t, ctx, `mutation($in: TaskInput!) { createTask(input: $in) { id } }`, map[string]interface{}{
+		"in": map[string]interface{}{"title": "labels", "description": "kept", "state": "PENDING", "dueDate": "2030-01-01T10:00:00Z", "labels": []interface{}{"graphql-keep"}},
+	}, &created)
+	if !assert.Empty(t, res.Errors) {
+		return
+	}
+
+	var updated struct {
+		UpdateTask struct{ Labels []string } `json:"updateTask"`
+	}
+	update := `mutation($id: Int!, $in: TaskInput!) { updateTask(id: $id, input: $in) { labels } }`
+	res = run(t, ctx, update, map[string]interface{}{
+		"id": created.CreateTask.Id,
+		"in": map[string]interface{}{"title": "labels", "description": "renamed", "state": "PENDING", "dueDate": "2030-01-01T10:00:00Z"},
+	}, &updated)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, []string{"graphql-keep"}, updated.UpdateTask.Labels)
+
+	res = run(t, ctx, update, map[string]interface{}{
+		"id": created.CreateTask.Id,
+		"in": map[string]interface{}{"title": "labels", "description": "cleared", "state": "PENDING", "dueDate": "2030-01-01T10:00:00Z", "labels": []interface{}{}},
+	}, &updated)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, []string{}, updated.UpdateTask.Labels)
+}
+
+func TestExecute_FieldAuthorization(t *testing.T) {
+	testenv.SQLite(t)
+
+	res := run(t, context.Background(), `{ tasks { total } }`, nil, nil)
+	assert.Len(t, res.Errors, 1)
+
+	res = run(t, as("ROL_2"), `mutation { createTask(input: {title: "t", description: "d", state: "PENDING"}) { id } }`, nil, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Equal(t, []interface{}{"createTask"}, res.Errors[0].Path)
+		assert.Equal(t, 401, res.Errors[0].Extensions["code"])
+	}
+
+	res = run(t, as("ROL_2"), `mutation { deleteTask(id: 1) }`, nil, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Equal(t, []interface{}{"deleteTask"}, res.Errors[0].Path)
+		assert.Equal(t, 401, res.Errors[0].Extensions["code"])
+	}
+
+	res = run(t, as("ROL_2"), `{ tasks(limit: 1) { total } }`, nil, nil)
+	assert.Empty(t, res.Errors)
+
+	res = run(t, as("ROL_3"), `{ tasks { total } }`, nil, nil)
+	assert.Len(t, res.Errors, 1)
+	assert.Nil(t, res.Data)
+}
+
+func TestLimits_Check(t *testing.T) {
+	testenv.SQLite(t)
+	ctx := as("ROL_1")
+
+	res := run(t, ctx, `{ tasks(limit: 500) { items { comments(size: 100) { items { body } } } } }`, nil, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Contains(t, res.Errors[0].Message, "complexity")
+	}
+	assert.Nil(t, res.Data)
+
+	// variables and fragments are counted too
+	res = run(t, ctx, `query($n: Int) { tasks(limit: $n) { ...page } } fragment page on TaskPage { items { history(limit: 100) { type } } }`,
+		map[string]interface{}{"n": float64(50)}, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Contains(t, res.Errors[0].Message, "complexity")
+	}
+
+	// limit 0 would return every task
+	res = run(t, ctx, `{ tasks(limit: 0) { items { id } } }`, nil, nil)
+	if assert.Len(t, res.Errors, 1) {
+		assert.Equal(t, "BADREQUEST", res.Errors[0].Extensions["internalCode"])
+	}
+
+	res = Execute(ctx, Request{Query: `{ tasks { items { id } } }`}, Limits{MaxComplexity: 1000, MaxDepth: 2})
+	if assert.Len(t, res.Errors, 1) {
+		assert.Contains(t, res.Errors[0].Message, "depth")
+	}
+
+	res = run(t, ctx, `{ tasks { items { nope } } }`, nil, nil)
+	assert.Len(t, res.Errors, 1)
+}
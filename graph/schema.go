@@ -0,0 +1,369 @@
+// Package graph serves tasks over GraphQL. Queries and mutations are resolved
+// through the services, every guarded field is authorized with the casbin rule
+// of the REST endpoint it mirrors, and requests above a complexity or depth
+// limit are rejected before running.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/comment"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/graphql-go/graphql"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "graph")
+
+// DefaultTasksLimit - page size of the tasks query when limit is missing
+const DefaultTasksLimit = 20
+
+// Error - service error exposed with its codes in the GraphQL error extensions
+type Error struct {
+	errs.CustomError
+}
+
+// Extensions -
+func (e Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code, "internalCode": e.InternalCode}
+}
+
+// resolveError - wraps service errors so clients get their codes
+func resolveError(err error) error {
+	if ce, ok := err.(errs.CustomError); ok {
+		return Error{ce}
+	}
+	loggerf.WithError(err).Error("unexpected error")
+	return Error{errs.InternalError}
+}
+
+// guard - resolves the field only when the authenticated user may call the
+// REST endpoint given by rule
+func guard(rule func(p graphql.ResolveParams) (method string, path string), resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		au, ok := security.UserFromContext(p.Context)
+		if !ok {
+			return nil, Error{errs.Unauthorized}
+		}
+		method, path := rule(p)
+		if !security.IsAuthorized(au, method, path) {
+			return nil, Error{errs.Unauthorized.SetMessage("Without privileges for " + p.Info.ParentType.Name() + "." + p.Info.FieldName)}
+		}
+		v, err := resolve(p)
+		if err != nil {
+			return nil, resolveError(err)
+		}
+		return v, nil
+	}
+}
+
+// fixed - rule of a field that always maps to the same endpoint
+func fixed(method, path string) func(graphql.ResolveParams) (string, string) {
+	return func(graphql.ResolveParams) (string, string) { return method, path }
+}
+
+// argTask - rule of a field on the task given by its id argument
+func argTask(method, format string) func(graphql.ResolveParams) (string, string) {
+	return func(p graphql.ResolveParams) (string, string) {
+		id, _ := p.Args["id"].(int)
+		return method, fmt.Sprintf(format, id)
+	}
+}
+
+// sourceTask - rule of a field of the Task being resolved
+func sourceTask(method, format string) func(graphql.ResolveParams) (string, string) {
+	return func(p graphql.ResolveParams) (string, string) {
+		return method, fmt.Sprintf(format, p.Source.(model.Task).Id)
+	}
+}
+
+var commentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Comment",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"author":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"body":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"updatedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var commentPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CommentPage",
+	Fields: graphql.Fields{
+		"items": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType)))},
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var taskEventType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskEvent",
+	Fields: graphql.Fields{
+		"seq": &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return strconv.FormatInt(p.Source.(model.TaskEvent).Seq, 10), nil
+		}},
+		"type":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"occurredAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"data": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "JSON payload of the event",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return string(p.Source.(model.TaskEvent).Data), nil
+			}},
+	},
+})
+
+var taskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"dueDate":     &graphql.Field{Type: graphql.String},
+		"state":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"priority":    &graphql.Field{Type: graphql.String},
+		"parentId": &graphql.Field{Type: graphql.Int, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if id := p.Source.(model.Task).ParentId; id != 0 {
+				return id, nil
+			}
+			return nil, nil
+		}},
+		"recurrence": &graphql.Field{Type: graphql.String},
+		"overdue":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"labels": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if labels := p.Source.(model.Task).Labels; labels != nil {
+				return labels, nil
+			}
+			return []string{}, nil
+		}},
+		"comments": &graphql.Field{
+			Type: commentPageType,
+			Args: graphql.FieldConfigArgument{
+				"page": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+				"size": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: comment.DefaultPageSize},
+			},
+			Resolve: guard(sourceTask(http.MethodGet, "/api/v1/task/%d/comments"), func(p graphql.ResolveParams) (interface{}, error) {
+				res, err := comment.CommentService{}.ListComments(p.Context, comment.ListCommentsRequest{
+					TaskId: p.Source.(model.Task).Id, Page: p.Args["page"].(int), Size: p.Args["size"].(int),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"items": res.Comments, "total": res.Total}, nil
+			}),
+		},
+		"history": &graphql.Field{
+			Type:        graphql.NewList(graphql.NewNonNull(taskEventType)),
+			Description: "last events of the task, newest first",
+			Args: graphql.FieldConfigArgument{
+				"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: task.DefaultHistoryLimit},
+			},
+			Resolve: guard(fixed(http.MethodGet, "/api/v1/task/events"), func(p graphql.ResolveParams) (interface{}, error) {
+				res, err := task.TaskService{}.GetTaskHistory(p.Context, task.GetTaskHistoryRequest{
+					Id: p.Source.(model.Task).Id, Limit: p.Args["limit"].(int),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return res.Events, nil
+			}),
+		},
+	},
+})
+
+var taskPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskPage",
+	Fields: graphql.Fields{
+		"items": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType)))},
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var taskFilterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TaskFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"state":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"priority": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"label":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueFrom":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueTo":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"sort":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "id, priority, -priority, due_date or -due_date"},
+	},
+})
+
+var taskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"state":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"priority":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"parentId":    &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"recurrence":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"labels":      &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+	},
+})
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"tasks": &graphql.Field{
+			Type: graphql.NewNonNull(taskPageType),
+			Args: graphql.FieldConfigArgument{
+				"filter": &graphql.ArgumentConfig{Type: taskFilterType},
+				"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultTasksLimit},
+				"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+			},
+			Resolve: guard(fixed(http.MethodGet, "/api/v1/task/findAll"), func(p graphql.ResolveParams) (interface{}, error) {
+				// FindAllTasks returns every task with limit 0, which the
+				// complexity check cannot weigh
+				if p.Args["limit"].(int) < 1 {
+					return nil, errs.BadRequest.SetMessage("limit must be positive")
+				}
+				f, _ := p.Args["filter"].(map[string]interface{})
+				res, err := task.TaskService{}.FindAllTasks(p.Context, task.FindAllTasksRequest{
+					State:    str(f["state"]),
+					Priority: str(f["priority"]),
+					Label:    str(f["label"]),
+					DueFrom:  str(f["dueFrom"]),
+					DueTo:    str(f["dueTo"]),
+					Sort:     str(f["sort"]),
+					Limit:    p.Args["limit"].(int),
+					Offset:   p.Args["offset"].(int),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"items": res.Tasks, "total": res.Total}, nil
+			}),
+		},
+		"task": &graphql.Field{
+			Type: taskType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: guard(argTask(http.MethodGet, "/api/v1/task/%d"), func(p graphql.ResolveParams) (interface{}, error) {
+				return getTask(p.Context, p.Args["id"].(int))
+			}),
+		},
+	},
+})
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createTask": &graphql.Field{
+			Type: taskType,
+			Args: graphql.FieldConfigArgument{
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			},
+			Resolve: guard(fixed(http.MethodPost, "/api/v1/task"), func(p graphql.ResolveParams) (interface{}, error) {
+				res, err := task.TaskService{}.SaveTask(p.Context, task.SaveTaskRequest{Task: taskInput(p.Args["input"], 0)})
+				if err != nil {
+					return nil, err
+				}
+				return getTask(p.Context, int(res.Id))
+			}),
+		},
+		"updateTask": &graphql.Field{
+			Type: taskType,
+			Args: graphql.FieldConfigArgument{
+				"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			},
+			Resolve: guard(fixed(http.MethodPut, "/api/v1/task"), func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Args["id"].(int)
+				_, err := task.TaskService{}.UpdateTask(p.Context, task.UpdateTaskRequest{Task: taskInput(p.Args["input"], int32(id))})
+				if err != nil {
+					return nil, err
+				}
+				return getTask(p.Context, id)
+			}),
+		},
+		"deleteTask": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: guard(argTask(http.MethodDelete, "/api/v1/task/%d"), func(p graphql.ResolveParams) (interface{}, error) {
+				if _, err := (task.TaskService{}).DeleteTask(p.Context, task.DeleteTaskRequest{Id: int32(p.Args["id"].(int))}); err != nil {
+					return nil, err
+				}
+				return true, nil
+			}),
+		},
+		"transitionTask": &graphql.Field{
+			Type:        taskType,
+			Description: "moves a task to state with the rules of a state change",
+			Args: graphql.FieldConfigArgument{
+				"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"state": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: guard(fixed(http.MethodPut, "/api/v1/task"), func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Args["id"].(int)
+				res, err := task.TaskService{}.BulkUpdateTasks(p.Context, task.BulkUpdateTasksRequest{
+					Ids: []int32{int32(id)}, State: p.Args["state"].(string), AllOrNothing: true,
+				})
+				if err != nil {
+					return nil, err
+				}
+				if r := res.Results[0]; !r.Success {
+					return nil, *r.Error
+				}
+				return getTask(p.Context, id)
+			}),
+		},
+	},
+})
+
+// Schema - the tasks GraphQL schema
+var Schema = func() graphql.Schema {
+	s, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}()
+
+func getTask(ctx context.Context, id int) (interface{}, error) {
+	res, err := task.TaskService{}.GetTask(ctx, task.GetTaskRequest{Id: int32(id)})
+	if err != nil {
+		return nil, err
+	}
+	return res.Task, nil
+}
+
+func taskInput(v interface{}, id int32) model.Task {
+	in, _ := v.(map[string]interface{})
+	t := model.Task{
+		Id:          id,
+		Title:       str(in["title"]),
+		Description: str(in["description"]),
+		DueDate:     str(in["dueDate"]),
+		State:       str(in["state"]),
+		Priority:    str(in["priority"]),
+		Recurrence:  str(in["recurrence"]),
+	}
+	if parentId, ok := in["parentId"].(int); ok {
+		t.ParentId = int32(parentId)
+	}
+	// without the labels key the labels are kept on update
+	if labels, ok := in["labels"]; ok {
+		t.Labels = []string{}
+		list, _ := labels.([]interface{})
+		for _, l := range list {
+			t.Labels = append(t.Labels, str(l))
+		}
+	}
+	return t
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
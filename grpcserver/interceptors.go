@@ -0,0 +1,162 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/proto/taskpb"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/apikey"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from the calls.
+const (
+	authorizationKey = "authorization"
+	timeZoneKey      = "time-zone"
+)
+
+// ErrorDomain - domain of the errdetails.ErrorInfo attached to the statuses,
+// whose Reason is the InternalCode of the errs.CustomError
+const ErrorDomain = "test-cleverit"
+
+// permission - REST method and path whose access policy applies to a call
+type permission func(req interface{}) (method string, path string)
+
+// permissions - the casbin rules of each RPC are those of the REST endpoint
+// it mirrors
+var permissions = map[string]permission{
+	taskpb.TaskService_FindAllTasks_FullMethodName: func(interface{}) (string, string) {
+		return http.MethodGet, "/api/v1/task/findAll"
+	},
+	taskpb.TaskService_GetTask_FullMethodName: func(req interface{}) (string, string) {
+		return http.MethodGet, fmt.Sprintf("/api/v1/task/%d", req.(*taskpb.GetTaskRequest).GetId())
+	},
+	taskpb.TaskService_SaveTask_FullMethodName: func(interface{}) (string, string) {
+		return http.MethodPost, "/api/v1/task"
+	},
+	taskpb.TaskService_UpdateTask_FullMethodName: func(interface{}) (string, string) {
+		return http.MethodPut, "/api/v1/task"
+	},
+	taskpb.TaskService_DeleteTask_FullMethodName: func(req interface{}) (string, string) {
+		return http.MethodDelete, fmt.Sprintf("/api/v1/task/%d", req.(*taskpb.DeleteTaskRequest).GetId())
+	},
+}
+
+// Authenticate - reads the user token from the "authorization: Bearer ..."
+// metadata, or the API key from "authorization: ApiKey ...", and the time
+// zone from "time-zone" into the call context. Calls without a token go on
+// anonymously.
+func Authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if v := md.Get(timeZoneKey); len(v) > 0 && v[0] != "" {
+		loc, err := task.LoadLocation(v[0])
+		if err != nil {
+			return nil, err
+		}
+		ctx = task.WithLocation(ctx, loc)
+	}
+
+	if v := md.Get(authorizationKey); len(v) > 0 && strings.HasPrefix(v[0], "Bearer ") {
+		au, err := security.ParseToken(strings.TrimPrefix(v[0], "Bearer "))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = security.WithUser(ctx, au)
+	} else if len(v) > 0 && strings.HasPrefix(v[0], "ApiKey ") {
+		au, err := apikey.ApiKeyService{}.Authenticate(ctx, strings.TrimPrefix(v[0], "ApiKey "))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = security.WithUser(ctx, au)
+	}
+
+	return handler(ctx, req)
+}
+
+// Authorize - lets the call through only when the authenticated user has a
+// role allowed by the access policy for the REST endpoint it mirrors
+func Authorize(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	perm, ok := permissions[info.FullMethod]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "no access policy for "+info.FullMethod)
+	}
+
+	au, ok := security.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, errs.Unauthorized.Message)
+	}
+
+	method, path := perm(req)
+	if !security.IsAuthorized(au, method, path) {
+		return nil, status.Error(codes.PermissionDenied, "Without privileges for this function")
+	}
+
+	return handler(ctx, req)
+}
+
+// Errors - converts the errors of the services into gRPC statuses
+func Errors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	res, err := handler(ctx, req)
+	if err != nil {
+		err = ToStatus(err).Err()
+	}
+
+	return res, err
+}
+
+// ToStatus - maps errs.CustomError to the gRPC code of its HTTP code, with
+// its InternalCode in an ErrorInfo detail. Statuses are kept and any other
+// error is Internal.
+func ToStatus(err error) *status.Status {
+
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	ce, ok := err.(errs.CustomError)
+	if !ok {
+		loggerf.WithError(err).Error("unexpected error")
+		ce = errs.InternalError
+	}
+
+	s := status.New(Code(ce.Code), ce.Message)
+	if d, err := s.WithDetails(&errdetails.ErrorInfo{Reason: ce.InternalCode, Domain: ErrorDomain}); err == nil {
+		s = d
+	}
+
+	return s
+}
+
+// Code - gRPC code equivalent to an HTTP status code
+func Code(httpCode int) codes.Code {
+	switch httpCode {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge:
+		return codes.ResourceExhausted
+	case http.StatusInternalServerError:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,123 @@
+// Package grpcserver serves the task operations of services/task over gRPC,
+// next to the REST API and with the same access policy.
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/proto/taskpb"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"google.golang.org/grpc"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "grpcserver")
+
+// New - gets a gRPC server exposing TaskService, with authentication,
+// authorization and error mapping interceptors
+func New() *grpc.Server {
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(Errors, Authenticate, Authorize))
+	taskpb.RegisterTaskServiceServer(s, &TaskServer{})
+	return s
+}
+
+// TaskServer - taskpb.TaskServiceServer backed by task.TaskService
+type TaskServer struct {
+	taskpb.UnimplementedTaskServiceServer
+}
+
+// FindAllTasks -
+func (s *TaskServer) FindAllTasks(ctx context.Context, in *taskpb.FindAllTasksRequest) (*taskpb.FindAllTasksResponse, error) {
+
+	res, err := task.TaskService{}.FindAllTasks(ctx, task.FindAllTasksRequest{
+		State:    in.GetState(),
+		Priority: in.GetPriority(),
+		Label:    in.GetLabel(),
+		DueFrom:  in.GetDueFrom(),
+		DueTo:    in.GetDueTo(),
+		Sort:     in.GetSort(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &taskpb.FindAllTasksResponse{Tasks: make([]*taskpb.Task, 0, len(res.Tasks))}
+	for _, t := range res.Tasks {
+		out.Tasks = append(out.Tasks, toProto(t))
+	}
+
+	return out, nil
+}
+
+// GetTask -
+func (s *TaskServer) GetTask(ctx context.Context, in *taskpb.GetTaskRequest) (*taskpb.GetTaskResponse, error) {
+
+	res, err := task.TaskService{}.GetTask(ctx, task.GetTaskRequest{Id: in.GetId()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskpb.GetTaskResponse{Task: toProto(res.Task)}, nil
+}
+
+// SaveTask -
+func (s *TaskServer) SaveTask(ctx context.Context, in *taskpb.SaveTaskRequest) (*taskpb.SaveTaskResponse, error) {
+
+	res, err := task.TaskService{}.SaveTask(ctx, task.SaveTaskRequest{Task: fromProto(in.GetTask())})
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskpb.SaveTaskResponse{Id: res.Id}, nil
+}
+
+// UpdateTask -
+func (s *TaskServer) UpdateTask(ctx context.Context, in *taskpb.UpdateTaskRequest) (*taskpb.UpdateTaskResponse, error) {
+
+	res, err := task.TaskService{}.UpdateTask(ctx, task.UpdateTaskRequest{Task: fromProto(in.GetTask())})
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskpb.UpdateTaskResponse{NextOccurrenceId: res.NextOccurrenceId}, nil
+}
+
+// DeleteTask -
+func (s *TaskServer) DeleteTask(ctx context.Context, in *taskpb.DeleteTaskRequest) (*taskpb.DeleteTaskResponse, error) {
+
+	if _, err := (task.TaskService{}).DeleteTask(ctx, task.DeleteTaskRequest{Id: in.GetId()}); err != nil {
+		return nil, err
+	}
+
+	return &taskpb.DeleteTaskResponse{}, nil
+}
+
+func toProto(t model.Task) *taskpb.Task {
+	return &taskpb.Task{
+		Id:          t.Id,
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     t.DueDate,
+		State:       t.State,
+		Priority:    t.Priority,
+		ParentId:    t.ParentId,
+		Recurrence:  t.Recurrence,
+		Overdue:     t.Overdue,
+		Labels:      t.Labels,
+	}
+}
+
+func fromProto(t *taskpb.Task) model.Task {
+	return model.Task{
+		Id:          t.GetId(),
+		Title:       t.GetTitle(),
+		Description: t.GetDescription(),
+		DueDate:     t.GetDueDate(),
+		State:       t.GetState(),
+		Priority:    t.GetPriority(),
+		ParentId:    t.GetParentId(),
+		Recurrence:  t.GetRecurrence(),
+		Labels:      t.GetLabels(),
+	}
+}
@@ -0,0 +1,126 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/proto/taskpb"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	testenv.BasePath()
+	security.TokenSecret = []byte("test-secret")
+	os.Exit(m.Run())
+}
+
+// dial - starts the server on an in-memory listener and connects to it
+func dial(t *testing.T) taskpb.TaskServiceClient {
+	testenv.SQLite(t)
+
+	lis := bufconn.Listen(1 << 20)
+	s := New()
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("fails to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return taskpb.NewTaskServiceClient(conn)
+}
+
+// as - outgoing context with a token of a user with role
+func as(t *testing.T, role string) context.Context {
+	token, err := security.IssueToken(model.AuthenticatedUser{Email: role + "@example.com", CenterCode: "C1", Roles: []model.Role{{Code: role}}}, time.Hour)
+	if err != nil {
+		t.Fatalf("fails to issue token: %v", err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestTaskServer_CRUD(t *testing.T) {
+	client := dial(t)
+	ctx := as(t, "ROL_1")
+
+	saved, err := client.SaveTask(ctx, &taskpb.SaveTaskRequest{Task: &taskpb.Task{
+		Title: "grpc", Description: "over grpc", State: "PENDING", DueDate: "2030-01-01T10:00:00Z", Labels: []string{},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got, err := client.GetTask(metadata.AppendToOutgoingContext(ctx, "time-zone", "America/Santiago"), &taskpb.GetTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc", got.Task.Title)
+	assert.Equal(t, "2030-01-01T07:00:00-03:00", got.Task.DueDate)
+
+	got.Task.State = "IN_PROGRESS"
+	_, err = client.UpdateTask(ctx, &taskpb.UpdateTaskRequest{Task: got.Task})
+	assert.NoError(t, err)
+
+	all, err := client.FindAllTasks(ctx, &taskpb.FindAllTasksRequest{State: "IN_PROGRESS"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, all.Tasks)
+
+	_, err = client.DeleteTask(ctx, &taskpb.DeleteTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+
+	_, err = client.GetTask(ctx, &taskpb.GetTaskRequest{Id: saved.Id})
+	s := status.Convert(err)
+	assert.Equal(t, codes.NotFound, s.Code())
+	if assert.Len(t, s.Details(), 1) {
+		info := s.Details()[0].(*errdetails.ErrorInfo)
+		assert.Equal(t, "TASKS_NOT_FOUND", info.Reason)
+		assert.Equal(t, ErrorDomain, info.Domain)
+	}
+
+	_, err = client.SaveTask(ctx, &taskpb.SaveTaskRequest{Task: &taskpb.Task{Title: "no state"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaskServer_Authorization(t *testing.T) {
+	client := dial(t)
+
+	_, err := client.FindAllTasks(context.Background(), &taskpb.FindAllTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	bad := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")
+	_, err = client.FindAllTasks(bad, &taskpb.FindAllTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	reader := as(t, "ROL_2")
+	_, err = client.FindAllTasks(reader, &taskpb.FindAllTasksRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.SaveTask(reader, &taskpb.SaveTaskRequest{Task: &taskpb.Task{Title: "t", Description: "d", State: "PENDING"}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.DeleteTask(reader, &taskpb.DeleteTaskRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, codes.InvalidArgument, Code(400))
+	assert.Equal(t, codes.NotFound, Code(404))
+	assert.Equal(t, codes.FailedPrecondition, Code(409))
+	assert.Equal(t, codes.Internal, Code(500))
+	assert.Equal(t, codes.Unknown, Code(418))
+}
@@ -17,7 +17,7 @@ func Logger(file string) *apexLog.Entry {
 
 	apexLog.SetHandler(cli.Default)
 	apexLog.SetLevel(apexLog.InfoLevel)
-	apexLog.SetHandler(json.New(os.Stderr))
+	apexLog.SetHandler(NewRedactHandler(json.New(os.Stderr)))
 
 	ctx := apexLog.WithFields(apexLog.Fields{
 		"file": file,
@@ -31,7 +31,7 @@ func Logger(file string) *apexLog.Entry {
 func LoggerJSON() *apexLog.Logger {
 
 	l := &apexLog.Logger{
-		Handler: json.New(os.Stderr),
+		Handler: NewRedactHandler(json.New(os.Stderr)),
 		Level:   apexLog.DebugLevel,
 	}
 
@@ -0,0 +1,213 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	apexLog "github.com/apex/log"
+)
+
+// RedactedValue replaces every sensitive value before it reaches a handler.
+const RedactedValue = "[REDACTED]"
+
+// maxRedactDepth limits how deep nested values are inspected.
+const maxRedactDepth = 8
+
+// Secret marks a value that must never be written in clear text.
+type Secret string
+
+// String implements fmt.Stringer so a Secret never prints its content.
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+var defaultRedactedFields = []string{
+	"password", "passwd", "secret", "token", "apikey", "authorization", "email",
+}
+
+var redaction = struct {
+	sync.RWMutex
+	fields map[string]bool
+	types  map[reflect.Type]bool
+	emails bool
+}{
+	fields: map[string]bool{},
+	types:  map[reflect.Type]bool{reflect.TypeOf(Secret("")): true},
+	emails: true,
+}
+
+func init() {
+	RedactFields(defaultRedactedFields...)
+}
+
+// RedactFields adds field names whose values are masked. Names are matched
+// case-insensitively ignoring "_" and "-", and also as a suffix, so "password"
+// covers "userPassword" and "new_password".
+func RedactFields(names ...string) {
+	redaction.Lock()
+	defer redaction.Unlock()
+	for _, n := range names {
+		redaction.fields[normalizeFieldName(n)] = true
+	}
+}
+
+// RedactTypes adds the dynamic types of the given sample values to the set of
+// types that are always masked, wherever they appear in an entry.
+func RedactTypes(samples ...interface{}) {
+	redaction.Lock()
+	defer redaction.Unlock()
+	for _, s := range samples {
+		if s != nil {
+			redaction.types[reflect.TypeOf(s)] = true
+		}
+	}
+}
+
+// RedactEmails enables or disables masking of email addresses found inside
+// messages and string values.
+func RedactEmails(enabled bool) {
+	redaction.Lock()
+	defer redaction.Unlock()
+	redaction.emails = enabled
+}
+
+// RedactHandler masks sensitive fields, types and emails and then delegates
+// the entry to the wrapped handler.
+type RedactHandler struct {
+	Handler apexLog.Handler
+}
+
+// NewRedactHandler wraps h with the redaction layer.
+func NewRedactHandler(h apexLog.Handler) *RedactHandler {
+	return &RedactHandler{Handler: h}
+}
+
+// HandleLog implements apexLog.Handler.
+func (h *RedactHandler) HandleLog(e *apexLog.Entry) error {
+	redaction.RLock()
+	defer redaction.RUnlock()
+
+	redacted := *e
+	redacted.Message = redactString(e.Message)
+	redacted.Fields = apexLog.Fields{}
+	for k, v := range e.Fields {
+		if isRedactedField(k) {
+			redacted.Fields[k] = RedactedValue
+			continue
+		}
+		redacted.Fields[k] = redactValue(reflect.ValueOf(v), 0)
+	}
+
+	return h.Handler.HandleLog(&redacted)
+}
+
+func normalizeFieldName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "_", "")
+	return strings.ReplaceAll(name, "-", "")
+}
+
+func isRedactedField(name string) bool {
+	n := normalizeFieldName(name)
+	if redaction.fields[n] {
+		return true
+	}
+	for f := range redaction.fields {
+		if strings.HasSuffix(n, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactString(s string) string {
+	if !redaction.emails {
+		return s
+	}
+	return emailPattern.ReplaceAllString(s, RedactedValue)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// redactValue returns a copy of v that is safe to hand to a handler. Structs
+// and maps are converted to maps so that their fields can be masked by name.
+func redactValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if redaction.types[v.Type()] {
+		return RedactedValue
+	}
+	if depth > maxRedactDepth {
+		return fmt.Sprintf("%v", v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), depth+1)
+	case reflect.String:
+		return redactString(v.String())
+	case reflect.Struct:
+		if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(stringerType) {
+			return v.Interface()
+		}
+		out := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			if isRedactedField(f.Name) || isRedactedField(name) {
+				out[name] = RedactedValue
+				continue
+			}
+			out[name] = redactValue(v.Field(i), depth+1)
+		}
+		return out
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		out := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if isRedactedField(k) {
+				out[k] = RedactedValue
+				continue
+			}
+			out[k] = redactValue(iter.Value(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = redactValue(v.Index(i), depth+1)
+		}
+		return out
+	}
+
+	return v.Interface()
+}
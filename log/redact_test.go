@@ -0,0 +1,68 @@
+package log
+
+import (
+	"testing"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Token    string
+}
+
+type apiKey string
+
+func TestRedactHandler_Fields(t *testing.T) {
+
+	h := memory.New()
+	l := &apexLog.Logger{Handler: NewRedactHandler(h), Level: apexLog.DebugLevel}
+
+	l.WithField("password", "Secreto123").
+		WithField("new_password", "Secreto456").
+		WithField("accessToken", "abc").
+		WithField("hola", 2).
+		Info("login")
+
+	assert.Len(t, h.Entries, 1)
+	f := h.Entries[0].Fields
+	assert.Equal(t, RedactedValue, f["password"])
+	assert.Equal(t, RedactedValue, f["new_password"])
+	assert.Equal(t, RedactedValue, f["accessToken"])
+	assert.Equal(t, 2, f["hola"])
+}
+
+func TestRedactHandler_StructsAndTypes(t *testing.T) {
+
+	RedactTypes(apiKey(""))
+
+	h := memory.New()
+	l := &apexLog.Logger{Handler: NewRedactHandler(h), Level: apexLog.DebugLevel}
+
+	l.WithField("creds", credentials{User: "tobi", Password: "Secreto123", Token: "xyz"}).
+		WithField("keys", []apiKey{"k1"}).
+		WithField("secret", Secret("s")).
+		Debug("creds")
+
+	f := h.Entries[0].Fields
+	creds := f["creds"].(map[string]interface{})
+	assert.Equal(t, "tobi", creds["user"])
+	assert.Equal(t, RedactedValue, creds["password"])
+	assert.Equal(t, RedactedValue, creds["Token"])
+	assert.Equal(t, []interface{}{RedactedValue}, f["keys"])
+	assert.Equal(t, RedactedValue, f["secret"])
+}
+
+func TestRedactHandler_Emails(t *testing.T) {
+
+	h := memory.New()
+	l := &apexLog.Logger{Handler: NewRedactHandler(h), Level: apexLog.DebugLevel}
+
+	l.WithField("user", "tobi@example.com").Info("user tobi@example.com locked")
+
+	assert.Equal(t, "user "+RedactedValue+" locked", h.Entries[0].Message)
+	assert.Equal(t, RedactedValue, h.Entries[0].Fields["user"])
+}
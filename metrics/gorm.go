@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DAOMethodKey is the GORM setting used by the DAOs to name the method that
+// issues a statement, e.g. db.Set(metrics.DAOMethodKey, "TaskDAOImpl.FindAll").
+const DAOMethodKey = "metrics:dao_method"
+
+const startKey = "metrics:start"
+
+// GormPlugin observes the duration of every GORM statement in DBQueryDuration.
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin.
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	return RegisterGormHooks(db, p.Name(),
+		func(string) func(*gorm.DB) { return before },
+		after,
+	)
+}
+
+// RegisterGormHooks registers, around every kind of GORM statement (create,
+// query, update, delete, row and raw), the callbacks built by before and
+// after for that operation, named <plugin>:before_<operation> and
+// <plugin>:after_<operation>. The metrics and tracing plugins share it.
+func RegisterGormHooks(db *gorm.DB, plugin string, before, after func(operation string) func(*gorm.DB)) error {
+	cb := db.Callback()
+
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, h := range hooks {
+		if err := h.before(plugin+":before_"+h.operation, before(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after(plugin+":after_"+h.operation, after(h.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		method := "unknown"
+		if m, ok := db.Get(DAOMethodKey); ok {
+			if s, ok := m.(string); ok {
+				method = s
+			}
+		}
+
+		status := "ok"
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			status = "error"
+		}
+
+		DBQueryDuration.WithLabelValues(method, operation, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tasks_api"
+
+// Registry holds every collector exposed by /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests per route, method and status.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total HTTP requests handled, by route, method and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes request latency per route, method and status.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration observes GORM statement latency per DAO method and operation.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency, by DAO method and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"dao_method", "operation", "status"})
+
+	// TasksCreated counts created tasks per initial state.
+	TasksCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Tasks created, by state.",
+	}, []string{"state"})
+
+	// TasksUpdated counts updated tasks per resulting state.
+	TasksUpdated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_updated_total",
+		Help:      "Tasks updated, by resulting state.",
+	}, []string{"state"})
+
+	// TasksCompleted counts tasks moved to the COMPLETED state.
+	TasksCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_completed_total",
+		Help:      "Tasks moved to the COMPLETED state.",
+	})
+
+	// TasksDeleted counts deleted tasks.
+	TasksDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_deleted_total",
+		Help:      "Tasks deleted.",
+	})
+
+	// RemindersSent counts reminder events per kind and notifier outcome.
+	RemindersSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_sent_total",
+		Help:      "Reminder events emitted, by kind and status.",
+	}, []string{"kind", "status"})
+
+	// WebhookDeliveries counts webhook delivery attempts per event type and outcome.
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook delivery attempts, by event type and status.",
+	}, []string{"type", "status"})
+
+	// OutboxRelayed counts outbox events handed to each sink, by outcome.
+	OutboxRelayed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_relayed_total",
+		Help:      "Outbox events relayed, by sink and status.",
+	}, []string{"sink", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		DBQueryDuration,
+		TasksCreated,
+		TasksUpdated,
+		TasksCompleted,
+		TasksDeleted,
+		RemindersSent,
+		WebhookDeliveries,
+		OutboxRelayed,
+	)
+}
+
+var dbCollector prometheus.Collector
+
+// RegisterDB exposes the connection pool stats of db as gauges, replacing the
+// pool registered by a previous call.
+func RegisterDB(db *sql.DB, name string) error {
+	if dbCollector != nil {
+		Registry.Unregister(dbCollector)
+	}
+	dbCollector = collectors.NewDBStatsCollector(db, name)
+	return Registry.Register(dbCollector)
+}
+
+// Handler returns the http.Handler serving the registry in the Prometheus
+// exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
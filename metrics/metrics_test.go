@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_CountsPerRoute(t *testing.T) {
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/task/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return c.JSON(http.StatusBadRequest, "bad")
+		}
+		return c.JSON(http.StatusOK, "ok")
+	})
+	e.GET("/metrics", echo.WrapHandler(Handler()))
+
+	for _, id := range []string{"1", "2", "0"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/task/"+id, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/api/v1/task/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/api/v1/task/:id", "400")))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "tasks_api_http_request_duration_seconds"))
+}
+
+func TestMiddleware_HTTPError(t *testing.T) {
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/task/findAll", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/task/findAll", nil))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/api/v1/task/findAll", "403")))
+}
@@ -0,0 +1,295 @@
+// Package outbox relays the task events written by TaskService to the outbox
+// table, in the transaction of each change, to the configured sinks. Every
+// sink that accepts an event is recorded on its row and not published to
+// again; the event is marked as published only after every sink accepted it.
+// Delivery is at-least-once, consumers must deduplicate by event id. An event
+// that fails MaxAttempts times is dead-lettered and no longer relayed.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "outbox")
+
+// Sinks supported by ConfigFromEnv.
+const (
+	SinkBus   = "bus"
+	SinkFile  = "file"
+	SinkNATS  = "nats"
+	SinkKafka = "kafka"
+)
+
+// Config - relay configuration
+type Config struct {
+	// Sinks is the list of sinks every event is published to.
+	Sinks []string
+	// Interval is the time between two scans of the outbox.
+	Interval time.Duration
+	// BatchSize is the maximum number of events relayed per scan.
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which an event is
+	// dead-lettered, 0 retries forever.
+	MaxAttempts int
+	// Retention is how long published events are kept in the outbox.
+	Retention time.Duration
+	// File is the path appended to by the file sink.
+	File string
+	// NATSURL and NATSSubject configure the NATS sink; events are published
+	// to NATSSubject.<event type>.
+	NATSURL     string
+	NATSSubject string
+	// KafkaBrokers and KafkaTopic configure the Kafka sink; messages are
+	// keyed by task id.
+	KafkaBrokers []string
+	KafkaTopic   string
+}
+
+// ConfigFromEnv - builds a Config from OUTBOX_*, NATS_* and KAFKA_* variables
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Sinks:        []string{SinkBus},
+		Interval:     500 * time.Millisecond,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		Retention:    24 * time.Hour,
+		File:         os.Getenv("OUTBOX_FILE"),
+		NATSURL:      os.Getenv("NATS_URL"),
+		NATSSubject:  os.Getenv("NATS_SUBJECT"),
+		KafkaBrokers: []string{"localhost:9092"},
+		KafkaTopic:   os.Getenv("KAFKA_TOPIC"),
+	}
+
+	if v := os.Getenv("OUTBOX_SINKS"); v != "" {
+		cfg.Sinks = split(v)
+	}
+	if v, err := time.ParseDuration(os.Getenv("OUTBOX_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && v >= 0 {
+		cfg.MaxAttempts = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("OUTBOX_RETENTION")); err == nil && v > 0 {
+		cfg.Retention = v
+	}
+	if cfg.File == "" {
+		cfg.File = "events.json"
+	}
+	if cfg.NATSURL == "" {
+		cfg.NATSURL = "nats://localhost:4222"
+	}
+	if cfg.NATSSubject == "" {
+		cfg.NATSSubject = "tasks"
+	}
+	if v := os.Getenv("KAFKA_BROKERS"); v != "" {
+		cfg.KafkaBrokers = split(v)
+	}
+	if cfg.KafkaTopic == "" {
+		cfg.KafkaTopic = "tasks"
+	}
+
+	return cfg
+}
+
+func split(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// NewSinks - builds the sinks of cfg; bus publishes to the process-wide bus
+// that feeds webhooks and streams
+func NewSinks(cfg Config) ([]Sink, error) {
+	sinks := []Sink{}
+	for _, name := range cfg.Sinks {
+		var s Sink
+		var err error
+		switch name {
+		case SinkBus:
+			s = NewBusSink(events.Default())
+		case SinkFile:
+			s, err = NewFileSink(cfg.File)
+		case SinkNATS:
+			s, err = NewNATSSink(cfg.NATSURL, cfg.NATSSubject)
+		case SinkKafka:
+			s = NewKafkaSink(cfg.KafkaBrokers, cfg.KafkaTopic)
+		default:
+			err = fmt.Errorf("unknown outbox sink %q", name)
+		}
+		if err != nil {
+			Close(sinks)
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// Close - closes every sink, logging failures
+func Close(sinks []Sink) {
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			loggerf.WithError(err).WithField("sink", s.Name()).Error("problems with closing sink")
+		}
+	}
+}
+
+// Relay - periodically publishes the pending outbox events, oldest first
+type Relay struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Retention   time.Duration
+	Sinks       []Sink
+	// Now is the clock, time.Now when nil.
+	Now func() time.Time
+
+	outboxDAO dao.OutboxDAO
+}
+
+// NewRelay - gets a Relay that publishes to sinks
+func NewRelay(cfg Config, sinks []Sink) *Relay {
+	return &Relay{
+		Interval:    cfg.Interval,
+		BatchSize:   cfg.BatchSize,
+		MaxAttempts: cfg.MaxAttempts,
+		Retention:   cfg.Retention,
+		Sinks:       sinks,
+		outboxDAO:   dao.NewOutboxDAO(),
+	}
+}
+
+// Run - relays every Interval until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Tick(ctx); err != nil && ctx.Err() == nil {
+			loggerf.WithError(err).Error("outbox relay fails")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick - relays one batch and deletes the published events older than
+// Retention. Returns the number of events published; it stops at the first
+// event a sink rejects so later events are not relayed before it, unless
+// that event is dead-lettered.
+func (r *Relay) Tick(ctx context.Context) (int, error) {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+
+	pending, err := r.outboxDAO.FindPending(ctx, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, row := range pending {
+		if err := r.relay(ctx, row); err != nil {
+			if markErr := r.outboxDAO.MarkFailed(ctx, row.Id, err.Error()); markErr != nil {
+				return published, markErr
+			}
+			log := loggerf.WithError(err).WithField("eventId", row.EventId).WithField("attempts", row.Attempts+1)
+			if r.MaxAttempts == 0 || row.Attempts+1 < r.MaxAttempts {
+				log.Warn("outbox event not relayed")
+				break
+			}
+			if markErr := r.outboxDAO.MarkDead(ctx, row.Id, now()); markErr != nil {
+				return published, markErr
+			}
+			log.Error("outbox event dead-lettered, no attempts left")
+			continue
+		}
+		if err := r.outboxDAO.MarkPublished(ctx, row.Id, now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	if r.Retention > 0 {
+		if _, err := r.outboxDAO.DeletePublished(ctx, now().Add(-r.Retention)); err != nil {
+			return published, err
+		}
+	}
+
+	return published, nil
+}
+
+// relay - publishes row to every sink that has not accepted it yet, recording
+// each one that does. Its Seq is assigned on the first attempt, so Seq
+// follows the order of publication even when a row commits after one
+// inserted later.
+func (r *Relay) relay(ctx context.Context, row model.OutboxEvent) error {
+	seq, err := r.outboxDAO.AssignSeq(ctx, row.Id)
+	if err != nil {
+		return err
+	}
+	row.Seq = &seq
+	e := ToEvent(row)
+	delivered := split(row.Delivered)
+	for _, s := range r.Sinks {
+		if contains(delivered, s.Name()) {
+			continue
+		}
+		if err := s.Publish(ctx, e); err != nil {
+			metrics.OutboxRelayed.WithLabelValues(s.Name(), "failure").Inc()
+			return fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		metrics.OutboxRelayed.WithLabelValues(s.Name(), "success").Inc()
+		delivered = append(delivered, s.Name())
+		if err := r.outboxDAO.MarkDelivered(ctx, row.Id, strings.Join(delivered, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ToEvent - the event stored in an outbox row, with the Seq of the row
+func ToEvent(row model.OutboxEvent) events.Event {
+	e := events.Event{
+		Id:         row.EventId,
+		Type:       row.Type,
+		TaskId:     row.TaskId,
+		OccurredAt: row.OccurredAt.UTC(),
+		Data:       []byte(row.Payload),
+	}
+	if row.Seq != nil {
+		e.Seq = *row.Seq
+	}
+	return e
+}
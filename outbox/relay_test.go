@@ -0,0 +1,304 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink guarda los eventos y falla mientras fails sea mayor que cero.
+type memorySink struct {
+	name      string
+	fails     int
+	published []events.Event
+}
+
+func (s *memorySink) Name() string {
+	if s.name == "" {
+		return "memory"
+	}
+	return s.name
+}
+
+func (s *memorySink) Publish(ctx context.Context, e events.Event) error {
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, e)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// drain publica los eventos que dejaron otros tests.
+func drain(t *testing.T) {
+	_, err := (&Relay{BatchSize: 10000, Sinks: []Sink{&memorySink{}}, outboxDAO: dao.NewOutboxDAO()}).Tick(context.TODO())
+	assert.NoError(t, err)
+}
+
+func write(t *testing.T, taskId int32, eventType string) model.OutboxEvent {
+	e, _ := events.New(eventType, taskId, map[string]int32{"id": taskId})
+	row := model.OutboxEvent{EventId: e.Id, Type: e.Type, TaskId: e.TaskId, Payload: string(e.Data), OccurredAt: e.OccurredAt}
+	assert.NoError(t, dao.NewOutboxDAO().Save(context.TODO(), &row))
+	return row
+}
+
+func TestRelay_AtLeastOnceInOrder(t *testing.T) {
+
+	testenv.SQLite(t)
+	drain(t)
+
+	first := write(t, 1, events.TaskCreated)
+	second := write(t, 1, events.TaskUpdated)
+
+	// un evento escrito en una transacción deshecha nunca se publica
+	base.Transaction(context.TODO(), func(ctx context.Context) error {
+		e, _ := events.New(events.TaskDeleted, 1, nil)
+		dao.NewOutboxDAO().Save(ctx, &model.OutboxEvent{EventId: e.Id, Type: e.Type, TaskId: 1, Payload: "null", OccurredAt: e.OccurredAt})
+		return errors.New("rollback")
+	})
+
+	ok := &memorySink{name: "ok"}
+	flaky := &memorySink{name: "flaky", fails: 1}
+	relay := &Relay{BatchSize: 10, Sinks: []Sink{ok, flaky}, outboxDAO: dao.NewOutboxDAO()}
+
+	// el segundo destino falla: nada queda publicado y el orden se conserva
+	n, err := relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	pending, _ := dao.NewOutboxDAO().FindPending(context.TODO(), 10)
+	if assert.Len(t, pending, 2) {
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Contains(t, pending[0].LastError, "flaky: sink unavailable")
+		assert.Equal(t, "ok", pending[0].Delivered)
+	}
+
+	n, err = relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// el primer destino ya lo había aceptado y no lo recibe de nuevo
+	assert.Len(t, ok.published, 2)
+	if assert.Len(t, flaky.published, 2) {
+		assert.Equal(t, first.EventId, flaky.published[0].Id)
+		assert.Equal(t, second.EventId, flaky.published[1].Id)
+		assert.JSONEq(t, `{"id":1}`, string(flaky.published[0].Data))
+	}
+
+	pending, _ = dao.NewOutboxDAO().FindPending(context.TODO(), 10)
+	assert.Empty(t, pending)
+}
+
+func TestRelay_DeadLetter(t *testing.T) {
+
+	testenv.SQLite(t)
+	drain(t)
+
+	stuck := write(t, 3, events.TaskCreated)
+	next := write(t, 3, events.TaskUpdated)
+
+	broken := &memorySink{name: "broken", fails: 2}
+	relay := &Relay{BatchSize: 10, MaxAttempts: 2, Sinks: []Sink{broken}, outboxDAO: dao.NewOutboxDAO()}
+
+	// el primer intento falla y detiene el lote
+	n, err := relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	// el último intento lo deja en dead-letter y los siguientes siguen su curso
+	n, err = relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	if assert.Len(t, broken.published, 1) {
+		assert.Equal(t, next.EventId, broken.published[0].Id)
+	}
+
+	dead := model.OutboxEvent{}
+	base.GetDB().First(&dead, stuck.Id)
+	assert.NotNil(t, dead.DeadAt)
+	assert.Nil(t, dead.PublishedAt)
+	assert.Equal(t, 2, dead.Attempts)
+
+	pending, _ := dao.NewOutboxDAO().FindPending(context.TODO(), 10)
+	assert.Empty(t, pending)
+}
+
+func TestRelay_DeletesAfterRetention(t *testing.T) {
+
+	testenv.SQLite(t)
+	drain(t)
+
+	row := write(t, 2, events.TaskCreated)
+	last := write(t, 2, events.TaskUpdated)
+
+	now := time.Now()
+	relay := &Relay{BatchSize: 10, Retention: time.Hour, Sinks: []Sink{&memorySink{}}, outboxDAO: dao.NewOutboxDAO()}
+	relay.Now = func() time.Time { return now }
+	_, err := relay.Tick(context.TODO())
+	assert.NoError(t, err)
+
+	count := func() int64 {
+		var n int64
+		base.GetDB().Model(&model.OutboxEvent{}).Where("id = ?", row.Id).Count(&n)
+		return n
+	}
+	assert.Equal(t, int64(1), count())
+
+	relay.Now = func() time.Time { return now.Add(2 * time.Hour) }
+	_, err = relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	assert.Zero(t, count())
+
+	// el último evento publicado se conserva y el siguiente seq lo continúa
+	kept := model.OutboxEvent{}
+	assert.NoError(t, base.GetDB().First(&kept, last.Id).Error)
+	next := write(t, 2, events.TaskDeleted)
+	seq, err := dao.NewOutboxDAO().AssignSeq(context.TODO(), next.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, *kept.Seq+1, seq)
+}
+
+func TestRelay_SeqFollowsPublication(t *testing.T) {
+
+	testenv.SQLite(t)
+	drain(t)
+
+	sink := &memorySink{}
+	relay := &Relay{BatchSize: 10, Sinks: []Sink{sink}, outboxDAO: dao.NewOutboxDAO()}
+
+	// una fila insertada antes pero confirmada después de otra tiene el id
+	// menor y el seq mayor
+	var top int64
+	base.GetDB().Model(&model.OutboxEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&top)
+	outboxRow := func(id int64, eventType string) model.OutboxEvent {
+		e, _ := events.New(eventType, 4, nil)
+		row := model.OutboxEvent{Id: id, EventId: e.Id, Type: e.Type, TaskId: 4, Payload: "null", OccurredAt: e.OccurredAt}
+		assert.NoError(t, dao.NewOutboxDAO().Save(context.TODO(), &row))
+		return row
+	}
+
+	committed := outboxRow(top+10, events.TaskUpdated)
+	_, err := relay.Tick(context.TODO())
+	assert.NoError(t, err)
+	late := outboxRow(top+5, events.TaskCreated)
+	_, err = relay.Tick(context.TODO())
+	assert.NoError(t, err)
+
+	if assert.Len(t, sink.published, 2) {
+		assert.Equal(t, committed.EventId, sink.published[0].Id)
+		assert.Equal(t, late.EventId, sink.published[1].Id)
+		assert.Equal(t, sink.published[0].Seq+1, sink.published[1].Seq)
+	}
+
+	replayed, err := dao.NewOutboxDAO().FindPublishedAfter(context.TODO(), sink.published[0].Seq, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, replayed, 1) {
+		assert.Equal(t, late.EventId, replayed[0].EventId)
+	}
+}
+
+func TestBusSink(t *testing.T) {
+	bus := events.NewBus()
+	received := []string{}
+	bus.Subscribe(func(_ context.Context, e events.Event) { received = append(received, e.Id) })
+
+	e, _ := events.New(events.TaskCreated, 1, nil)
+	assert.NoError(t, NewBusSink(bus).Publish(context.TODO(), e))
+	assert.Equal(t, []string{e.Id}, received)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	s, err := NewFileSink(path)
+	assert.NoError(t, err)
+
+	e, _ := events.New(events.TaskDeleted, 3, map[string]int32{"id": 3})
+	assert.NoError(t, s.Publish(context.TODO(), e))
+	assert.NoError(t, s.Close())
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	assert.True(t, scanner.Scan())
+	got := events.Event{}
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+	assert.Equal(t, e.Id, got.Id)
+	assert.Equal(t, events.TaskDeleted, got.Type)
+}
+
+type fakeNATS struct {
+	msgs    []*nats.Msg
+	flushed int
+}
+
+func (c *fakeNATS) PublishMsg(m *nats.Msg) error {
+	c.msgs = append(c.msgs, m)
+	return nil
+}
+
+func (c *fakeNATS) FlushTimeout(time.Duration) error {
+	c.flushed++
+	return nil
+}
+
+func (c *fakeNATS) Close() {}
+
+func TestNATSSink(t *testing.T) {
+	conn := &fakeNATS{}
+	e, _ := events.New(events.TaskStateChanged, 4, nil)
+
+	assert.NoError(t, NewNATSSinkWithConn(conn, "tasks").Publish(context.TODO(), e))
+	if assert.Len(t, conn.msgs, 1) {
+		assert.Equal(t, "tasks.task.state_changed", conn.msgs[0].Subject)
+		assert.Equal(t, e.Id, conn.msgs[0].Header.Get(nats.MsgIdHdr))
+	}
+	assert.Equal(t, 1, conn.flushed)
+}
+
+type fakeKafka struct {
+	msgs []kafka.Message
+}
+
+func (w *fakeKafka) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeKafka) Close() error { return nil }
+
+func TestKafkaSink(t *testing.T) {
+	writer := &fakeKafka{}
+	e, _ := events.New(events.TaskUpdated, 42, nil)
+
+	assert.NoError(t, NewKafkaSinkWithWriter(writer).Publish(context.TODO(), e))
+	if assert.Len(t, writer.msgs, 1) {
+		assert.Equal(t, "42", string(writer.msgs[0].Key))
+		assert.Equal(t, []kafka.Header{
+			{Key: "event-id", Value: []byte(e.Id)},
+			{Key: "event-type", Value: []byte(events.TaskUpdated)},
+		}, writer.msgs[0].Headers)
+	}
+}
+
+func TestNewSinks_Unknown(t *testing.T) {
+	_, err := NewSinks(Config{Sinks: []string{SinkBus, "carrier-pigeon"}})
+	assert.Error(t, err)
+}
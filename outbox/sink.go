@@ -0,0 +1,192 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// Sink - destination of the relayed events. Publish returns nil only when the
+// event was accepted; otherwise the relay retries it.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e events.Event) error
+	Close() error
+}
+
+// BusSink - publishes to an in-process bus
+type BusSink struct {
+	bus *events.Bus
+}
+
+// NewBusSink - gets a BusSink for bus
+func NewBusSink(bus *events.Bus) *BusSink {
+	return &BusSink{bus: bus}
+}
+
+// Name -
+func (s *BusSink) Name() string { return SinkBus }
+
+// Publish -
+func (s *BusSink) Publish(ctx context.Context, e events.Event) error {
+	s.bus.Publish(ctx, e)
+	return nil
+}
+
+// Close -
+func (s *BusSink) Close() error { return nil }
+
+// FileSink - appends every event as a JSON line to a file
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink - opens path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+// Name -
+func (s *FileSink) Name() string { return SinkFile }
+
+// Publish -
+func (s *FileSink) Publish(ctx context.Context, e events.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close - closes the file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// NATSConn - the part of *nats.Conn used by NATSSink
+type NATSConn interface {
+	PublishMsg(m *nats.Msg) error
+	FlushTimeout(timeout time.Duration) error
+	Close()
+}
+
+// NATSSink - publishes every event to <subject>.<event type>, with the event
+// id in the Nats-Msg-Id header so JetStream streams deduplicate retries
+type NATSSink struct {
+	conn    NATSConn
+	subject string
+}
+
+// NewNATSSink - connects to the NATS server at url
+func NewNATSSink(url string, subject string) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("test-cleverit-outbox"))
+	if err != nil {
+		return nil, err
+	}
+	return NewNATSSinkWithConn(conn, subject), nil
+}
+
+// NewNATSSinkWithConn - gets a NATSSink that publishes through conn
+func NewNATSSinkWithConn(conn NATSConn, subject string) *NATSSink {
+	return &NATSSink{conn: conn, subject: subject}
+}
+
+// Name -
+func (s *NATSSink) Name() string { return SinkNATS }
+
+// Publish - the flush makes the server acknowledge the message before the
+// event is marked as published
+func (s *NATSSink) Publish(ctx context.Context, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(s.subject + "." + e.Type)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, e.Id)
+
+	if err := s.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	timeout := 5 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return s.conn.FlushTimeout(timeout)
+}
+
+// Close - closes the connection
+func (s *NATSSink) Close() error {
+	s.conn.Close()
+	return nil
+}
+
+// KafkaWriter - the part of *kafka.Writer used by KafkaSink
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSink - writes every event to a topic, keyed by task id so the events
+// of a task keep their order within a partition
+type KafkaSink struct {
+	writer KafkaWriter
+}
+
+// NewKafkaSink - gets a KafkaSink that waits for every in-sync replica
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return NewKafkaSinkWithWriter(&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	})
+}
+
+// NewKafkaSinkWithWriter - gets a KafkaSink that writes through writer
+func NewKafkaSinkWithWriter(writer KafkaWriter) *KafkaSink {
+	return &KafkaSink{writer: writer}
+}
+
+// Name -
+func (s *KafkaSink) Name() string { return SinkKafka }
+
+// Publish -
+func (s *KafkaSink) Publish(ctx context.Context, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.Itoa(int(e.TaskId))),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(e.Id)},
+			{Key: "event-type", Value: []byte(e.Type)},
+		},
+	})
+}
+
+// Close - flushes and closes the writer
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+func attachmentPath(taskId, id int32) string {
+	return fmt.Sprintf("%s/attachments/%d", taskPath(taskId), id)
+}
+
+// ListAttachments - GET /task/{id}/attachments
+func (c *Client) ListAttachments(ctx context.Context, taskId int32) (ListAttachmentsResponse, error) {
+	out := ListAttachmentsResponse{}
+	err := c.call(ctx, request{method: http.MethodGet, path: taskPath(taskId) + "/attachments"}, &out)
+	return out, err
+}
+
+// UploadAttachment - POST /task/{id}/attachments, streaming r as the file
+// fileName. An empty contentType lets the API guess it from the extension.
+func (c *Client) UploadAttachment(ctx context.Context, taskId int32, fileName, contentType string, r io.Reader) (SaveAttachmentResponse, error) {
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, fileName))
+		if contentType != "" {
+			h.Set("Content-Type", contentType)
+		}
+		part, err := mw.CreatePart(h)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	// unblocks the writer when the request ends before reading it all
+	defer pr.Close()
+
+	out := SaveAttachmentResponse{}
+	err := c.call(ctx, request{method: http.MethodPost, path: taskPath(taskId) + "/attachments", body: pr, contentType: mw.FormDataContentType()}, &out)
+	return out, err
+}
+
+// DownloadAttachment - GET /task/{id}/attachments/{attachmentId}, copying
+// the content into w
+func (c *Client) DownloadAttachment(ctx context.Context, taskId, id int32, w io.Writer) error {
+
+	res, err := c.do(ctx, request{method: http.MethodGet, path: attachmentPath(taskId, id)})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// DeleteAttachment - DELETE /task/{id}/attachments/{attachmentId}
+func (c *Client) DeleteAttachment(ctx context.Context, taskId, id int32) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: attachmentPath(taskId, id)}, nil)
+}
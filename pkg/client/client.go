@@ -0,0 +1,260 @@
+// Package client is a typed Go client of the task REST API. Calls take a
+// context, send the user token and time zone of the Config, retry idempotent
+// requests on network errors and unavailable servers, and return the
+// errs.CustomError sent by the API as the error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+)
+
+// Defaults of Config.
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 200 * time.Millisecond
+)
+
+// Config - settings of a Client
+type Config struct {
+	// BaseURL - scheme and host of the API, e.g. http://localhost:1323
+	BaseURL string
+	// Token - user token sent as "Authorization: Bearer <token>"
+	Token string
+	// TokenSource - gets the token of each request, takes precedence over
+	// Token for callers that refresh it
+	TokenSource func(ctx context.Context) (string, error)
+	// ApiKey - API key of a service sent as "Authorization: ApiKey <key>"
+	// when there is no token
+	ApiKey string
+	// TimeZone - IANA time zone of the dates, sent in the Time-Zone header
+	TimeZone string
+	// HTTPClient - http.DefaultClient when nil
+	HTTPClient *http.Client
+	// MaxRetries - retries of idempotent requests, DefaultMaxRetries when 0
+	// and none when negative
+	MaxRetries int
+	// RetryWait - wait before the first retry, doubled on each one
+	RetryWait time.Duration
+}
+
+// Client - client of the task API, safe for concurrent use
+type Client struct {
+	cfg Config
+}
+
+// New - gets a client for cfg
+func New(cfg Config) *Client {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryWait <= 0 {
+		cfg.RetryWait = DefaultRetryWait
+	}
+	return &Client{cfg: cfg}
+}
+
+// request - a call to the API; body is either a JSON value or a reader sent
+// as is with contentType
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        interface{}
+	contentType string
+	header      http.Header
+	// once - sends r a single time, for calls retried by their caller
+	once bool
+}
+
+// idempotent - methods that may be sent again without changing the outcome
+func (r request) idempotent() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable - responses worth another attempt
+func retryable(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do - sends r and returns the response when its status is 2xx, or the
+// decoded API error otherwise. The caller closes the body.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+
+	var payload []byte
+	reader, streamed := r.body.(io.Reader)
+	if r.body != nil && !streamed {
+		var err error
+		if payload, err = json.Marshal(r.body); err != nil {
+			return nil, err
+		}
+		r.contentType = "application/json"
+	}
+
+	attempts := 1
+	if r.idempotent() && !streamed && !r.once && c.cfg.MaxRetries > 0 {
+		attempts += c.cfg.MaxRetries
+	}
+
+	wait := c.cfg.RetryWait
+	for attempt := 1; ; attempt++ {
+		body := reader
+		if !streamed && payload != nil {
+			body = bytes.NewReader(payload)
+		}
+
+		res, err := c.send(ctx, r, body)
+		if err == nil && res.StatusCode < 300 {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= attempts || (err == nil && !retryable(res.StatusCode)) {
+			if err != nil {
+				return nil, err
+			}
+			defer res.Body.Close()
+			return nil, decodeError(res)
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(wait):
+			wait *= 2
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r request, body io.Reader) (*http.Response, error) {
+
+	u := c.cfg.BaseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if c.cfg.TimeZone != "" {
+		req.Header.Set("Time-Zone", c.cfg.TimeZone)
+	}
+
+	token := c.cfg.Token
+	if c.cfg.TokenSource != nil {
+		if token, err = c.cfg.TokenSource(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.cfg.ApiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.cfg.ApiKey)
+	}
+
+	return c.cfg.HTTPClient.Do(req)
+}
+
+// call - sends r and decodes the JSON response into out
+func (c *Client) call(ctx context.Context, r request, out interface{}) error {
+
+	res, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// decodeError - the errs.CustomError of an error response. Bodies that are
+// no CustomError keep the HTTP status as Code.
+func decodeError(res *http.Response) error {
+
+	ce := errs.CustomError{}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	json.Unmarshal(b, &ce)
+
+	if ce.Code == 0 {
+		ce.Code = res.StatusCode
+	}
+	if ce.Message == "" {
+		ce.Message = http.StatusText(res.StatusCode)
+	}
+
+	return ce
+}
+
+// values - url values of the fields of v with a query tag, skipping zero
+// values and walking embedded structs
+func values(v interface{}) url.Values {
+
+	q := url.Values{}
+	addValues(q, reflect.ValueOf(v))
+	return q
+}
+
+func addValues(q url.Values, rv reflect.Value) {
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f, fv := rt.Field(i), rv.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			addValues(q, fv)
+			continue
+		}
+		name := f.Tag.Get("query")
+		if name == "" || fv.IsZero() {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			q.Set(name, fv.String())
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			q.Set(name, strconv.FormatInt(fv.Int(), 10))
+		case reflect.Bool:
+			q.Set(name, strconv.FormatBool(fv.Bool()))
+		default:
+			q.Set(name, fmt.Sprint(fv.Interface()))
+		}
+	}
+}
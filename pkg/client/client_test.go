@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(url string) *Client {
+	return New(Config{BaseURL: url, Token: "secret", TimeZone: "America/Santiago", RetryWait: time.Millisecond})
+}
+
+func TestClient_RetriesIdempotentCalls(t *testing.T) {
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "America/Santiago", r.Header.Get("Time-Zone"))
+		assert.Equal(t, "limit=5&state=PENDING", r.URL.RawQuery)
+		fmt.Fprint(w, `{"tasks":[{"id":7,"title":"t"}],"total":1}`)
+	}))
+	defer srv.Close()
+
+	res, err := newClient(srv.URL).FindAllTasks(context.Background(), TaskFilter{State: "PENDING", Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls)
+	assert.Equal(t, int32(7), res.Tasks[0].Id)
+	assert.Equal(t, int64(1), res.Total)
+
+	// POST is sent once
+	atomic.StoreInt32(&calls, 0)
+	_, err = newClient(srv.URL).SaveTask(context.Background(), SaveTaskRequest{})
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(errs.CustomError).Code)
+}
+
+func TestClient_DecodesCustomError(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/task/1":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Tasks not found","code":404,"internalCode":"TASKS_NOT_FOUND"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"code=400, message=Syntax error"}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := newClient(srv.URL)
+
+	_, err := c.GetTask(context.Background(), 1)
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	err = c.DeleteTask(context.Background(), 2)
+	ce := errs.CustomError{}
+	if assert.True(t, errors.As(err, &ce)) {
+		assert.Equal(t, http.StatusBadRequest, ce.Code)
+		assert.Equal(t, "code=400, message=Syntax error", ce.Message)
+	}
+}
+
+func TestClient_TokenSourceAndCancel(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer fresh", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL, Token: "stale", RetryWait: time.Hour,
+		TokenSource: func(context.Context) (string, error) { return "fresh", nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.GetTask(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestClient_TaskEventsResumes(t *testing.T) {
+
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			assert.Equal(t, "", r.Header.Get("Last-Event-ID"))
+			assert.Equal(t, "PENDING,COMPLETED", r.URL.Query().Get("state"))
+			fmt.Fprint(w, "retry: 3000\n\n: ping\n\nid: 4\nevent: task.created\ndata: {\"seq\":4,\"type\":\"task.created\",\"taskId\":1}\n\n")
+		default:
+			// resumed after the last event received
+			assert.Equal(t, "4", r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "id: 5\ndata: {\"seq\":5,\"type\":\"task.deleted\",\"taskId\":1}\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	got := []int64{}
+	stop := errors.New("stop")
+	err := newClient(srv.URL).TaskEvents(context.Background(), []string{"PENDING", "COMPLETED"}, 0, func(e events.Event) error {
+		got = append(got, e.Seq)
+		if len(got) == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []int64{4, 5}, got)
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+)
+
+func commentPath(taskId, id int32) string {
+	return fmt.Sprintf("%s/comments/%d", taskPath(taskId), id)
+}
+
+// commentBody - body of the comment requests
+type commentBody struct {
+	Comment model.Comment `json:"comment"`
+}
+
+// ListComments - GET /task/{id}/comments, page and size 0 use the defaults
+// of the API
+func (c *Client) ListComments(ctx context.Context, taskId int32, page, size int) (ListCommentsResponse, error) {
+	out := ListCommentsResponse{}
+	q := url.Values{}
+	if page > 0 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if size > 0 {
+		q.Set("size", strconv.Itoa(size))
+	}
+	err := c.call(ctx, request{method: http.MethodGet, path: taskPath(taskId) + "/comments", query: q}, &out)
+	return out, err
+}
+
+// SaveComment - POST /task/{id}/comments, in the name of the token's user
+func (c *Client) SaveComment(ctx context.Context, taskId int32, body string) (SaveCommentResponse, error) {
+	out := SaveCommentResponse{}
+	err := c.call(ctx, request{method: http.MethodPost, path: taskPath(taskId) + "/comments", body: commentBody{model.Comment{Body: body}}}, &out)
+	return out, err
+}
+
+// UpdateComment - PUT /task/{id}/comments/{commentId}
+func (c *Client) UpdateComment(ctx context.Context, taskId, id int32, body string) error {
+	return c.call(ctx, request{method: http.MethodPut, path: commentPath(taskId, id), body: commentBody{model.Comment{Body: body}}}, nil)
+}
+
+// DeleteComment - DELETE /task/{id}/comments/{commentId}
+func (c *Client) DeleteComment(ctx context.Context, taskId, id int32) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: commentPath(taskId, id)}, nil)
+}
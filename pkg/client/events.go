@@ -0,0 +1,112 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+)
+
+// TaskEvents - follows GET /task/events calling fn with every event, of the
+// given states only when there are any. lastSeq resumes after that event;
+// fn gets an events.StreamResync when the events after it were purged and
+// the tasks must be reloaded. Dropped streams are resumed from the last event received, failing after
+// MaxRetries attempts in a row; API errors such as 401 end it at once. It returns when ctx is done, with ctx.Err(),
+// or with the first error of fn.
+func (c *Client) TaskEvents(ctx context.Context, states []string, lastSeq int64, fn func(events.Event) error) error {
+
+	failures := 0
+	wait := c.cfg.RetryWait
+	for {
+		received, err := c.followEvents(ctx, states, &lastSeq, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if ce, ok := err.(callbackError); ok {
+			return ce.err
+		}
+		if ce, ok := err.(errs.CustomError); ok && !retryable(ce.Code) {
+			return err
+		}
+		if received {
+			failures, wait = 0, c.cfg.RetryWait
+		}
+		if failures++; c.cfg.MaxRetries < 0 || failures > c.cfg.MaxRetries {
+			if err == nil {
+				err = ErrStreamClosed
+			}
+			return err
+		}
+
+		select {
+		case <-time.After(wait):
+			wait *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// callbackError - error returned by the fn of TaskEvents, ends the stream
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string { return e.err.Error() }
+
+// ErrStreamClosed - the server ended the event stream more times in a row
+// than the retries allow
+var ErrStreamClosed = errors.New("event stream closed by the server")
+
+// followEvents - reads one connection of the stream, updating lastSeq
+func (c *Client) followEvents(ctx context.Context, states []string, lastSeq *int64, fn func(events.Event) error) (bool, error) {
+
+	q := url.Values{}
+	if len(states) > 0 {
+		q.Set("state", strings.Join(states, ","))
+	}
+	h := http.Header{}
+	h.Set("Accept", "text/event-stream")
+	if *lastSeq > 0 {
+		h.Set("Last-Event-ID", strconv.FormatInt(*lastSeq, 10))
+	}
+
+	res, err := c.do(ctx, request{method: http.MethodGet, path: tasksPath + "/events", query: q, header: h, once: true})
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	received := false
+	var data strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "" && data.Len() > 0:
+			e := events.Event{}
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return received, err
+			}
+			data.Reset()
+			received = true
+			*lastSeq = e.Seq
+			if err := fn(e); err != nil {
+				return received, callbackError{err}
+			}
+		}
+	}
+
+	return received, scanner.Err()
+}
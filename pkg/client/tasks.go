@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const tasksPath = "/api/v1/task"
+
+func taskPath(id int32) string {
+	return fmt.Sprintf("%s/%d", tasksPath, id)
+}
+
+// FindAllTasks - GET /task/findAll
+func (c *Client) FindAllTasks(ctx context.Context, filter TaskFilter) (FindAllTasksResponse, error) {
+	out := FindAllTasksResponse{}
+	err := c.call(ctx, request{method: http.MethodGet, path: tasksPath + "/findAll", query: values(filter)}, &out)
+	return out, err
+}
+
+// SearchTasks - GET /task/search, limit 0 uses the default of the API
+func (c *Client) SearchTasks(ctx context.Context, q string, limit int) (SearchTasksResponse, error) {
+	out := SearchTasksResponse{}
+	query := url.Values{"q": {q}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	err := c.call(ctx, request{method: http.MethodGet, path: tasksPath + "/search", query: query}, &out)
+	return out, err
+}
+
+// GetTask - GET /task/{id}
+func (c *Client) GetTask(ctx context.Context, id int32) (GetTaskResponse, error) {
+	out := GetTaskResponse{}
+	err := c.call(ctx, request{method: http.MethodGet, path: taskPath(id)}, &out)
+	return out, err
+}
+
+// SaveTask - POST /task
+func (c *Client) SaveTask(ctx context.Context, in SaveTaskRequest) (SaveTaskResponse, error) {
+	out := SaveTaskResponse{}
+	err := c.call(ctx, request{method: http.MethodPost, path: tasksPath, body: in}, &out)
+	return out, err
+}
+
+// UpdateTask - PUT /task
+func (c *Client) UpdateTask(ctx context.Context, in UpdateTaskRequest) (UpdateTaskResponse, error) {
+	out := UpdateTaskResponse{}
+	err := c.call(ctx, request{method: http.MethodPut, path: tasksPath, body: in}, &out)
+	return out, err
+}
+
+// DeleteTask - DELETE /task/{id}
+func (c *Client) DeleteTask(ctx context.Context, id int32) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: taskPath(id)}, nil)
+}
+
+// BulkSaveTasks - POST /task/bulk. Items that failed come in the results
+// and are no error.
+func (c *Client) BulkSaveTasks(ctx context.Context, in BulkSaveTasksRequest) (BulkTasksResponse, error) {
+	out := BulkTasksResponse{}
+	err := c.call(ctx, request{method: http.MethodPost, path: tasksPath + "/bulk", body: in}, &out)
+	return out, err
+}
+
+// BulkUpdateTasks - PUT /task/bulk
+func (c *Client) BulkUpdateTasks(ctx context.Context, in BulkUpdateTasksRequest) (BulkTasksResponse, error) {
+	out := BulkTasksResponse{}
+	err := c.call(ctx, request{method: http.MethodPut, path: tasksPath + "/bulk", body: in}, &out)
+	return out, err
+}
+
+// BulkDeleteTasks - DELETE /task/bulk
+func (c *Client) BulkDeleteTasks(ctx context.Context, in BulkDeleteTasksRequest) (BulkTasksResponse, error) {
+	out := BulkTasksResponse{}
+	err := c.call(ctx, request{method: http.MethodDelete, path: tasksPath + "/bulk", body: in}, &out)
+	return out, err
+}
+
+// ExportTasks - GET /task/export in format csv, json or ndjson, copying the
+// file into w
+func (c *Client) ExportTasks(ctx context.Context, filter TaskFilter, format string, w io.Writer) error {
+
+	q := values(filter)
+	q.Set("format", format)
+
+	res, err := c.do(ctx, request{method: http.MethodGet, path: tasksPath + "/export", query: q})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// ImportTasks - POST /task/import with the file read from r, sent with
+// contentType
+func (c *Client) ImportTasks(ctx context.Context, opts ImportOptions, contentType string, r io.Reader) (ImportTasksResponse, error) {
+	out := ImportTasksResponse{}
+	err := c.call(ctx, request{method: http.MethodPost, path: tasksPath + "/import", query: values(opts), body: r, contentType: contentType}, &out)
+	return out, err
+}
+
+// GetTaskGraph - GET /task/{id}/graph
+func (c *Client) GetTaskGraph(ctx context.Context, id int32) (GetTaskGraphResponse, error) {
+	out := GetTaskGraphResponse{}
+	err := c.call(ctx, request{method: http.MethodGet, path: taskPath(id) + "/graph"}, &out)
+	return out, err
+}
+
+// SetParent - PUT /task/{id}/parent, parentId 0 detaches the task
+func (c *Client) SetParent(ctx context.Context, id, parentId int32) error {
+	body := map[string]int32{"parent_id": parentId}
+	return c.call(ctx, request{method: http.MethodPut, path: taskPath(id) + "/parent", body: body}, nil)
+}
+
+// AddBlocker - POST /task/{id}/blockers
+func (c *Client) AddBlocker(ctx context.Context, id, blockerId int32) error {
+	body := map[string]int32{"blocker_id": blockerId}
+	return c.call(ctx, request{method: http.MethodPost, path: taskPath(id) + "/blockers", body: body}, nil)
+}
+
+// RemoveBlocker - DELETE /task/{id}/blockers/{blockerId}
+func (c *Client) RemoveBlocker(ctx context.Context, id, blockerId int32) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: fmt.Sprintf("%s/blockers/%d", taskPath(id), blockerId)}, nil)
+}
+
+// ListOccurrences - GET /task/{id}/occurrences, limit 0 uses the default of
+// the API
+func (c *Client) ListOccurrences(ctx context.Context, id int32, limit int) (ListOccurrencesResponse, error) {
+	out := ListOccurrencesResponse{}
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	err := c.call(ctx, request{method: http.MethodGet, path: taskPath(id) + "/occurrences", query: q}, &out)
+	return out, err
+}
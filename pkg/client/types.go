@@ -0,0 +1,166 @@
+package client
+
+import (
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+)
+
+// The request and response bodies of the API. They mirror those of the
+// services, which are not imported so that the client does not depend on
+// the database packages.
+
+// TaskFilter - filters and page of FindAllTasks and ExportTasks
+type TaskFilter struct {
+	State    string `query:"state"`
+	Priority string `query:"priority"`
+	Label    string `query:"label"`
+	DueFrom  string `query:"dueFrom"`
+	DueTo    string `query:"dueTo"`
+	// Sort - id, priority, -priority, due_date or -due_date
+	Sort string `query:"sort"`
+	// Limit - page size, every task when 0; ignored by ExportTasks
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// FindAllTasksResponse -
+type FindAllTasksResponse struct {
+	Tasks []model.Task `json:"tasks"`
+	// Total - tasks matching the filters, only when paginated
+	Total int64 `json:"total,omitempty"`
+}
+
+// SearchResult -
+type SearchResult struct {
+	Task  model.Task `json:"task"`
+	Score float64    `json:"score"`
+}
+
+// SearchTasksResponse -
+type SearchTasksResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// GetTaskResponse -
+type GetTaskResponse struct {
+	Task model.Task `json:"task"`
+}
+
+// SaveTaskRequest -
+type SaveTaskRequest struct {
+	Task model.Task `json:"task"`
+}
+
+// SaveTaskResponse -
+type SaveTaskResponse struct {
+	Id int32 `json:"id"`
+}
+
+// UpdateTaskRequest -
+type UpdateTaskRequest struct {
+	Task model.Task `json:"task"`
+}
+
+// UpdateTaskResponse -
+type UpdateTaskResponse struct {
+	// NextOccurrenceId - task created when a recurring task is completed
+	NextOccurrenceId int32 `json:"nextOccurrenceId,omitempty"`
+}
+
+// BulkSaveTasksRequest -
+type BulkSaveTasksRequest struct {
+	Tasks        []model.Task `json:"tasks"`
+	AllOrNothing bool         `json:"allOrNothing"`
+}
+
+// BulkUpdateTasksRequest -
+type BulkUpdateTasksRequest struct {
+	Ids          []int32 `json:"ids"`
+	State        string  `json:"state"`
+	AllOrNothing bool    `json:"allOrNothing"`
+}
+
+// BulkDeleteTasksRequest -
+type BulkDeleteTasksRequest struct {
+	Ids          []int32 `json:"ids"`
+	AllOrNothing bool    `json:"allOrNothing"`
+}
+
+// BulkItemResult -
+type BulkItemResult struct {
+	Index            int               `json:"index"`
+	Id               int32             `json:"id,omitempty"`
+	Success          bool              `json:"success"`
+	Error            *errs.CustomError `json:"error,omitempty"`
+	NextOccurrenceId int32             `json:"nextOccurrenceId,omitempty"`
+}
+
+// BulkTasksResponse -
+type BulkTasksResponse struct {
+	Results   []BulkItemResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// ImportOptions - query of ImportTasks
+type ImportOptions struct {
+	// Format - csv, json or ndjson, taken from the content type when empty
+	Format       string `query:"format"`
+	DryRun       bool   `query:"dryRun"`
+	AllOrNothing bool   `query:"allOrNothing"`
+}
+
+// ImportRowResult -
+type ImportRowResult struct {
+	Row     int               `json:"row"`
+	Id      int32             `json:"id,omitempty"`
+	Success bool              `json:"success"`
+	Error   *errs.CustomError `json:"error,omitempty"`
+}
+
+// ImportTasksResponse -
+type ImportTasksResponse struct {
+	DryRun   bool              `json:"dryRun"`
+	Total    int               `json:"total"`
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// GetTaskGraphResponse -
+type GetTaskGraphResponse struct {
+	Task     model.Task   `json:"task"`
+	Parent   *model.Task  `json:"parent,omitempty"`
+	Subtasks []model.Task `json:"subtasks"`
+	Blockers []model.Task `json:"blockers"`
+	Blocking []model.Task `json:"blocking"`
+}
+
+// ListOccurrencesResponse -
+type ListOccurrencesResponse struct {
+	Recurrence  string   `json:"recurrence"`
+	Occurrences []string `json:"occurrences"`
+}
+
+// ListCommentsResponse -
+type ListCommentsResponse struct {
+	Comments []model.Comment `json:"comments"`
+	Page     int             `json:"page"`
+	Size     int             `json:"size"`
+	Total    int64           `json:"total"`
+}
+
+// SaveCommentResponse -
+type SaveCommentResponse struct {
+	Id int32 `json:"id"`
+}
+
+// ListAttachmentsResponse -
+type ListAttachmentsResponse struct {
+	Attachments []model.Attachment `json:"attachments"`
+}
+
+// SaveAttachmentResponse -
+type SaveAttachmentResponse struct {
+	Attachment model.Attachment `json:"attachment"`
+}
@@ -0,0 +1,301 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// by recurring tasks:
+//
+//	FREQ=DAILY|WEEKLY|MONTHLY|YEARLY (required)
+//	INTERVAL=n
+//	COUNT=n or UNTIL=YYYYMMDD[THHMMSS[Z]]
+//	BYDAY=MO,TU,... (WEEKLY only, no ordinals)
+//	BYMONTHDAY=1,15,-1 (MONTHLY only)
+//
+// Weeks start on Monday. The start of a series (DTSTART) is the due date of
+// the task carrying the rule, and it is always its first occurrence.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies supported in FREQ.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxPeriods bounds the search for the next occurrence, so rules that can
+// never match again (e.g. BYMONTHDAY=31 every 12 months from April) end.
+const maxPeriods = 1000
+
+// ErrInvalidRule - the rule is not valid or uses an unsupported part
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule - parsed recurrence rule
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+// Parse - parses an RRULE value, with or without the "RRULE:" prefix
+func Parse(s string) (Rule, error) {
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	r := Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		if seen[key] {
+			return Rule{}, fmt.Errorf("%w: duplicated %s", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = value
+			default:
+				err = fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = positive(value)
+		case "COUNT":
+			r.Count, err = positive(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[d]
+				if !ok {
+					err = fmt.Errorf("unsupported BYDAY %s", d)
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, convErr := strconv.Atoi(d)
+				if convErr != nil || n == 0 || n < -31 || n > 31 {
+					err = fmt.Errorf("invalid BYMONTHDAY %s", d)
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("unsupported WKST %s", value)
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	case r.Count > 0 && !r.Until.IsZero():
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are exclusive", ErrInvalidRule)
+	case len(r.ByDay) > 0 && r.Freq != Weekly:
+		return Rule{}, fmt.Errorf("%w: BYDAY requires FREQ=WEEKLY", ErrInvalidRule)
+	case len(r.ByMonthDay) > 0 && r.Freq != Monthly:
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY requires FREQ=MONTHLY", ErrInvalidRule)
+	}
+
+	return r, nil
+}
+
+// String - formats the rule back to its RRULE value, without prefix
+func (r Rule) String() string {
+
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := []string{}
+		for _, wd := range r.ByDay {
+			for name, d := range weekdays {
+				if d == wd {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := []string{}
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next - gets the first occurrence after the series start dtstart, false when
+// the series has no more occurrences
+func (r Rule) Next(dtstart time.Time) (time.Time, bool) {
+	next := r.Occurrences(dtstart, 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// Occurrences - gets up to n occurrences that follow dtstart. COUNT includes
+// dtstart itself, so at most COUNT-1 occurrences are returned.
+func (r Rule) Occurrences(dtstart time.Time, n int) []time.Time {
+
+	if r.Count > 0 && n > r.Count-1 {
+		n = r.Count - 1
+	}
+
+	occurrences := []time.Time{}
+	for period := 0; period < maxPeriods && len(occurrences) < n; period++ {
+		for _, t := range r.candidates(dtstart, period) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return occurrences
+			}
+			occurrences = append(occurrences, t)
+			if len(occurrences) == n {
+				return occurrences
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// Remaining - the rule for the series that starts at the next occurrence,
+// with COUNT reduced by the occurrence that was just completed
+func (r Rule) Remaining() Rule {
+	if r.Count > 0 {
+		r.Count--
+	}
+	return r
+}
+
+// candidates - occurrences in the period-th period of the series, sorted
+func (r Rule) candidates(dtstart time.Time, period int) []time.Time {
+
+	step := period * r.Interval
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, dtstart.Nanosecond(), loc)
+	}
+
+	out := []time.Time{}
+
+	switch r.Freq {
+	case Daily:
+		out = append(out, at(y, m, d+step))
+
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			out = append(out, at(y, m, d+7*step))
+			break
+		}
+		// monday of the dtstart week, then the period week
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		for _, wd := range r.ByDay {
+			out = append(out, at(y, m, monday+(int(wd)+6)%7))
+		}
+
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{d}
+		}
+		last := daysIn(first.Year(), first.Month())
+		// 31 and -1 may resolve to the same day
+		seen := map[int]bool{}
+		for _, md := range days {
+			if md < 0 {
+				md = last + md + 1
+			}
+			if md >= 1 && md <= last && !seen[md] {
+				seen[md] = true
+				out = append(out, at(first.Year(), first.Month(), md))
+			}
+		}
+
+	case Yearly:
+		if d <= daysIn(y+step, m) {
+			out = append(out, at(y+step, m, d))
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+
+	return out
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func positive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s is not a positive number", s)
+	}
+	return n, nil
+}
+
+func parseUntil(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			if layout == "20060102" {
+				// a date-only UNTIL includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %s", s)
+}
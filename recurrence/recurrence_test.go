@@ -0,0 +1,99 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02T15:04:05", s)
+	return t
+}
+
+func TestParse_Invalid(t *testing.T) {
+
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20231001",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;FREQ=WEEKLY",
+	} {
+		_, err := Parse(s)
+		assert.True(t, errors.Is(err, ErrInvalidRule), s)
+	}
+}
+
+func TestParse_String(t *testing.T) {
+
+	r, err := Parse("RRULE:freq=weekly;interval=2;byday=MO,FR;count=5")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;COUNT=5;BYDAY=MO,FR", r.String())
+}
+
+func TestOccurrences(t *testing.T) {
+
+	cases := []struct {
+		rule     string
+		dtstart  string
+		n        int
+		expected []string
+	}{
+		{"FREQ=DAILY;INTERVAL=3", "2023-10-01T09:00:00", 2,
+			[]string{"2023-10-04T09:00:00", "2023-10-07T09:00:00"}},
+		// 2023-10-04 is a Wednesday
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR", "2023-10-04T09:00:00", 3,
+			[]string{"2023-10-06T09:00:00", "2023-10-09T09:00:00", "2023-10-11T09:00:00"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "2023-10-04T09:00:00", 2,
+			[]string{"2023-10-17T09:00:00", "2023-10-31T09:00:00"}},
+		// months without day 31 are skipped
+		{"FREQ=MONTHLY", "2023-08-31T09:00:00", 2,
+			[]string{"2023-10-31T09:00:00", "2023-12-31T09:00:00"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", "2023-01-15T09:00:00", 3,
+			[]string{"2023-01-31T09:00:00", "2023-02-01T09:00:00", "2023-02-28T09:00:00"}},
+		// 31 and -1 are the same day in months with 31 days
+		{"FREQ=MONTHLY;BYMONTHDAY=31,-1", "2023-01-15T09:00:00", 3,
+			[]string{"2023-01-31T09:00:00", "2023-02-28T09:00:00", "2023-03-31T09:00:00"}},
+		{"FREQ=YEARLY", "2024-02-29T09:00:00", 1,
+			[]string{"2028-02-29T09:00:00"}},
+		{"FREQ=DAILY;COUNT=3", "2023-10-01T09:00:00", 10,
+			[]string{"2023-10-02T09:00:00", "2023-10-03T09:00:00"}},
+		{"FREQ=DAILY;UNTIL=20231002", "2023-10-01T09:00:00", 10,
+			[]string{"2023-10-02T09:00:00"}},
+	}
+
+	for _, c := range cases {
+		r, err := Parse(c.rule)
+		assert.NoError(t, err, c.rule)
+
+		got := []string{}
+		for _, o := range r.Occurrences(date(c.dtstart), c.n) {
+			got = append(got, o.Format("2006-01-02T15:04:05"))
+		}
+		assert.Equal(t, c.expected, got, c.rule)
+	}
+}
+
+func TestNext_Remaining(t *testing.T) {
+
+	r, _ := Parse("FREQ=WEEKLY;COUNT=2")
+
+	next, ok := r.Next(date("2023-10-01T09:00:00"))
+	assert.True(t, ok)
+	assert.Equal(t, date("2023-10-08T09:00:00"), next)
+
+	r = r.Remaining()
+	assert.Equal(t, 1, r.Count)
+
+	_, ok = r.Next(next)
+	assert.False(t, ok)
+}
@@ -0,0 +1,59 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// LogNotifier - writes every event to the application log
+type LogNotifier struct{}
+
+// NewLogNotifier - gets a LogNotifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify -
+func (n *LogNotifier) Notify(ctx context.Context, e Event) error {
+	loggerf.WithField("kind", e.Kind).
+		WithField("taskId", e.TaskId).
+		WithField("title", e.Title).
+		WithField("dueDate", e.DueDate).
+		Info("task reminder")
+	return nil
+}
+
+// FileNotifier - appends every event as a JSON line to a file
+type FileNotifier struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileNotifier - opens path for appending, creating it if needed
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileNotifier{file: f}, nil
+}
+
+// Notify -
+func (n *FileNotifier) Notify(ctx context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = n.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close - closes the file
+func (n *FileNotifier) Close() error {
+	return n.file.Close()
+}
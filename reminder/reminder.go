@@ -0,0 +1,180 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "reminder")
+
+// Kinds of reminder events.
+const (
+	KindOverdue = "overdue"
+	KindDueSoon = "due_soon"
+)
+
+// Notifiers supported by ConfigFromEnv.
+const (
+	NotifierNone = "none"
+	NotifierLog  = "log"
+	NotifierFile = "file"
+)
+
+// Event - a task that became overdue or is due within the window
+type Event struct {
+	Kind    string    `json:"kind"`
+	TaskId  int32     `json:"taskId"`
+	Title   string    `json:"title"`
+	DueDate time.Time `json:"dueDate"`
+	At      time.Time `json:"at"`
+}
+
+// Notifier - receives the reminder events. Each event is emitted once per
+// task and due date; a failing Notify is logged and not retried.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Config - scheduler configuration
+type Config struct {
+	// Notifier is one of none, log or file; none disables the scheduler.
+	Notifier string
+	// File is the path appended to by the file notifier.
+	File string
+	// Interval is the time between two scans.
+	Interval time.Duration
+	// Window is how far ahead a task counts as due soon.
+	Window time.Duration
+}
+
+// ConfigFromEnv - builds a Config from REMINDER_* variables
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Notifier: os.Getenv("REMINDER_NOTIFIER"),
+		File:     os.Getenv("REMINDER_FILE"),
+		Interval: time.Minute,
+		Window:   24 * time.Hour,
+	}
+
+	if cfg.Notifier == "" {
+		cfg.Notifier = NotifierNone
+	}
+	if cfg.File == "" {
+		cfg.File = "reminders.json"
+	}
+	if v, err := time.ParseDuration(os.Getenv("REMINDER_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("REMINDER_WINDOW")); err == nil && v >= 0 {
+		cfg.Window = v
+	}
+
+	return cfg
+}
+
+// NewNotifier - builds the notifier of cfg, nil for none
+func NewNotifier(cfg Config) (Notifier, error) {
+	switch cfg.Notifier {
+	case NotifierNone, "":
+		return nil, nil
+	case NotifierLog:
+		return NewLogNotifier(), nil
+	case NotifierFile:
+		n, err := NewFileNotifier(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", cfg.Notifier)
+	}
+}
+
+// Scheduler - periodically flags overdue tasks and reminds due-soon tasks
+type Scheduler struct {
+	Interval time.Duration
+	Window   time.Duration
+	Notifier Notifier
+	// Now is the clock, time.Now when nil.
+	Now func() time.Time
+
+	taskDAO dao.TaskDAO
+}
+
+// NewScheduler - gets a Scheduler that reports to notifier
+func NewScheduler(cfg Config, notifier Notifier) *Scheduler {
+	return &Scheduler{
+		Interval: cfg.Interval,
+		Window:   cfg.Window,
+		Notifier: notifier,
+		taskDAO:  dao.NewTaskDAO(),
+	}
+}
+
+// Run - scans once immediately and then every Interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+
+	log := loggerf.WithField("func", "Run")
+	log.WithField("interval", s.Interval.String()).WithField("window", s.Window.String()).Info("reminder scheduler started")
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.WithError(err).Error("reminder scan fails")
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick - runs one scan: overdue tasks first, then tasks due within Window
+func (s *Scheduler) Tick(ctx context.Context) error {
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	now = now.UTC()
+
+	overdue, err := s.taskDAO.MarkOverdue(ctx, now)
+	if err != nil {
+		return err
+	}
+	s.notify(ctx, KindOverdue, now, overdue)
+
+	dueSoon, err := s.taskDAO.MarkDueSoon(ctx, now, now.Add(s.Window))
+	if err != nil {
+		return err
+	}
+	s.notify(ctx, KindDueSoon, now, dueSoon)
+
+	return nil
+}
+
+func (s *Scheduler) notify(ctx context.Context, kind string, now time.Time, tasks []model.Task) {
+
+	log := loggerf.WithField("func", "notify")
+
+	for _, t := range tasks {
+		e := Event{Kind: kind, TaskId: t.Id, Title: t.Title, DueDate: t.DueDate.UTC(), At: now}
+		if err := s.Notifier.Notify(ctx, e); err != nil {
+			log.WithError(err).WithField("taskId", t.Id).WithField("kind", kind).Error("notify fails")
+			metrics.RemindersSent.WithLabelValues(kind, "error").Inc()
+			continue
+		}
+		metrics.RemindersSent.WithLabelValues(kind, "ok").Inc()
+	}
+}
@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Notify(ctx context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestScheduler_Tick(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	now := time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC)
+	taskDAO := dao.NewTaskDAO()
+
+	save := func(title string, due time.Time, state string) int32 {
+		task := model.Task{Title: title, Description: title, DueDate: due, State: state, Priority: "MEDIUM", CenterCode: "C1"}
+		assert.NoError(t, taskDAO.Save(ctx, &task))
+		return task.Id
+	}
+
+	late := save("Atrasada", now.Add(-time.Hour), "PENDING")
+	soon := save("Pronto", now.Add(2*time.Hour), "IN_PROGRESS")
+	save("Lejana", now.Add(72*time.Hour), "PENDING")
+	save("Completada", now.Add(-time.Hour), "COMPLETED")
+
+	rec := &recorder{}
+	s := NewScheduler(Config{Interval: time.Minute, Window: 24 * time.Hour}, rec)
+	s.Now = func() time.Time { return now }
+
+	assert.NoError(t, s.Tick(ctx))
+	assert.Len(t, rec.events, 2)
+	assert.Equal(t, Event{Kind: KindOverdue, TaskId: late, Title: "Atrasada", DueDate: now.Add(-time.Hour), At: now}, rec.events[0])
+	assert.Equal(t, KindDueSoon, rec.events[1].Kind)
+	assert.Equal(t, soon, rec.events[1].TaskId)
+
+	// each task is reported once
+	assert.NoError(t, s.Tick(ctx))
+	assert.Len(t, rec.events, 2)
+
+	// the due-soon task becomes overdue later
+	s.Now = func() time.Time { return now.Add(3 * time.Hour) }
+	assert.NoError(t, s.Tick(ctx))
+	assert.Len(t, rec.events, 3)
+	assert.Equal(t, KindOverdue, rec.events[2].Kind)
+	assert.Equal(t, soon, rec.events[2].TaskId)
+
+	// moving the due date re-enables the reminders
+	assert.NoError(t, taskDAO.ResetReminders(ctx, late))
+	assert.NoError(t, s.Tick(ctx))
+	assert.Len(t, rec.events, 4)
+	assert.Equal(t, late, rec.events[3].TaskId)
+}
+
+func TestFileNotifier(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "reminders.json")
+
+	n, err := NewFileNotifier(path)
+	assert.NoError(t, err)
+
+	due := time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, n.Notify(context.TODO(), Event{Kind: KindOverdue, TaskId: 1, Title: "Uno", DueDate: due, At: due}))
+	assert.NoError(t, n.Notify(context.TODO(), Event{Kind: KindDueSoon, TaskId: 2, Title: "Dos", DueDate: due, At: due}))
+	assert.NoError(t, n.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+
+	e := Event{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, int32(2), e.TaskId)
+	assert.Equal(t, KindDueSoon, e.Kind)
+}
+
+func TestNewNotifier(t *testing.T) {
+
+	n, err := NewNotifier(Config{Notifier: NotifierNone})
+	assert.NoError(t, err)
+	assert.Nil(t, n)
+
+	n, err = NewNotifier(Config{Notifier: NotifierLog})
+	assert.NoError(t, err)
+	assert.IsType(t, &LogNotifier{}, n)
+
+	_, err = NewNotifier(Config{Notifier: "smtp"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"golang.org/x/text/unicode/norm"
+)
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// titleWeight counts every title term this many times, so title matches rank
+// above description matches.
+const titleWeight = 2
+
+// MemoryIndex - embedded inverted index ranked with BM25, used when the
+// database has no full-text support (SQLite, in-memory)
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[int32]int
+	lengths  map[int32]int
+	terms    map[int32][]string
+	centers  map[int32]string
+	totalLen int
+}
+
+// NewMemoryIndex - gets an empty MemoryIndex
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		postings: map[string]map[int32]int{},
+		lengths:  map[int32]int{},
+		terms:    map[int32][]string{},
+		centers:  map[int32]string{},
+	}
+}
+
+// Name -
+func (idx *MemoryIndex) Name() string {
+	return BackendMemory
+}
+
+// Index - adds or replaces doc
+func (idx *MemoryIndex) Index(ctx context.Context, doc Document) error {
+
+	tokens := []string{}
+	for i := 0; i < titleWeight; i++ {
+		tokens = append(tokens, tokenize(doc.Title)...)
+	}
+	tokens = append(tokens, tokenize(doc.Description)...)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.Id)
+
+	freqs := map[string]int{}
+	for _, t := range tokens {
+		freqs[t]++
+	}
+
+	terms := make([]string, 0, len(freqs))
+	for t, f := range freqs {
+		if idx.postings[t] == nil {
+			idx.postings[t] = map[int32]int{}
+		}
+		idx.postings[t][doc.Id] = f
+		terms = append(terms, t)
+	}
+
+	idx.terms[doc.Id] = terms
+	idx.centers[doc.Id] = doc.CenterCode
+	idx.lengths[doc.Id] = len(tokens)
+	idx.totalLen += len(tokens)
+
+	return nil
+}
+
+// Remove - removes the document with id, if present
+func (idx *MemoryIndex) Remove(ctx context.Context, id int32) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+	return nil
+}
+
+func (idx *MemoryIndex) remove(id int32) {
+	terms, ok := idx.terms[id]
+	if !ok {
+		return
+	}
+	for _, t := range terms {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	idx.totalLen -= idx.lengths[id]
+	delete(idx.terms, id)
+	delete(idx.centers, id)
+	delete(idx.lengths, id)
+}
+
+// Search - ranks the documents of the tenant of ctx containing any query
+// term with BM25; the limit applies after the tenant filter
+func (idx *MemoryIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+
+	tenant, scoped := base.Tenant(ctx)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	n := float64(len(idx.lengths))
+	if n == 0 {
+		return []Hit{}, nil
+	}
+	avgLen := float64(idx.totalLen) / n
+
+	scores := map[int32]float64{}
+	seen := map[string]bool{}
+	for _, t := range tokenize(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		docs := idx.postings[t]
+		df := float64(len(docs))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, tf := range docs {
+			if scoped && idx.centers[id] != tenant {
+				continue
+			}
+			f := float64(tf)
+			norm := f + bm25K1*(1-bm25B+bm25B*float64(idx.lengths[id])/avgLen)
+			scores[id] += idf * f * (bm25K1 + 1) / norm
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{Id: id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+// tokenize lowercases s, strips accents and splits it on anything that is not
+// a letter or a digit, so "Revisión" and "revision" match.
+func tokenize(s string) []string {
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.FieldsFunc(b.String(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
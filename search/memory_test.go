@@ -0,0 +1,91 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryIndex_Ranking(t *testing.T) {
+
+	idx := NewMemoryIndex()
+	ctx := context.TODO()
+
+	idx.Index(ctx, Document{Id: 1, Title: "Revisión de contratos", Description: "Revisar los contratos del proveedor"})
+	idx.Index(ctx, Document{Id: 2, Title: "Preparar informe", Description: "Informe mensual, incluye revision de gastos"})
+	idx.Index(ctx, Document{Id: 3, Title: "Comprar café", Description: "Para la oficina"})
+
+	hits, err := idx.Search(ctx, "revision", 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, int32(1), hits[0].Id)
+	assert.Equal(t, int32(2), hits[1].Id)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+}
+
+func TestMemoryIndex_ReindexAndRemove(t *testing.T) {
+
+	idx := NewMemoryIndex()
+	ctx := context.TODO()
+
+	idx.Index(ctx, Document{Id: 1, Title: "Comprar café"})
+	idx.Index(ctx, Document{Id: 1, Title: "Comprar té"})
+
+	hits, _ := idx.Search(ctx, "café", 10)
+	assert.Empty(t, hits)
+
+	hits, _ = idx.Search(ctx, "TÉ", 10)
+	assert.Len(t, hits, 1)
+
+	idx.Remove(ctx, 1)
+
+	hits, _ = idx.Search(ctx, "comprar", 10)
+	assert.Empty(t, hits)
+	assert.Empty(t, idx.postings)
+	assert.Zero(t, idx.totalLen)
+}
+
+func TestMemoryIndex_Limit(t *testing.T) {
+
+	idx := NewMemoryIndex()
+	ctx := context.TODO()
+
+	for i := int32(1); i <= 5; i++ {
+		idx.Index(ctx, Document{Id: i, Title: "tarea"})
+	}
+
+	hits, _ := idx.Search(ctx, "tarea", 3)
+	assert.Len(t, hits, 3)
+	assert.Equal(t, int32(1), hits[0].Id)
+}
+
+func TestMemoryIndex_Tenant(t *testing.T) {
+
+	idx := NewMemoryIndex()
+	ctx := context.TODO()
+
+	// the best hits are from another center, the page is still full
+	for i := int32(1); i <= 5; i++ {
+		idx.Index(ctx, Document{Id: i, Title: "tarea tarea", CenterCode: "C1"})
+	}
+	for i := int32(6); i <= 8; i++ {
+		idx.Index(ctx, Document{Id: i, Title: "tarea", Description: "otra cosa", CenterCode: "C2"})
+	}
+
+	hits, _ := idx.Search(base.WithTenant(ctx, "C2"), "tarea", 2)
+	if assert.Len(t, hits, 2) {
+		assert.Equal(t, int32(6), hits[0].Id)
+		assert.Equal(t, int32(7), hits[1].Id)
+	}
+
+	hits, _ = idx.Search(ctx, "tarea", 10)
+	assert.Len(t, hits, 8)
+}
+
+func TestTokenize(t *testing.T) {
+
+	assert.Equal(t, []string{"revision", "n2", "ano"}, tokenize("Revisión, N2: Año"))
+}
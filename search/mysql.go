@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+)
+
+// MySQLIndex - searches with the FULLTEXT index of the tasks table, which
+// MySQL keeps in sync by itself
+type MySQLIndex struct{}
+
+// NewMySQLIndex - gets a MySQLIndex instance
+func NewMySQLIndex() *MySQLIndex {
+	return &MySQLIndex{}
+}
+
+// Name -
+func (idx *MySQLIndex) Name() string {
+	return BackendMySQL
+}
+
+// Index - no-op, the FULLTEXT index is updated by MySQL
+func (idx *MySQLIndex) Index(ctx context.Context, doc Document) error {
+	return nil
+}
+
+// Remove - no-op, the FULLTEXT index is updated by MySQL
+func (idx *MySQLIndex) Remove(ctx context.Context, id int32) error {
+	return nil
+}
+
+// Search -
+func (idx *MySQLIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+
+	scores, err := dao.NewTaskDAO().FullTextSearch(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for _, s := range scores {
+		hits = append(hits, Hit{Id: s.Id, Score: s.Score})
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/log"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "search")
+
+// Backends selected with SEARCH_BACKEND. By default MySQL databases use their
+// FULLTEXT index and any other database the in-memory index.
+const (
+	BackendMySQL  = "mysql"
+	BackendMemory = "memory"
+)
+
+// Document - searchable text of a task and the center it belongs to
+type Document struct {
+	Id          int32
+	Title       string
+	Description string
+	CenterCode  string
+}
+
+// Hit - a matching task and its relevance, higher is better
+type Hit struct {
+	Id    int32
+	Score float64
+}
+
+// Index - full-text index over task titles and descriptions. Index and Remove
+// keep it in sync with the tasks table; backends that are maintained by the
+// database itself implement them as no-ops. Search only returns the tasks of
+// the tenant of ctx, see base.Tenant.
+type Index interface {
+	Name() string
+	Index(ctx context.Context, doc Document) error
+	Remove(ctx context.Context, id int32) error
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+}
+
+var (
+	defaultIndex Index
+	defaultOnce  sync.Once
+)
+
+// Default - gets the index for the configured database, building it on first use
+func Default() Index {
+	defaultOnce.Do(func() {
+		defaultIndex = newDefault(context.Background())
+	})
+	return defaultIndex
+}
+
+func newDefault(ctx context.Context) Index {
+
+	log := loggerf.WithField("func", "newDefault")
+
+	backend := os.Getenv("SEARCH_BACKEND")
+	if backend == "" {
+		backend = BackendMemory
+		if db := base.GetDB(); db != nil && db.Dialector.Name() == "mysql" {
+			backend = BackendMySQL
+		}
+	}
+
+	if backend == BackendMySQL {
+		return NewMySQLIndex()
+	}
+
+	idx := NewMemoryIndex()
+
+	tasks, err := dao.NewTaskDAO().FindAll(ctx)
+	if err != nil {
+		log.WithError(err).Error("problems with loading tasks into the search index")
+		return idx
+	}
+
+	for _, t := range tasks {
+		idx.Index(ctx, Document{Id: t.Id, Title: t.Title, Description: t.Description, CenterCode: t.CenterCode})
+	}
+
+	log.WithField("documents", len(tasks)).Info("search index built")
+
+	return idx
+}
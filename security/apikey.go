@@ -0,0 +1,22 @@
+package security
+
+import "github.com/Alonso-Arias/test-cleverit/services/model"
+
+// ApiKeyScopes - role of the access policy given by each API key scope
+var ApiKeyScopes = map[string]string{
+	"tasks:read":  "ROL_READ",
+	"tasks:admin": "ROL_1",
+}
+
+// ScopeRoles - roles given by scopes, false when some scope is unknown
+func ScopeRoles(scopes []string) ([]model.Role, bool) {
+	roles := []model.Role{}
+	for _, s := range scopes {
+		code, ok := ApiKeyScopes[s]
+		if !ok {
+			return nil, false
+		}
+		roles = append(roles, model.Role{Code: code})
+	}
+	return roles, true
+}
@@ -1,37 +1,70 @@
 package security
 
 import (
+	"fmt"
 	"os"
+	"sync"
 
 	"github.com/Alonso-Arias/test-cleverit/services/model"
 	"github.com/casbin/casbin/v2"
 )
 
-var e *casbin.Enforcer
+// Actions of the task rules
+const (
+	ActUpdate = "update"
+	ActDelete = "delete"
+)
 
-func init() {
+// Subject - attributes of the user seen by the task rules as r2.sub
+type Subject struct {
+	Email string
+	Roles []string
+}
 
-	BASE_PATH := os.Getenv("BASE_PATH")
+// TaskObject - attributes of the task seen by the task rules as r2.obj
+type TaskObject struct {
+	// Owner - email of the user who created the task
+	Owner string
+	State string
+}
 
-	var err error
+var (
+	e            *casbin.Enforcer
+	enforcerOnce sync.Once
+)
 
-	e, err = casbin.NewEnforcer(BASE_PATH+"/security/casbin_model.conf", BASE_PATH+"/security/casbin_policy.csv")
+// Enforcer - gets the access policy enforcer, loading the model and policy
+// under BASE_PATH on first use. The API calls it at startup so a broken
+// policy stops the process before serving.
+func Enforcer() *casbin.Enforcer {
 
-	if err != nil {
+	enforcerOnce.Do(func() {
 
-		loggerf.WithError(err).Fatal("Failed to load access policy")
+		BASE_PATH := os.Getenv("BASE_PATH")
 
-	}
+		var err error
+
+		e, err = LoadEnforcer(BASE_PATH+"/security/casbin_model.conf", BASE_PATH+"/security/casbin_policy.csv")
+
+		if err != nil {
+
+			loggerf.WithError(err).Fatal("Failed to load access policy")
+
+		}
+	})
+
+	return e
 }
 
-// IsAuthorized -
+// IsAuthorized - some role of au is allowed method on path by the policies
+// of every center or of the center of au
 func IsAuthorized(au model.AuthenticatedUser, method string, path string) bool {
 
 	//log := loggerf.WithField("func", "IsAuthorized")
 
 	ok := false
 	for _, r := range au.Roles {
-		ok, _ = e.Enforce(r.Code, path, method)
+		ok, _ = Enforcer().Enforce(r.Code, au.CenterCode, path, method)
 		if ok {
 			break
 		}
@@ -40,3 +73,40 @@ func IsAuthorized(au model.AuthenticatedUser, method string, path string) bool {
 	return ok
 
 }
+
+// IsAuthorizedTask - the task rules (p2) allow au to do act on the task
+func IsAuthorizedTask(au model.AuthenticatedUser, act string, obj TaskObject) bool {
+
+	log := loggerf.WithField("func", "IsAuthorizedTask")
+
+	sub := Subject{Email: au.Email}
+	for _, r := range au.Roles {
+		sub.Roles = append(sub.Roles, r.Code)
+	}
+
+	ok, err := Enforcer().Enforce(casbin.NewEnforceContext("2"), sub, obj, act)
+	if err != nil {
+		log.WithError(err).Error("task rules evaluation fails")
+		return false
+	}
+
+	return ok
+}
+
+// addFunctions - adds the hasRole(r2.sub.Roles, 'ROL_1') function of the
+// task rules to e
+func addFunctions(e *casbin.Enforcer) {
+	e.AddFunction("hasRole", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return false, fmt.Errorf("hasRole expects 2 arguments, got %d", len(args))
+		}
+		roles, _ := args[0].([]string)
+		role, _ := args[1].(string)
+		for _, r := range roles {
+			if r == role {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
@@ -1,17 +1,114 @@
 package security
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/casbin/casbin/v2"
+	casbinmodel "github.com/casbin/casbin/v2/model"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	testenv.BasePath()
+	os.Exit(m.Run())
+}
+
 func Test_IsAuthorized(t *testing.T) {
 
 	au := model.AuthenticatedUser{
 		Roles: []model.Role{{Code: "ROL_1"}},
 	}
 
-	assert.True(t, IsAuthorized(au, "GET", "/api/v1/user/sksksks"))
+	assert.True(t, IsAuthorized(au, "GET", "/api/v1/task/sksksks"))
+	// no rule covers the user routes
+	assert.False(t, IsAuthorized(au, "GET", "/api/v1/user/sksksks"))
+}
+
+func Test_IsAuthorized_MatcherKeepsDecisions(t *testing.T) {
+
+	// the matcher before the subresource rules, with keyMatch
+	m, err := casbinmodel.NewModelFromString(`
+[request_definition]
+r = sub, dom, obj, act
+[policy_definition]
+p = sub, dom, obj, act
+[policy_effect]
+e = some(where (p.eft == allow))
+[matchers]
+m = r.sub == p.sub && (p.dom == "*" || r.dom == p.dom) && keyMatch(r.obj, p.obj) && regexMatch(r.act, p.act)
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	old, err := casbin.NewEnforcer(m)
+	assert.NoError(t, err)
+	current, err := casbin.NewEnforcer(os.Getenv("BASE_PATH") + "/security/casbin_model.conf")
+	assert.NoError(t, err)
+
+	// the rules with a wildcard only at the end, the only kind keyMatch
+	// supported, are decided the same way by keyMatch2
+	for _, p := range Enforcer().GetPolicy() {
+		path := p[2]
+		if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+			continue
+		}
+		old.AddPolicy(p)
+		current.AddPolicy(p)
+	}
+
+	requests := [][2]string{
+		{"POST", "/api/v1/task"},
+		{"PUT", "/api/v1/task"},
+		{"GET", "/api/v1/task/findAll"},
+		{"GET", "/api/v1/task/1"},
+		{"DELETE", "/api/v1/task/1"},
+		{"GET", "/api/v1/label/1"},
+		{"DELETE", "/api/v1/webhook/1"},
+		{"GET", "/api/v1/user/1"},
+	}
+	for _, role := range []string{"ROL_1", "ROL_2", "ROL_READ"} {
+		for _, r := range requests {
+			want, _ := old.Enforce(role, "C1", r[1], r[0])
+			got, _ := current.Enforce(role, "C1", r[1], r[0])
+			assert.Equal(t, want, got, "%s %s %s", role, r[0], r[1])
+		}
+	}
+}
+
+func Test_IsAuthorized_SubresourceRules(t *testing.T) {
+
+	au := model.AuthenticatedUser{
+		Roles: []model.Role{{Code: "ROL_2"}},
+	}
+
+	assert.True(t, IsAuthorized(au, "POST", "/api/v1/task/1/comments"))
+	assert.True(t, IsAuthorized(au, "DELETE", "/api/v1/task/1/comments/2"))
+	// a wildcard in the middle of a rule does not match every path after it
+	assert.False(t, IsAuthorized(au, "POST", "/api/v1/task/bulk"))
+	assert.False(t, IsAuthorized(au, "DELETE", "/api/v1/task/1"))
+}
+
+func TestIsAuthorizedTask(t *testing.T) {
+
+	admin := model.AuthenticatedUser{Email: "admin@example.com", Roles: []model.Role{{Code: "ROL_1"}}}
+	reader := model.AuthenticatedUser{Email: "reader@example.com", Roles: []model.Role{{Code: "ROL_2"}}}
+
+	own := TaskObject{Owner: reader.Email, State: "PENDING"}
+	other := TaskObject{Owner: admin.Email, State: "PENDING"}
+	completed := TaskObject{Owner: reader.Email, State: "COMPLETED"}
+
+	assert.True(t, IsAuthorizedTask(admin, ActUpdate, own))
+	assert.True(t, IsAuthorizedTask(reader, ActUpdate, own))
+	assert.False(t, IsAuthorizedTask(reader, ActUpdate, other))
+
+	assert.True(t, IsAuthorizedTask(admin, ActDelete, other))
+	assert.False(t, IsAuthorizedTask(reader, ActDelete, own))
+	// the deny rule wins over the role
+	assert.False(t, IsAuthorizedTask(admin, ActDelete, completed))
+
+	assert.False(t, IsAuthorizedTask(model.AuthenticatedUser{Email: "x@example.com"}, ActUpdate, own))
 }
@@ -10,8 +10,6 @@ import (
 
 	"github.com/trustelem/zxcvbn"
 
-	"strconv"
-
 	errs "github.com/Alonso-Arias/test-cleverit/errors"
 	"github.com/Alonso-Arias/test-cleverit/log"
 	"github.com/raja/argon2pw"
@@ -33,7 +31,7 @@ func (ph PasswordHashImpl) Hash(p string) (string, error) {
 	log := loggerf.WithField("func", "Hash")
 	hashedPassword, err := argon2pw.GenerateSaltedHash(p)
 	if err != nil {
-		log.Error("hashedPassword fail")
+		log.WithError(err).Error("hashedPassword fail")
 		return "", err
 	}
 
@@ -44,10 +42,9 @@ func (ph PasswordHashImpl) Compare(p string, hash string) (bool, error) {
 	log := loggerf.WithField("func", "Compare")
 	valid, err := argon2pw.CompareHashWithPassword(hash, p)
 	if err != nil {
-		log.Error("CompareHashWithPassword fail")
+		log.WithError(err).Error("CompareHashWithPassword fail")
 		return false, err
 	}
-	//log.Info("CompareHashWithPassword valid : " + valid)
 	return valid, nil
 }
 
@@ -74,12 +71,10 @@ func (pp PasswordPolicyImpl) Validate(p string) (StrengthPassword, error) {
 
 	log := loggerf.WithField("func", "Validate")
 
-	prefix := "[" + p + "]"
-
 	result := Weak
 	evaluation := zxcvbn.PasswordStrength(p, nil)
 
-	log.Info(prefix + " Password points :" + strconv.Itoa(evaluation.Score))
+	log.WithField("score", evaluation.Score).Info("Password points")
 
 	if evaluation.Score >= 3 && evaluation.Score <= 4 {
 		result = Medium
@@ -93,19 +88,19 @@ func (pp PasswordPolicyImpl) Validate(p string) (StrengthPassword, error) {
 		return result, errs.LenPassPolicy
 	}
 
-	log.Info(prefix + " Policy containUpper")
+	log.Info("Policy containUpper")
 
 	if !containUpper(p) {
 		return result, errs.UpperPassPolicy
 	}
 
-	log.Info(prefix + " Policy containLower")
+	log.Info("Policy containLower")
 
 	if !containLower(p) {
 		return result, errs.LowerPassPolicy
 	}
 
-	log.Info(prefix + " Policy containDigit")
+	log.Info("Policy containDigit")
 
 	if !containDigit(p) {
 		return result, errs.DigitPassPolicy
@@ -0,0 +1,266 @@
+package security
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// Route - method and path of a registered route, in echo syntax
+// (/task/:id, /swagger/*)
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// PolicyCase - expected decision of the policy for a role of a center, path
+// and method
+type PolicyCase struct {
+	Role string
+	// Center - center of the user, empty for a user of no center, who only
+	// gets the policies of every center
+	Center  string
+	Path    string
+	Method  string
+	Allowed bool
+}
+
+func (pc PolicyCase) String() string {
+	decision := "deny"
+	if pc.Allowed {
+		decision = "allow"
+	}
+	role := pc.Role
+	if pc.Center != "" {
+		role += "@" + pc.Center
+	}
+	return fmt.Sprintf("%s %s %s -> %s", role, pc.Method, pc.Path, decision)
+}
+
+// PolicyReport - gaps found by CheckPolicy
+type PolicyReport struct {
+	// Uncovered - routes no policy lets through
+	Uncovered []Route
+	// Unused - policies (sub, dom, obj, act) matching no route
+	Unused [][]string
+	// Failed - cases whose decision is not the expected one
+	Failed []PolicyCase
+	// Invalid - task rules (rule, act, eft) that fail to evaluate
+	Invalid [][]string
+}
+
+// OK - the report found no gap
+func (pr PolicyReport) OK() bool {
+	return len(pr.Uncovered) == 0 && len(pr.Unused) == 0 && len(pr.Failed) == 0 && len(pr.Invalid) == 0
+}
+
+// Write - writes a line per gap
+func (pr PolicyReport) Write(w io.Writer) {
+	for _, r := range pr.Uncovered {
+		fmt.Fprintf(w, "uncovered route: %s\n", r)
+	}
+	for _, p := range pr.Unused {
+		fmt.Fprintf(w, "unused policy: %s\n", strings.Join(p, ", "))
+	}
+	for _, c := range pr.Failed {
+		fmt.Fprintf(w, "failed case: %s\n", c)
+	}
+	for _, p := range pr.Invalid {
+		fmt.Fprintf(w, "invalid task rule: %s\n", strings.Join(p, ", "))
+	}
+}
+
+// LoadEnforcer - enforcer for the given model and policy files, with the
+// functions of the task rules
+func LoadEnforcer(modelPath, policyPath string) (*casbin.Enforcer, error) {
+
+	e, err := casbin.NewEnforcer(modelPath, policyPath)
+	if err != nil {
+		return nil, err
+	}
+	addFunctions(e)
+
+	return e, nil
+}
+
+// CheckPolicy - matches every policy of e against every route, evaluates
+// every task rule and evaluates the cases. Each policy is tried alone on an
+// enforcer with the model of e, so a policy only counts as used when it
+// matches a route by itself. Routes are requested on a sample path where
+// :params are "1" and * is "x", in the center of the policy.
+func CheckPolicy(e *casbin.Enforcer, routes []Route, cases []PolicyCase) (PolicyReport, error) {
+
+	report := PolicyReport{}
+	covered := make([]bool, len(routes))
+
+	for _, p := range e.GetPolicy() {
+
+		single, err := singleEnforcer(e, "p", p)
+		if err != nil {
+			return PolicyReport{}, err
+		}
+
+		used := false
+		for i, r := range routes {
+			ok, err := single.Enforce(p[0], p[1], samplePath(r.Path), r.Method)
+			if err != nil {
+				return PolicyReport{}, err
+			}
+			if ok {
+				used, covered[i] = true, true
+			}
+		}
+		if !used {
+			report.Unused = append(report.Unused, p)
+		}
+	}
+
+	for i, r := range routes {
+		if !covered[i] {
+			report.Uncovered = append(report.Uncovered, r)
+		}
+	}
+
+	// A task rule that does not compile or reads a missing attribute fails
+	// every evaluation, so each one is evaluated alone on a sample task.
+	for _, p := range e.GetNamedPolicy("p2") {
+
+		single, err := singleEnforcer(e, "p2", p)
+		if err != nil {
+			return PolicyReport{}, err
+		}
+		sub := Subject{Email: "user@example.com", Roles: []string{"ROL_1"}}
+		obj := TaskObject{Owner: "user@example.com", State: "PENDING"}
+		if _, err := single.Enforce(casbin.NewEnforceContext("2"), sub, obj, p[1]); err != nil {
+			report.Invalid = append(report.Invalid, p)
+		}
+	}
+
+	for _, c := range cases {
+		ok, err := e.Enforce(c.Role, c.Center, c.Path, c.Method)
+		if err != nil {
+			return PolicyReport{}, err
+		}
+		if ok != c.Allowed {
+			report.Failed = append(report.Failed, c)
+		}
+	}
+
+	return report, nil
+}
+
+// singleEnforcer - enforcer with the model and functions of e and p as its
+// only policy of ptype
+func singleEnforcer(e *casbin.Enforcer, ptype string, p []string) (*casbin.Enforcer, error) {
+
+	// ToText only writes the first section of each kind, so the model is
+	// copied instead
+	single, err := casbin.NewEnforcer(e.GetModel().Copy())
+	if err != nil {
+		return nil, err
+	}
+	single.ClearPolicy()
+	addFunctions(single)
+
+	if _, err := single.AddNamedPolicy(ptype, toInterfaces(p)...); err != nil {
+		return nil, err
+	}
+
+	return single, nil
+}
+
+// AllowedRoles - subjects of the policy of e allowed on the sample path of r
+// in some center, sorted
+func AllowedRoles(e *casbin.Enforcer, r Route) ([]string, error) {
+	allowed := map[string]bool{}
+	for _, p := range e.GetPolicy() {
+		if allowed[p[0]] {
+			continue
+		}
+		ok, err := e.Enforce(p[0], p[1], samplePath(r.Path), r.Method)
+		if err != nil {
+			return nil, err
+		}
+		allowed[p[0]] = ok
+	}
+
+	roles := []string{}
+	for sub, ok := range allowed {
+		if ok {
+			roles = append(roles, sub)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+var routeParam = regexp.MustCompile(`:[^/]+`)
+
+// samplePath - a request path that the route serves
+func samplePath(path string) string {
+	return strings.ReplaceAll(routeParam.ReplaceAllString(path, "1"), "*", "x")
+}
+
+func toInterfaces(p []string) []interface{} {
+	out := make([]interface{}, len(p))
+	for i, v := range p {
+		out[i] = v
+	}
+	return out
+}
+
+// LoadPolicyCases - reads the cases from a CSV file of role, an optional
+// center, path, method and allow or deny. Blank lines and lines starting
+// with # are skipped.
+func LoadPolicyCases(path string) ([]PolicyCase, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	cases := []PolicyCase{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return cases, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		center := ""
+		if len(rec) == 5 {
+			center = rec[1]
+			rec = append(rec[:1], rec[2:]...)
+		} else if len(rec) != 4 {
+			return nil, fmt.Errorf("%s:%d: expected 4 or 5 fields, got %d", path, line, len(rec))
+		}
+
+		c := PolicyCase{Role: rec[0], Center: center, Path: rec[1], Method: rec[2]}
+		switch strings.TrimSpace(rec[3]) {
+		case "allow":
+			c.Allowed = true
+		case "deny":
+		default:
+			return nil, fmt.Errorf("%s:%d: expected allow or deny, got %q", path, line, rec[3])
+		}
+		cases = append(cases, c)
+	}
+}
@@ -0,0 +1,53 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPolicy(t *testing.T) {
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.csv")
+	casesPath := filepath.Join(dir, "cases.csv")
+	os.WriteFile(policyPath, []byte("p, ROL_1, *, /api/v1/task/*, GET\np, ROL_1, *, /api/v1/old, GET\np, ROL_2, C1, /api/v1/task/*, DELETE\n"+
+		"p2, \"hasRole(r2.sub.Roles, 'ROL_1')\", update, allow\np2, r2.obj.Missing == 'x', delete, deny\n"), 0o600)
+	os.WriteFile(casesPath, []byte("# rol, centro, ruta, método\nROL_1, /api/v1/task/1, GET, allow\nROL_2, /api/v1/task/1, GET, allow\n"+
+		"ROL_2, C1, /api/v1/task/1, DELETE, allow\nROL_2, C2, /api/v1/task/1, DELETE, allow\n"), 0o600)
+
+	e, err := LoadEnforcer(os.Getenv("BASE_PATH")+"/security/casbin_model.conf", policyPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	cases, err := LoadPolicyCases(casesPath)
+	assert.NoError(t, err)
+
+	routes := []Route{
+		{Method: "GET", Path: "/api/v1/task/:id"},
+		{Method: "GET", Path: "/api/v1/task/:id/comments"},
+		{Method: "DELETE", Path: "/api/v1/task/:id"},
+	}
+
+	report, err := CheckPolicy(e, routes, cases)
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Empty(t, report.Uncovered)
+	assert.Equal(t, [][]string{{"ROL_1", "*", "/api/v1/old", "GET"}}, report.Unused)
+	// the rule of center C1 is not a rule of center C2
+	assert.Equal(t, []PolicyCase{
+		{Role: "ROL_2", Path: "/api/v1/task/1", Method: "GET", Allowed: true},
+		{Role: "ROL_2", Center: "C2", Path: "/api/v1/task/1", Method: "DELETE", Allowed: true},
+	}, report.Failed)
+	assert.Equal(t, [][]string{{"r2.obj.Missing == 'x'", "delete", "deny"}}, report.Invalid)
+
+	roles, err := AllowedRoles(e, Route{Method: "DELETE", Path: "/api/v1/task/:id"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ROL_2"}, roles)
+
+	os.WriteFile(casesPath, []byte("ROL_1, /api/v1/task/1, GET, maybe\n"), 0o600)
+	_, err = LoadPolicyCases(casesPath)
+	assert.Error(t, err)
+}
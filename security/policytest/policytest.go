@@ -0,0 +1,48 @@
+// Package policytest checks the access policy from tests.
+package policytest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/security"
+)
+
+// Files - model, policy and cases of the API under BASE_PATH
+func Files() (model, policy, cases string) {
+	base := os.Getenv("BASE_PATH") + "/security/"
+	return base + "casbin_model.conf", base + "casbin_policy.csv", base + "casbin_cases.csv"
+}
+
+// Check - fails t with every route of routes not covered by the policy,
+// every policy matching none of them and every case of the cases file whose
+// decision is not the expected one
+func Check(t testing.TB, routes []security.Route) {
+	t.Helper()
+
+	modelPath, policyPath, casesPath := Files()
+
+	e, err := security.LoadEnforcer(modelPath, policyPath)
+	if err != nil {
+		t.Fatalf("fails to load the policy: %v", err)
+	}
+	cases, err := security.LoadPolicyCases(casesPath)
+	if err != nil {
+		t.Fatalf("fails to load the policy cases: %v", err)
+	}
+
+	report, err := security.CheckPolicy(e, routes, cases)
+	if err != nil {
+		t.Fatalf("fails to check the policy: %v", err)
+	}
+
+	for _, r := range report.Uncovered {
+		t.Errorf("no policy covers route %s", r)
+	}
+	for _, p := range report.Unused {
+		t.Errorf("policy %v matches no route", p)
+	}
+	for _, c := range report.Failed {
+		t.Errorf("policy case failed: %s", c)
+	}
+}
@@ -0,0 +1,107 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenSecret - key of the HS256 user tokens, from TOKEN_SECRET. With an
+// empty key every token is rejected.
+var TokenSecret = []byte(os.Getenv("TOKEN_SECRET"))
+
+// TokenTTL - lifetime of the tokens issued on login, from TOKEN_TTL (8h by
+// default)
+var TokenTTL = tokenTTLFromEnv()
+
+// TokenClaims - claims of the user tokens
+type TokenClaims struct {
+	Email string `json:"email"`
+	// Center - center of the user, the tenant whose tasks the token sees;
+	// tokens without center are rejected
+	Center string   `json:"center,omitempty"`
+	Roles  []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// IssueToken - signs a token for au that expires after ttl, au must have a
+// center
+func IssueToken(au model.AuthenticatedUser, ttl time.Duration) (string, error) {
+
+	if len(TokenSecret) == 0 {
+		return "", errors.New("TOKEN_SECRET is not set")
+	}
+	if au.CenterCode == "" {
+		return "", errors.New("user without center")
+	}
+
+	now := time.Now()
+	claims := TokenClaims{
+		Email:  au.Email,
+		Center: au.CenterCode,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   au.Email,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	for _, r := range au.Roles {
+		claims.Roles = append(claims.Roles, r.Code)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(TokenSecret)
+}
+
+// ParseToken - validates token and gets its user, errs.ExpiredToken or
+// errs.InvalidToken when it cannot be used
+func ParseToken(token string) (model.AuthenticatedUser, error) {
+
+	if len(TokenSecret) == 0 {
+		return model.AuthenticatedUser{}, errs.InvalidToken
+	}
+
+	claims := TokenClaims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return TokenSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return model.AuthenticatedUser{}, errs.ExpiredToken
+	} else if err != nil || claims.Email == "" || claims.Center == "" {
+		return model.AuthenticatedUser{}, errs.InvalidToken
+	}
+
+	au := model.AuthenticatedUser{Email: claims.Email, CenterCode: claims.Center}
+	for _, code := range claims.Roles {
+		au.Roles = append(au.Roles, model.Role{Code: code})
+	}
+
+	return au, nil
+}
+
+type userKey struct{}
+
+// WithUser - returns a copy of ctx carrying the authenticated user, whose
+// center is the tenant of the DAO queries of ctx
+func WithUser(ctx context.Context, au model.AuthenticatedUser) context.Context {
+	return base.WithTenant(context.WithValue(ctx, userKey{}, au), au.CenterCode)
+}
+
+// UserFromContext - the authenticated user of ctx, false for anonymous calls
+func UserFromContext(ctx context.Context) (model.AuthenticatedUser, bool) {
+	au, ok := ctx.Value(userKey{}).(model.AuthenticatedUser)
+	return au, ok
+}
+
+func tokenTTLFromEnv() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("TOKEN_TTL")); err == nil && v > 0 {
+		return v
+	}
+	return 8 * time.Hour
+}
@@ -0,0 +1,44 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueParseToken(t *testing.T) {
+
+	TokenSecret = []byte("test-secret")
+	defer func() { TokenSecret = nil }()
+
+	au := model.AuthenticatedUser{Email: "ana@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_2"}}}
+
+	token, err := IssueToken(au, time.Minute)
+	assert.NoError(t, err)
+
+	got, err := ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, au, got)
+
+	expired, _ := IssueToken(au, -time.Minute)
+	_, err = ParseToken(expired)
+	assert.Equal(t, errs.ExpiredToken, err)
+
+	_, err = ParseToken(token + "x")
+	assert.Equal(t, errs.InvalidToken, err)
+
+	// every token has the center of its user
+	_, err = IssueToken(model.AuthenticatedUser{Email: "ana@example.com"}, time.Minute)
+	assert.Error(t, err)
+	withoutCenter, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, TokenClaims{Email: "ana@example.com", Roles: []string{"ROL_1"}}).SignedString(TokenSecret)
+	_, err = ParseToken(withoutCenter)
+	assert.Equal(t, errs.InvalidToken, err)
+
+	TokenSecret = []byte("other-secret")
+	_, err = ParseToken(token)
+	assert.Equal(t, errs.InvalidToken, err)
+}
@@ -0,0 +1,243 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gopkg.in/dealancer/validate.v2"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// UserPrefix antecede al prefijo de la clave en el usuario de las llamadas
+// hechas con ella, que queda por ejemplo como dueño de las tareas creadas.
+const UserPrefix = "apikey:"
+
+// lastUsedResolution es el tiempo mínimo entre dos registros del último uso
+// de una clave, así cada llamada no escribe en la base de datos.
+const lastUsedResolution = time.Minute
+
+// verified guarda el sha256 de cada clave ya comparada con su hash, por
+// prefijo, así argon2 solo se evalúa en el primer uso de la clave en el
+// proceso. La revocación se sigue leyendo de la base de datos en cada uso.
+var verified sync.Map
+
+// ApiKeyService contiene los métodos relacionados con las claves de API.
+type ApiKeyService struct{}
+
+// FindAllApiKeysResponse es la respuesta para FindAllApiKeys.
+type FindAllApiKeysResponse struct {
+	ApiKeys []model.ApiKey `json:"apiKeys"`
+}
+
+// FindAllApiKeys recupera todas las claves del centro del usuario, sin su
+// parte secreta.
+func (ks ApiKeyService) FindAllApiKeys(ctx context.Context) (FindAllApiKeysResponse, error) {
+	log := loggerf.WithField("service", "ApiKeyService").WithField("func", "FindAllApiKeys")
+
+	ctx, span := tracing.Tracer().Start(ctx, "ApiKeyService.FindAllApiKeys")
+	defer span.End()
+
+	keys, err := dao.NewApiKeyDAO().FindAll(ctx)
+	if err != nil {
+		log.WithError(err).Error("problems with getting api keys")
+		return FindAllApiKeysResponse{}, err
+	}
+
+	results := []model.ApiKey{}
+	for _, v := range keys {
+		results = append(results, toApiKeyModel(v))
+	}
+
+	return FindAllApiKeysResponse{ApiKeys: results}, nil
+}
+
+// IssueApiKeyRequest es la solicitud para IssueApiKey.
+type IssueApiKeyRequest struct {
+	ApiKey model.ApiKey `json:"apiKey"`
+}
+
+// IssueApiKeyResponse es la respuesta para IssueApiKey. Key es la clave que
+// se envía en "Authorization: ApiKey ..." y no se vuelve a mostrar.
+type IssueApiKeyResponse struct {
+	Id  int32  `json:"id"`
+	Key string `json:"key"`
+}
+
+// IssueApiKey crea una clave en el centro del usuario con los roles de sus
+// scopes (security.ApiKeyScopes); el usuario debe tener centro. Solo se
+// guarda el hash de su parte secreta.
+func (ks ApiKeyService) IssueApiKey(ctx context.Context, in IssueApiKeyRequest) (IssueApiKeyResponse, error) {
+	log := loggerf.WithField("service", "ApiKeyService").WithField("func", "IssueApiKey")
+
+	ctx, span := tracing.Tracer().Start(ctx, "ApiKeyService.IssueApiKey")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil || len(in.ApiKey.Scopes) == 0 {
+		log.WithError(err).Error("validation problems")
+		return IssueApiKeyResponse{}, errs.BadRequest
+	}
+
+	if _, ok := security.ScopeRoles(in.ApiKey.Scopes); !ok {
+		return IssueApiKeyResponse{}, errs.ApiKeyScopeInvalid.SetMessage("Unknown scope in " + strings.Join(in.ApiKey.Scopes, ","))
+	}
+
+	prefix, secret := newToken(8), newToken(32)
+
+	hash, err := security.PasswordHashImpl{}.Hash(secret)
+	if err != nil {
+		return IssueApiKeyResponse{}, err
+	}
+
+	key := md.ApiKey{
+		Name:   in.ApiKey.Name,
+		Prefix: prefix,
+		Hash:   hash,
+		Scopes: strings.Join(in.ApiKey.Scopes, ","),
+	}
+	key.CenterCode, _ = base.Tenant(ctx)
+	if key.CenterCode == "" {
+		return IssueApiKeyResponse{}, errs.BadRequest.SetMessage("API key without center")
+	}
+	if au, ok := security.UserFromContext(ctx); ok {
+		key.CreatedBy = au.Email
+	}
+
+	if err := dao.NewApiKeyDAO().Save(ctx, &key); err != nil {
+		log.WithError(err).Error("problems with saving api key")
+		return IssueApiKeyResponse{}, err
+	}
+
+	return IssueApiKeyResponse{Id: key.Id, Key: prefix + "." + secret}, nil
+}
+
+// RevokeApiKeyRequest es la solicitud para RevokeApiKey.
+type RevokeApiKeyRequest struct {
+	Id int32 `json:"id"`
+}
+
+// RevokeApiKeyResponse es la respuesta para RevokeApiKey.
+type RevokeApiKeyResponse struct{}
+
+// RevokeApiKey revoca una clave, que deja de aceptarse en la siguiente
+// llamada. La clave se conserva con su fecha de revocación.
+func (ks ApiKeyService) RevokeApiKey(ctx context.Context, in RevokeApiKeyRequest) (RevokeApiKeyResponse, error) {
+
+	ctx, span := tracing.Tracer().Start(ctx, "ApiKeyService.RevokeApiKey")
+	defer span.End()
+
+	if in.Id == 0 {
+		return RevokeApiKeyResponse{}, errs.BadRequest
+	}
+
+	if _, err := getApiKey(ctx, in.Id); err != nil {
+		return RevokeApiKeyResponse{}, err
+	}
+
+	if err := dao.NewApiKeyDAO().Revoke(ctx, in.Id, time.Now().UTC()); err != nil {
+		return RevokeApiKeyResponse{}, err
+	}
+
+	return RevokeApiKeyResponse{}, nil
+}
+
+// Authenticate obtiene el usuario de una clave: su centro y los roles de sus
+// scopes. Devuelve InvalidApiKey si la clave no existe o no coincide y
+// RevokedApiKey si fue revocada; registra el uso de la clave.
+func (ks ApiKeyService) Authenticate(ctx context.Context, key string) (model.AuthenticatedUser, error) {
+	log := loggerf.WithField("service", "ApiKeyService").WithField("func", "Authenticate")
+
+	ctx, span := tracing.Tracer().Start(ctx, "ApiKeyService.Authenticate")
+	defer span.End()
+
+	prefix, secret, ok := strings.Cut(key, ".")
+	if !ok || prefix == "" || secret == "" {
+		return model.AuthenticatedUser{}, errs.InvalidApiKey
+	}
+
+	v, err := dao.NewApiKeyDAO().GetByPrefix(ctx, prefix)
+	if err == gorm.ErrRecordNotFound {
+		return model.AuthenticatedUser{}, errs.InvalidApiKey
+	} else if err != nil {
+		return model.AuthenticatedUser{}, err
+	}
+
+	if v.RevokedAt != nil {
+		return model.AuthenticatedUser{}, errs.RevokedApiKey
+	}
+	if v.CenterCode == "" {
+		return model.AuthenticatedUser{}, errs.InvalidApiKey
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	if cached, ok := verified.Load(prefix); !ok || cached.([32]byte) != sum {
+		if valid, _ := (security.PasswordHashImpl{}).Compare(secret, v.Hash); !valid {
+			return model.AuthenticatedUser{}, errs.InvalidApiKey
+		}
+		verified.Store(prefix, sum)
+	}
+
+	roles, ok := security.ScopeRoles(strings.Split(v.Scopes, ","))
+	if !ok {
+		log.WithField("prefix", prefix).Error("api key with unknown scope")
+		return model.AuthenticatedUser{}, errs.InvalidApiKey
+	}
+
+	now := time.Now().UTC()
+	if v.LastUsedAt == nil || now.Sub(*v.LastUsedAt) >= lastUsedResolution {
+		// un registro fallido no impide la llamada
+		dao.NewApiKeyDAO().Touch(ctx, v.Id, now)
+	}
+
+	return model.AuthenticatedUser{Email: UserPrefix + v.Prefix, CenterCode: v.CenterCode, Roles: roles}, nil
+}
+
+// getApiKey devuelve ApiKeyNotFound si no existe la clave.
+func getApiKey(ctx context.Context, id int32) (md.ApiKey, error) {
+
+	v, err := dao.NewApiKeyDAO().Get(ctx, id)
+	if err == gorm.ErrRecordNotFound {
+		return md.ApiKey{}, errs.ApiKeyNotFound
+	}
+
+	return v, err
+}
+
+func toApiKeyModel(v md.ApiKey) model.ApiKey {
+	k := model.ApiKey{
+		Id:        v.Id,
+		Name:      v.Name,
+		Prefix:    v.Prefix,
+		Scopes:    strings.Split(v.Scopes, ","),
+		CreatedBy: v.CreatedBy,
+		CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if v.LastUsedAt != nil {
+		k.LastUsedAt = v.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	if v.RevokedAt != nil {
+		k.RevokedAt = v.RevokedAt.UTC().Format(time.RFC3339)
+	}
+	return k
+}
+
+// newToken genera n bytes aleatorios en hexadecimal.
+func newToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
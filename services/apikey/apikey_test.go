@@ -0,0 +1,67 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeyService_Lifecycle(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ks := ApiKeyService{}
+	admin := security.WithUser(context.TODO(), model.AuthenticatedUser{Email: "admin@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_1"}}})
+
+	_, err := ks.IssueApiKey(admin, IssueApiKeyRequest{ApiKey: model.ApiKey{Name: "batch", Scopes: []string{"tasks:everything"}}})
+	assert.Equal(t, errs.ApiKeyScopeInvalid.InternalCode, err.(errs.CustomError).InternalCode)
+
+	issued, err := ks.IssueApiKey(admin, IssueApiKeyRequest{ApiKey: model.ApiKey{Name: "batch", Scopes: []string{"tasks:read"}}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// only the hash of the secret is stored
+	stored, _ := dao.NewApiKeyDAO().Get(context.TODO(), issued.Id)
+	_, secret, _ := strings.Cut(issued.Key, ".")
+	assert.NotContains(t, stored.Hash, secret)
+	assert.Equal(t, "C1", stored.CenterCode)
+	assert.Nil(t, stored.LastUsedAt)
+
+	au, err := ks.Authenticate(context.TODO(), issued.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, UserPrefix+stored.Prefix, au.Email)
+	assert.Equal(t, "C1", au.CenterCode)
+	assert.Equal(t, []model.Role{{Code: "ROL_READ"}}, au.Roles)
+
+	_, err = ks.Authenticate(context.TODO(), stored.Prefix+".wrong")
+	assert.Equal(t, errs.InvalidApiKey, err)
+	_, err = ks.Authenticate(context.TODO(), "nope")
+	assert.Equal(t, errs.InvalidApiKey, err)
+
+	all, err := ks.FindAllApiKeys(admin)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, all.ApiKeys) {
+		last := all.ApiKeys[len(all.ApiKeys)-1]
+		assert.Equal(t, "batch", last.Name)
+		assert.Equal(t, "admin@example.com", last.CreatedBy)
+		assert.NotEmpty(t, last.LastUsedAt)
+	}
+
+	// the keys of C1 are not seen from C2
+	other := security.WithUser(context.TODO(), model.AuthenticatedUser{Email: "admin@example.com", CenterCode: "C2", Roles: []model.Role{{Code: "ROL_1"}}})
+	_, err = ks.RevokeApiKey(other, RevokeApiKeyRequest{Id: issued.Id})
+	assert.Equal(t, errs.ApiKeyNotFound, err)
+
+	_, err = ks.RevokeApiKey(admin, RevokeApiKeyRequest{Id: issued.Id})
+	assert.NoError(t, err)
+	_, err = ks.Authenticate(context.TODO(), issued.Key)
+	assert.Equal(t, errs.RevokedApiKey, err)
+}
@@ -0,0 +1,296 @@
+package attachment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/Alonso-Arias/test-cleverit/storage"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// MaxSize es el tamaño máximo de un adjunto en bytes, de ATTACHMENT_MAX_SIZE
+// (10 MiB por defecto).
+var MaxSize = maxSizeFromEnv()
+
+// ContentTypes son los tipos de contenido aceptados, de la lista separada por
+// comas ATTACHMENT_CONTENT_TYPES.
+var ContentTypes = contentTypesFromEnv()
+
+// AttachmentService contiene los métodos relacionados con los adjuntos de las tareas.
+type AttachmentService struct{}
+
+// ListAttachmentsRequest es la solicitud para ListAttachments.
+type ListAttachmentsRequest struct {
+	TaskId int32 `json:"taskId"`
+}
+
+// ListAttachmentsResponse es la respuesta para ListAttachments.
+type ListAttachmentsResponse struct {
+	Attachments []model.Attachment `json:"attachments"`
+}
+
+// ListAttachments recupera los datos de los adjuntos de una tarea.
+func (as AttachmentService) ListAttachments(ctx context.Context, in ListAttachmentsRequest) (ListAttachmentsResponse, error) {
+	log := loggerf.WithField("service", "AttachmentService").WithField("func", "ListAttachments")
+
+	ctx, span := tracing.Tracer().Start(ctx, "AttachmentService.ListAttachments")
+	defer span.End()
+
+	if in.TaskId == 0 {
+		return ListAttachmentsResponse{}, errs.BadRequest
+	}
+
+	if err := task.Exists(ctx, in.TaskId); err != nil {
+		return ListAttachmentsResponse{}, err
+	}
+
+	attachments, err := dao.NewTaskAttachmentDAO().FindByTask(ctx, in.TaskId)
+	if err != nil {
+		log.WithError(err).Error("problems with getting attachments")
+		return ListAttachmentsResponse{}, err
+	}
+
+	results := []model.Attachment{}
+	for _, v := range attachments {
+		results = append(results, toAttachmentModel(v))
+	}
+
+	return ListAttachmentsResponse{Attachments: results}, nil
+}
+
+// SaveAttachmentRequest es la solicitud para SaveAttachment; el contenido se
+// recibe aparte.
+type SaveAttachmentRequest struct {
+	TaskId      int32  `json:"taskId"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// SaveAttachmentResponse es la respuesta para SaveAttachment.
+type SaveAttachmentResponse struct {
+	Attachment model.Attachment `json:"attachment"`
+}
+
+// SaveAttachment guarda el contenido de r en el almacenamiento y registra el
+// adjunto de la tarea, comprobando el tamaño y el tipo de contenido.
+func (as AttachmentService) SaveAttachment(ctx context.Context, in SaveAttachmentRequest, r io.Reader) (SaveAttachmentResponse, error) {
+	log := loggerf.WithField("service", "AttachmentService").WithField("func", "SaveAttachment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "AttachmentService.SaveAttachment")
+	defer span.End()
+
+	fileName := path.Base(strings.ReplaceAll(in.FileName, "\\", "/"))
+	if in.TaskId == 0 || fileName == "." || fileName == "/" || in.Size < 0 {
+		return SaveAttachmentResponse{}, errs.BadRequest
+	}
+
+	if in.Size > MaxSize {
+		return SaveAttachmentResponse{}, errs.AttachmentTooLarge.SetMessage(fmt.Sprintf("Attachment too large, max %d bytes", MaxSize))
+	}
+
+	contentType, err := contentTypeValidate(in.ContentType)
+	if err != nil {
+		return SaveAttachmentResponse{}, err
+	}
+
+	if err := task.Exists(ctx, in.TaskId); err != nil {
+		return SaveAttachmentResponse{}, err
+	}
+
+	attachment := md.TaskAttachment{
+		TaskId:      in.TaskId,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        in.Size,
+		StorageKey:  fmt.Sprintf("tasks/%d/%s", in.TaskId, newKey()),
+	}
+	if au, ok := security.UserFromContext(ctx); ok {
+		attachment.UploadedBy = au.Email
+	}
+
+	blobs := storage.Default()
+
+	if err := blobs.Put(ctx, attachment.StorageKey, r, in.Size, contentType); err != nil {
+		log.WithError(err).Error("problems with storing attachment")
+		return SaveAttachmentResponse{}, err
+	}
+
+	if err := dao.NewTaskAttachmentDAO().Save(ctx, &attachment); err != nil {
+		log.WithError(err).Error("problems with saving attachment")
+		if err := blobs.Delete(ctx, attachment.StorageKey); err != nil {
+			log.WithError(err).Error("problems with removing orphan attachment")
+		}
+		return SaveAttachmentResponse{}, err
+	}
+
+	return SaveAttachmentResponse{Attachment: toAttachmentModel(attachment)}, nil
+}
+
+// GetAttachmentRequest es la solicitud para GetAttachment.
+type GetAttachmentRequest struct {
+	TaskId int32 `json:"taskId"`
+	Id     int32 `json:"id"`
+}
+
+// GetAttachmentResponse es la respuesta para GetAttachment. Quien llama debe
+// cerrar Content.
+type GetAttachmentResponse struct {
+	Attachment model.Attachment `json:"attachment"`
+	Content    io.ReadCloser    `json:"-"`
+}
+
+// GetAttachment obtiene los datos y el contenido de un adjunto.
+func (as AttachmentService) GetAttachment(ctx context.Context, in GetAttachmentRequest) (GetAttachmentResponse, error) {
+	log := loggerf.WithField("service", "AttachmentService").WithField("func", "GetAttachment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "AttachmentService.GetAttachment")
+	defer span.End()
+
+	v, err := getAttachment(ctx, in.TaskId, in.Id)
+	if err != nil {
+		return GetAttachmentResponse{}, err
+	}
+
+	content, err := storage.Default().Get(ctx, v.StorageKey)
+	if err == storage.ErrNotFound {
+		log.WithField("key", v.StorageKey).Error("attachment content is missing")
+		return GetAttachmentResponse{}, errs.AttachmentNotFound
+	} else if err != nil {
+		log.WithError(err).Error("problems with reading attachment")
+		return GetAttachmentResponse{}, err
+	}
+
+	return GetAttachmentResponse{Attachment: toAttachmentModel(v), Content: content}, nil
+}
+
+// DeleteAttachmentRequest es la solicitud para DeleteAttachment.
+type DeleteAttachmentRequest struct {
+	TaskId int32 `json:"taskId"`
+	Id     int32 `json:"id"`
+}
+
+// DeleteAttachmentResponse es la respuesta para DeleteAttachment.
+type DeleteAttachmentResponse struct{}
+
+// DeleteAttachment elimina un adjunto y su contenido.
+func (as AttachmentService) DeleteAttachment(ctx context.Context, in DeleteAttachmentRequest) (DeleteAttachmentResponse, error) {
+	log := loggerf.WithField("service", "AttachmentService").WithField("func", "DeleteAttachment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "AttachmentService.DeleteAttachment")
+	defer span.End()
+
+	v, err := getAttachment(ctx, in.TaskId, in.Id)
+	if err != nil {
+		return DeleteAttachmentResponse{}, err
+	}
+
+	if err := dao.NewTaskAttachmentDAO().Delete(ctx, v.Id); err != nil {
+		log.WithError(err).Error("problems with deleting attachment")
+		return DeleteAttachmentResponse{}, err
+	}
+
+	// sin el registro el contenido ya no es accesible, un fallo solo deja un huérfano
+	if err := storage.Default().Delete(ctx, v.StorageKey); err != nil {
+		log.WithError(err).Error("problems with removing attachment content")
+	}
+
+	return DeleteAttachmentResponse{}, nil
+}
+
+// getAttachment obtiene un adjunto de la tarea o AttachmentNotFound; la
+// tarea debe ser del centro del usuario.
+func getAttachment(ctx context.Context, taskId, id int32) (md.TaskAttachment, error) {
+
+	if taskId == 0 || id == 0 {
+		return md.TaskAttachment{}, errs.BadRequest
+	}
+
+	if err := task.Exists(ctx, taskId); err != nil {
+		return md.TaskAttachment{}, err
+	}
+
+	v, err := dao.NewTaskAttachmentDAO().Get(ctx, taskId, id)
+	if err == gorm.ErrRecordNotFound {
+		return md.TaskAttachment{}, errs.AttachmentNotFound
+	}
+
+	return v, err
+}
+
+// contentTypeValidate normaliza el tipo de contenido y comprueba que esté
+// entre los aceptados.
+func contentTypeValidate(contentType string) (string, error) {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", errs.AttachmentTypeNotAllowed
+	}
+
+	for _, t := range ContentTypes {
+		if t == mediaType {
+			return mediaType, nil
+		}
+	}
+
+	return "", errs.AttachmentTypeNotAllowed.SetMessage("Attachment content type not allowed: " + mediaType)
+}
+
+// newKey genera el nombre aleatorio del contenido en el almacenamiento.
+func newKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func maxSizeFromEnv() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 10 << 20
+}
+
+func contentTypesFromEnv() []string {
+	v := os.Getenv("ATTACHMENT_CONTENT_TYPES")
+	if v == "" {
+		v = "application/pdf,image/png,image/jpeg,image/gif,text/plain,text/markdown,text/csv"
+	}
+	types := []string{}
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, strings.ToLower(t))
+		}
+	}
+	return types
+}
+
+func toAttachmentModel(v md.TaskAttachment) model.Attachment {
+	return model.Attachment{
+		Id:          v.Id,
+		TaskId:      v.TaskId,
+		FileName:    v.FileName,
+		ContentType: v.ContentType,
+		Size:        v.Size,
+		UploadedBy:  v.UploadedBy,
+		CreatedAt:   v.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,165 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/Alonso-Arias/test-cleverit/storage"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func setup(t *testing.T) string {
+	testenv.SQLite(t)
+	dir := t.TempDir()
+	s, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("fails to init storage: %v", err)
+	}
+	storage.SetDefault(s)
+	return dir
+}
+
+func newTask(t *testing.T) int32 {
+	v := md.Task{Title: "attachments", Description: "attachments", DueDate: time.Now().UTC(), State: "PENDING", CenterCode: "C1"}
+	if err := dao.NewTaskDAO().Save(context.TODO(), &v); err != nil {
+		t.Fatalf("fails to save task: %v", err)
+	}
+	return v.Id
+}
+
+func TestAttachmentService_UploadDownloadDelete(t *testing.T) {
+	dir := setup(t)
+
+	ctx := context.TODO()
+	as := AttachmentService{}
+	taskId := newTask(t)
+	content := "# Spec\n"
+
+	saved, err := as.SaveAttachment(ctx, SaveAttachmentRequest{
+		TaskId: taskId, FileName: `C:\docs\spec.md`, ContentType: "text/markdown; charset=utf-8", Size: int64(len(content)),
+	}, strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, "spec.md", saved.Attachment.FileName)
+	assert.Equal(t, "text/markdown", saved.Attachment.ContentType)
+
+	list, err := as.ListAttachments(ctx, ListAttachmentsRequest{TaskId: taskId})
+	assert.NoError(t, err)
+	assert.Len(t, list.Attachments, 1)
+
+	got, err := as.GetAttachment(ctx, GetAttachmentRequest{TaskId: taskId, Id: saved.Attachment.Id})
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(got.Content)
+		got.Content.Close()
+		assert.Equal(t, content, string(b))
+	}
+
+	_, err = as.GetAttachment(ctx, GetAttachmentRequest{TaskId: newTask(t), Id: saved.Attachment.Id})
+	assert.Equal(t, errs.AttachmentNotFound, err)
+
+	_, err = as.DeleteAttachment(ctx, DeleteAttachmentRequest{TaskId: taskId, Id: saved.Attachment.Id})
+	assert.NoError(t, err)
+
+	_, err = as.GetAttachment(ctx, GetAttachmentRequest{TaskId: taskId, Id: saved.Attachment.Id})
+	assert.Equal(t, errs.AttachmentNotFound, err)
+	assert.Empty(t, files(t, dir))
+}
+
+func TestAttachmentService_Limits(t *testing.T) {
+	setup(t)
+
+	ctx := context.TODO()
+	as := AttachmentService{}
+	taskId := newTask(t)
+
+	_, err := as.SaveAttachment(ctx, SaveAttachmentRequest{
+		TaskId: taskId, FileName: "big.pdf", ContentType: "application/pdf", Size: MaxSize + 1,
+	}, strings.NewReader(""))
+	assert.IsType(t, errs.CustomError{}, err)
+	assert.Equal(t, errs.AttachmentTooLarge.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = as.SaveAttachment(ctx, SaveAttachmentRequest{
+		TaskId: taskId, FileName: "run.exe", ContentType: "application/x-msdownload", Size: 1,
+	}, strings.NewReader("x"))
+	assert.IsType(t, errs.CustomError{}, err)
+	assert.Equal(t, errs.AttachmentTypeNotAllowed.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = as.SaveAttachment(ctx, SaveAttachmentRequest{
+		TaskId: 999999, FileName: "a.txt", ContentType: "text/plain", Size: 1,
+	}, strings.NewReader("x"))
+	assert.Equal(t, errs.TasksNotFound, err)
+}
+
+func TestAttachmentService_OtherCenter(t *testing.T) {
+	setup(t)
+
+	c1, c2 := base.WithTenant(context.TODO(), "C1"), base.WithTenant(context.TODO(), "C2")
+	as := AttachmentService{}
+
+	v := md.Task{Title: "attachments", Description: "attachments", DueDate: time.Now().UTC(), State: "PENDING", CenterCode: "C1"}
+	if err := dao.NewTaskDAO().Save(c1, &v); err != nil {
+		t.Fatalf("fails to save task: %v", err)
+	}
+
+	saved, err := as.SaveAttachment(c1, SaveAttachmentRequest{
+		TaskId: v.Id, FileName: "a.txt", ContentType: "text/plain", Size: 1,
+	}, strings.NewReader("x"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// C2 neither reads nor deletes the attachments of C1
+	_, err = as.GetAttachment(c2, GetAttachmentRequest{TaskId: v.Id, Id: saved.Attachment.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+	_, err = as.DeleteAttachment(c2, DeleteAttachmentRequest{TaskId: v.Id, Id: saved.Attachment.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	got, err := as.GetAttachment(c1, GetAttachmentRequest{TaskId: v.Id, Id: saved.Attachment.Id})
+	if assert.NoError(t, err) {
+		got.Content.Close()
+	}
+}
+
+func TestAttachmentService_RemovedWithTask(t *testing.T) {
+	dir := setup(t)
+
+	ctx := context.TODO()
+	taskId := newTask(t)
+
+	_, err := AttachmentService{}.SaveAttachment(ctx, SaveAttachmentRequest{
+		TaskId: taskId, FileName: "a.txt", ContentType: "text/plain", Size: 1,
+	}, strings.NewReader("x"))
+	assert.NoError(t, err)
+	assert.Len(t, files(t, dir), 1)
+
+	_, err = task.TaskService{}.DeleteTask(ctx, task.DeleteTaskRequest{Id: taskId})
+	assert.NoError(t, err)
+
+	attachments, err := dao.NewTaskAttachmentDAO().FindByTask(ctx, taskId)
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+	assert.Empty(t, files(t, dir))
+}
+
+// files - regular files stored under dir
+func files(t *testing.T, dir string) []string {
+	found := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			found = append(found, path)
+		}
+		return err
+	})
+	assert.NoError(t, err)
+	return found
+}
@@ -0,0 +1,220 @@
+package comment
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gopkg.in/dealancer/validate.v2"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// Tamaños de página de ListComments.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// CommentService contiene los métodos relacionados con los comentarios de las tareas.
+type CommentService struct{}
+
+// ListCommentsRequest es la solicitud para ListComments. Page empieza en 1.
+type ListCommentsRequest struct {
+	TaskId int32 `json:"taskId"`
+	Page   int   `json:"page" query:"page"`
+	Size   int   `json:"size" query:"size"`
+}
+
+// ListCommentsResponse es la respuesta para ListComments.
+type ListCommentsResponse struct {
+	Comments []model.Comment `json:"comments"`
+	Page     int             `json:"page"`
+	Size     int             `json:"size"`
+	Total    int64           `json:"total"`
+}
+
+// ListComments recupera una página de los comentarios de una tarea, del más
+// antiguo al más reciente.
+func (cs CommentService) ListComments(ctx context.Context, in ListCommentsRequest) (ListCommentsResponse, error) {
+	log := loggerf.WithField("service", "CommentService").WithField("func", "ListComments")
+
+	ctx, span := tracing.Tracer().Start(ctx, "CommentService.ListComments")
+	defer span.End()
+
+	if in.TaskId == 0 || in.Page < 0 || in.Size < 0 || in.Size > MaxPageSize {
+		return ListCommentsResponse{}, errs.BadRequest
+	}
+	if in.Page == 0 {
+		in.Page = 1
+	}
+	if in.Size == 0 {
+		in.Size = DefaultPageSize
+	}
+
+	if err := task.Exists(ctx, in.TaskId); err != nil {
+		return ListCommentsResponse{}, err
+	}
+
+	comments, total, err := dao.NewTaskCommentDAO().FindByTask(ctx, in.TaskId, (in.Page-1)*in.Size, in.Size)
+	if err != nil {
+		log.WithError(err).Error("problems with getting comments")
+		return ListCommentsResponse{}, err
+	}
+
+	results := []model.Comment{}
+	for _, v := range comments {
+		results = append(results, toCommentModel(v))
+	}
+
+	return ListCommentsResponse{Comments: results, Page: in.Page, Size: in.Size, Total: total}, nil
+}
+
+// SaveCommentRequest es la solicitud para SaveComment.
+type SaveCommentRequest struct {
+	TaskId  int32         `json:"taskId"`
+	Comment model.Comment `json:"comment"`
+}
+
+// SaveCommentResponse es la respuesta para SaveComment.
+type SaveCommentResponse struct {
+	Id int32 `json:"id"`
+}
+
+// SaveComment agrega un comentario a una tarea; el autor es el usuario autenticado.
+func (cs CommentService) SaveComment(ctx context.Context, in SaveCommentRequest) (SaveCommentResponse, error) {
+	log := loggerf.WithField("service", "CommentService").WithField("func", "SaveComment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "CommentService.SaveComment")
+	defer span.End()
+
+	au, ok := security.UserFromContext(ctx)
+	if !ok {
+		return SaveCommentResponse{}, errs.Unauthorized
+	}
+
+	if err := validate.Validate(in); err != nil || in.TaskId == 0 {
+		log.WithError(err).Error("validation problems")
+		return SaveCommentResponse{}, errs.BadRequest
+	}
+
+	if err := task.Exists(ctx, in.TaskId); err != nil {
+		return SaveCommentResponse{}, err
+	}
+
+	comment := md.TaskComment{TaskId: in.TaskId, Author: au.Email, Body: in.Comment.Body}
+	if err := dao.NewTaskCommentDAO().Save(ctx, &comment); err != nil {
+		log.WithError(err).Error("problems with saving comment")
+		return SaveCommentResponse{}, err
+	}
+
+	return SaveCommentResponse{Id: comment.Id}, nil
+}
+
+// UpdateCommentRequest es la solicitud para UpdateComment.
+type UpdateCommentRequest struct {
+	TaskId  int32         `json:"taskId"`
+	Comment model.Comment `json:"comment"`
+}
+
+// UpdateCommentResponse es la respuesta para UpdateComment.
+type UpdateCommentResponse struct{}
+
+// UpdateComment cambia el texto de un comentario; solo puede hacerlo su autor.
+func (cs CommentService) UpdateComment(ctx context.Context, in UpdateCommentRequest) (UpdateCommentResponse, error) {
+	log := loggerf.WithField("service", "CommentService").WithField("func", "UpdateComment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil || in.TaskId == 0 || in.Comment.Id == 0 {
+		log.WithError(err).Error("validation problems")
+		return UpdateCommentResponse{}, errs.BadRequest
+	}
+
+	if _, err := authoredComment(ctx, in.TaskId, in.Comment.Id); err != nil {
+		return UpdateCommentResponse{}, err
+	}
+
+	err := dao.NewTaskCommentDAO().Update(ctx, md.TaskComment{Id: in.Comment.Id, Body: in.Comment.Body})
+	if err != nil {
+		log.WithError(err).Error("problems with updating comment")
+		return UpdateCommentResponse{}, err
+	}
+
+	return UpdateCommentResponse{}, nil
+}
+
+// DeleteCommentRequest es la solicitud para DeleteComment.
+type DeleteCommentRequest struct {
+	TaskId int32 `json:"taskId"`
+	Id     int32 `json:"id"`
+}
+
+// DeleteCommentResponse es la respuesta para DeleteComment.
+type DeleteCommentResponse struct{}
+
+// DeleteComment elimina un comentario; solo puede hacerlo su autor.
+func (cs CommentService) DeleteComment(ctx context.Context, in DeleteCommentRequest) (DeleteCommentResponse, error) {
+	log := loggerf.WithField("service", "CommentService").WithField("func", "DeleteComment")
+
+	ctx, span := tracing.Tracer().Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+
+	if in.TaskId == 0 || in.Id == 0 {
+		return DeleteCommentResponse{}, errs.BadRequest
+	}
+
+	if _, err := authoredComment(ctx, in.TaskId, in.Id); err != nil {
+		return DeleteCommentResponse{}, err
+	}
+
+	if err := dao.NewTaskCommentDAO().Delete(ctx, in.Id); err != nil {
+		log.WithError(err).Error("problems with deleting comment")
+		return DeleteCommentResponse{}, err
+	}
+
+	return DeleteCommentResponse{}, nil
+}
+
+// authoredComment obtiene un comentario de la tarea y comprueba que su autor
+// sea el usuario autenticado.
+func authoredComment(ctx context.Context, taskId, id int32) (md.TaskComment, error) {
+
+	au, ok := security.UserFromContext(ctx)
+	if !ok {
+		return md.TaskComment{}, errs.Unauthorized
+	}
+
+	v, err := dao.NewTaskCommentDAO().Get(ctx, taskId, id)
+	if err == gorm.ErrRecordNotFound {
+		return md.TaskComment{}, errs.CommentNotFound
+	} else if err != nil {
+		return md.TaskComment{}, err
+	}
+
+	if v.Author != au.Email {
+		return md.TaskComment{}, errs.CommentNotAuthor
+	}
+
+	return v, nil
+}
+
+func toCommentModel(v md.TaskComment) model.Comment {
+	return model.Comment{
+		Id:        v.Id,
+		TaskId:    v.TaskId,
+		Author:    v.Author,
+		Body:      v.Body,
+		CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: v.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
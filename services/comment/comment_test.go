@@ -0,0 +1,108 @@
+package comment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTask(t *testing.T) int32 {
+	task := md.Task{Title: "comments", Description: "comments", DueDate: time.Now().UTC(), State: "PENDING", CenterCode: "C1"}
+	if err := dao.NewTaskDAO().Save(context.TODO(), &task); err != nil {
+		t.Fatalf("fails to save task: %v", err)
+	}
+	return task.Id
+}
+
+func asUser(email string) context.Context {
+	return security.WithUser(context.TODO(), model.AuthenticatedUser{Email: email, CenterCode: "C1"})
+}
+
+func TestCommentService_AuthorOnlyChanges(t *testing.T) {
+	testenv.SQLite(t)
+
+	cs := CommentService{}
+	taskId := newTask(t)
+	alice, bob := asUser("alice@example.com"), asUser("bob@example.com")
+
+	_, err := cs.SaveComment(context.TODO(), SaveCommentRequest{TaskId: taskId, Comment: model.Comment{Body: "hi"}})
+	assert.Equal(t, errs.Unauthorized, err)
+
+	_, err = cs.SaveComment(alice, SaveCommentRequest{TaskId: taskId})
+	assert.Equal(t, errs.BadRequest, err)
+
+	_, err = cs.SaveComment(alice, SaveCommentRequest{TaskId: 999999, Comment: model.Comment{Body: "hi"}})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	saved, err := cs.SaveComment(alice, SaveCommentRequest{TaskId: taskId, Comment: model.Comment{Body: "first"}})
+	assert.NoError(t, err)
+	assert.NotZero(t, saved.Id)
+
+	_, err = cs.UpdateComment(bob, UpdateCommentRequest{TaskId: taskId, Comment: model.Comment{Id: saved.Id, Body: "edited"}})
+	assert.Equal(t, errs.CommentNotAuthor, err)
+	_, err = cs.DeleteComment(bob, DeleteCommentRequest{TaskId: taskId, Id: saved.Id})
+	assert.Equal(t, errs.CommentNotAuthor, err)
+
+	_, err = cs.UpdateComment(alice, UpdateCommentRequest{TaskId: taskId + 1, Comment: model.Comment{Id: saved.Id, Body: "edited"}})
+	assert.Equal(t, errs.CommentNotFound, err)
+
+	_, err = cs.UpdateComment(alice, UpdateCommentRequest{TaskId: taskId, Comment: model.Comment{Id: saved.Id, Body: "edited"}})
+	assert.NoError(t, err)
+
+	res, err := cs.ListComments(bob, ListCommentsRequest{TaskId: taskId})
+	assert.NoError(t, err)
+	assert.Len(t, res.Comments, 1)
+	assert.Equal(t, "edited", res.Comments[0].Body)
+	assert.Equal(t, "alice@example.com", res.Comments[0].Author)
+
+	_, err = cs.DeleteComment(alice, DeleteCommentRequest{TaskId: taskId, Id: saved.Id})
+	assert.NoError(t, err)
+
+	res, err = cs.ListComments(bob, ListCommentsRequest{TaskId: taskId})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Comments)
+	assert.Zero(t, res.Total)
+}
+
+func TestCommentService_ListComments_Paginated(t *testing.T) {
+	testenv.SQLite(t)
+
+	cs := CommentService{}
+	taskId := newTask(t)
+	ctx := asUser("alice@example.com")
+
+	for _, body := range []string{"1", "2", "3", "4", "5"} {
+		_, err := cs.SaveComment(ctx, SaveCommentRequest{TaskId: taskId, Comment: model.Comment{Body: body}})
+		assert.NoError(t, err)
+	}
+
+	res, err := cs.ListComments(ctx, ListCommentsRequest{TaskId: taskId, Page: 2, Size: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), res.Total)
+	assert.Equal(t, 2, res.Page)
+	if assert.Len(t, res.Comments, 2) {
+		assert.Equal(t, "3", res.Comments[0].Body)
+		assert.Equal(t, "4", res.Comments[1].Body)
+	}
+
+	res, err = cs.ListComments(ctx, ListCommentsRequest{TaskId: taskId, Page: 3, Size: 2})
+	assert.NoError(t, err)
+	assert.Len(t, res.Comments, 1)
+
+	_, err = cs.ListComments(ctx, ListCommentsRequest{TaskId: taskId, Size: MaxPageSize + 1})
+	assert.Equal(t, errs.BadRequest, err)
+
+	// los comentarios se eliminan junto con su tarea
+	assert.NoError(t, dao.NewTaskDAO().Delete(context.TODO(), taskId))
+	_, total, err := dao.NewTaskCommentDAO().FindByTask(context.TODO(), taskId, 0, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, total)
+}
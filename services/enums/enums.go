@@ -1,9 +0,0 @@
-package enums
-
-const (
-
-	// Tasks Status
-	PendingTaskStatus    string = "PENDING"
-	InProgressTaskStatus string = "IN_PROGRESS"
-	CompletedTaskStatus  string = "COMPLETED"
-)
@@ -0,0 +1,217 @@
+package label
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gopkg.in/dealancer/validate.v2"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// LabelService contiene los métodos relacionados con las etiquetas.
+type LabelService struct{}
+
+// FindAllLabelsResponse es la respuesta para FindAllLabels.
+type FindAllLabelsResponse struct {
+	Labels []model.Label `json:"labels"`
+}
+
+// FindAllLabels recupera todas las etiquetas ordenadas por nombre.
+func (ls LabelService) FindAllLabels(ctx context.Context) (FindAllLabelsResponse, error) {
+	log := loggerf.WithField("service", "LabelService").WithField("func", "FindAllLabels")
+
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.FindAllLabels")
+	defer span.End()
+
+	labels, err := dao.NewLabelDAO().FindAll(ctx)
+	if err != nil {
+		log.WithError(err).Error("problems with getting labels")
+		return FindAllLabelsResponse{}, err
+	}
+
+	results := []model.Label{}
+	for _, v := range labels {
+		results = append(results, toLabelModel(v))
+	}
+
+	return FindAllLabelsResponse{Labels: results}, nil
+}
+
+// GetLabelRequest es la solicitud para GetLabel.
+type GetLabelRequest struct {
+	Id int32 `json:"id"`
+}
+
+// GetLabelResponse es la respuesta para GetLabel.
+type GetLabelResponse struct {
+	Label model.Label `json:"label"`
+}
+
+// GetLabel obtiene una etiqueta por su ID.
+func (ls LabelService) GetLabel(ctx context.Context, in GetLabelRequest) (GetLabelResponse, error) {
+	log := loggerf.WithField("service", "LabelService").WithField("func", "GetLabel")
+
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.GetLabel")
+	defer span.End()
+
+	if in.Id == 0 {
+		return GetLabelResponse{}, errs.BadRequest
+	}
+
+	v, err := dao.NewLabelDAO().Get(ctx, in.Id)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting label")
+		return GetLabelResponse{}, err
+	} else if err == gorm.ErrRecordNotFound {
+		return GetLabelResponse{}, errs.LabelNotFound
+	}
+
+	return GetLabelResponse{Label: toLabelModel(v)}, nil
+}
+
+// SaveLabelRequest es la solicitud para SaveLabel.
+type SaveLabelRequest struct {
+	Label model.Label `json:"label"`
+}
+
+// SaveLabelResponse es la respuesta para SaveLabel.
+type SaveLabelResponse struct {
+	Id int32 `json:"id"`
+}
+
+// SaveLabel crea una etiqueta; los nombres son únicos.
+func (ls LabelService) SaveLabel(ctx context.Context, in SaveLabelRequest) (SaveLabelResponse, error) {
+	log := loggerf.WithField("service", "LabelService").WithField("func", "SaveLabel")
+
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.SaveLabel")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return SaveLabelResponse{}, errs.BadRequest
+	}
+
+	labelDAO := dao.NewLabelDAO()
+
+	if err := uniqueName(ctx, labelDAO, in.Label.Name, 0); err != nil {
+		return SaveLabelResponse{}, err
+	}
+
+	label := md.Label{Name: in.Label.Name, Color: in.Label.Color}
+	if err := labelDAO.Save(ctx, &label); err != nil {
+		log.WithError(err).Error("problems with saving label")
+		return SaveLabelResponse{}, err
+	}
+
+	return SaveLabelResponse{Id: label.Id}, nil
+}
+
+// UpdateLabelRequest es la solicitud para UpdateLabel.
+type UpdateLabelRequest struct {
+	Label model.Label `json:"label"`
+}
+
+// UpdateLabelResponse es la respuesta para UpdateLabel.
+type UpdateLabelResponse struct{}
+
+// UpdateLabel actualiza el nombre y el color de una etiqueta.
+func (ls LabelService) UpdateLabel(ctx context.Context, in UpdateLabelRequest) (UpdateLabelResponse, error) {
+	log := loggerf.WithField("service", "LabelService").WithField("func", "UpdateLabel")
+
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.UpdateLabel")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil || in.Label.Id == 0 {
+		log.WithError(err).Error("validation problems")
+		return UpdateLabelResponse{}, errs.BadRequest
+	}
+
+	labelDAO := dao.NewLabelDAO()
+
+	_, err := labelDAO.Get(ctx, in.Label.Id)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting label")
+		return UpdateLabelResponse{}, err
+	} else if err == gorm.ErrRecordNotFound {
+		return UpdateLabelResponse{}, errs.LabelNotFound
+	}
+
+	if err := uniqueName(ctx, labelDAO, in.Label.Name, in.Label.Id); err != nil {
+		return UpdateLabelResponse{}, err
+	}
+
+	err = labelDAO.Update(ctx, md.Label{Id: in.Label.Id, Name: in.Label.Name, Color: in.Label.Color})
+	if err != nil {
+		return UpdateLabelResponse{}, err
+	}
+
+	return UpdateLabelResponse{}, nil
+}
+
+// DeleteLabelRequest es la solicitud para DeleteLabel.
+type DeleteLabelRequest struct {
+	Id int32 `json:"id"`
+}
+
+// DeleteLabelResponse es la respuesta para DeleteLabel.
+type DeleteLabelResponse struct{}
+
+// DeleteLabel elimina una etiqueta y la quita de las tareas que la usan.
+func (ls LabelService) DeleteLabel(ctx context.Context, in DeleteLabelRequest) (DeleteLabelResponse, error) {
+	log := loggerf.WithField("service", "LabelService").WithField("func", "DeleteLabel")
+
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.DeleteLabel")
+	defer span.End()
+
+	if in.Id == 0 {
+		return DeleteLabelResponse{}, errs.BadRequest
+	}
+
+	labelDAO := dao.NewLabelDAO()
+
+	_, err := labelDAO.Get(ctx, in.Id)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting label")
+		return DeleteLabelResponse{}, err
+	} else if err == gorm.ErrRecordNotFound {
+		return DeleteLabelResponse{}, errs.LabelNotFound
+	}
+
+	if err := labelDAO.Delete(ctx, in.Id); err != nil {
+		return DeleteLabelResponse{}, err
+	}
+
+	return DeleteLabelResponse{}, nil
+}
+
+// uniqueName comprueba que ninguna otra etiqueta distinta de id use name.
+func uniqueName(ctx context.Context, labelDAO dao.LabelDAO, name string, id int32) error {
+
+	existing, err := labelDAO.GetByNames(ctx, []string{name})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range existing {
+		if l.Id != id {
+			return errs.LabelAlreadyExists
+		}
+	}
+
+	return nil
+}
+
+func toLabelModel(v md.Label) model.Label {
+	return model.Label{
+		Id:    v.Id,
+		Name:  v.Name,
+		Color: v.Color,
+	}
+}
@@ -0,0 +1,40 @@
+package label
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelService_CRUD(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ls := LabelService{}
+
+	saved, err := ls.SaveLabel(ctx, SaveLabelRequest{Label: model.Label{Name: "trabajo", Color: "#ff0000"}})
+	assert.NoError(t, err)
+	assert.NotZero(t, saved.Id)
+
+	_, err = ls.SaveLabel(ctx, SaveLabelRequest{Label: model.Label{Name: "trabajo"}})
+	assert.Equal(t, errs.LabelAlreadyExists, err)
+
+	_, err = ls.UpdateLabel(ctx, UpdateLabelRequest{Label: model.Label{Id: saved.Id, Name: "oficina"}})
+	assert.NoError(t, err)
+
+	got, err := ls.GetLabel(ctx, GetLabelRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "oficina", got.Label.Name)
+	assert.Equal(t, "#ff0000", got.Label.Color)
+
+	_, err = ls.DeleteLabel(ctx, DeleteLabelRequest{Id: saved.Id})
+	assert.NoError(t, err)
+
+	_, err = ls.GetLabel(ctx, GetLabelRequest{Id: saved.Id})
+	assert.Equal(t, errs.LabelNotFound, err)
+}
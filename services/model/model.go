@@ -1,11 +1,14 @@
 package model
 
+import "encoding/json"
+
 // User ...
 type User struct {
-	FullName string `json:"fullName,omitempty"`
-	Email    string `json:"email,omitempty"`
-	Lock     bool   `json:"lock,omitempty"`
-	Roles    []Role `json:"roles,omitempty"`
+	FullName   string `json:"fullName,omitempty"`
+	Email      string `json:"email,omitempty"`
+	CenterCode string `json:"centerCode,omitempty"`
+	Lock       bool   `json:"lock,omitempty"`
+	Roles      []Role `json:"roles,omitempty"`
 }
 
 // Role ...
@@ -24,7 +27,10 @@ type Permission struct {
 // AuthenticatedUser ...
 type AuthenticatedUser struct {
 	Email string
-	Roles []Role
+	// CenterCode es el centro del usuario, sus tareas son solo las de ese
+	// centro.
+	CenterCode string
+	Roles      []Role
 }
 
 type Task struct {
@@ -33,4 +39,86 @@ type Task struct {
 	Description string `json:"description" validate:"empty=false"`
 	DueDate     string `json:"due_date,omitempty"`
 	State       string `json:"state" validate:"empty=false"`
+	Priority    string `json:"priority,omitempty"`
+	ParentId    int32  `json:"parent_id,omitempty"`
+	Recurrence  string `json:"recurrence,omitempty"`
+	// Owner es el usuario que creó la tarea; se ignora en las solicitudes.
+	Owner string `json:"owner,omitempty"`
+	// CenterCode es el centro del usuario que creó la tarea; se ignora en
+	// las solicitudes.
+	CenterCode string `json:"centerCode,omitempty"`
+	// Overdue se calcula en las respuestas y se ignora en las solicitudes.
+	Overdue bool     `json:"overdue"`
+	Labels  []string `json:"labels,omitempty"`
+}
+
+type Label struct {
+	Id    int32  `json:"id,omitempty"`
+	Name  string `json:"name" validate:"empty=false"`
+	Color string `json:"color,omitempty"`
+}
+
+// TaskEvent es un cambio registrado de una tarea.
+type TaskEvent struct {
+	Seq        int64           `json:"seq"`
+	Type       string          `json:"type"`
+	OccurredAt string          `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Comment es un comentario de una tarea. Author y las fechas se asignan en
+// el servicio y se ignoran en las solicitudes.
+type Comment struct {
+	Id        int32  `json:"id,omitempty"`
+	TaskId    int32  `json:"taskId,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Body      string `json:"body" validate:"empty=false"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Attachment son los datos de un archivo adjunto a una tarea.
+type Attachment struct {
+	Id          int32  `json:"id"`
+	TaskId      int32  `json:"taskId"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	UploadedBy  string `json:"uploadedBy,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// Webhook es una suscripción a los eventos de tareas. Events vacío recibe
+// todos los tipos; Secret solo se devuelve al crearla.
+type Webhook struct {
+	Id       int32    `json:"id,omitempty"`
+	Url      string   `json:"url" validate:"empty=false"`
+	Secret   string   `json:"secret,omitempty"`
+	Events   []string `json:"events,omitempty"`
+	Disabled bool     `json:"disabled"`
+}
+
+// ApiKey es la clave de un servicio que llama a la API sin iniciar sesión.
+// La clave completa solo se devuelve al crearla; Prefix es su parte pública.
+type ApiKey struct {
+	Id         int32    `json:"id,omitempty"`
+	Name       string   `json:"name" validate:"empty=false"`
+	Prefix     string   `json:"prefix,omitempty"`
+	Scopes     []string `json:"scopes" validate:"empty=false"`
+	CreatedBy  string   `json:"createdBy,omitempty"`
+	CreatedAt  string   `json:"createdAt,omitempty"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	RevokedAt  string   `json:"revokedAt,omitempty"`
+}
+
+type WebhookDelivery struct {
+	Id         int64  `json:"id"`
+	EventId    string `json:"eventId"`
+	EventType  string `json:"eventType"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Success    bool   `json:"success"`
+	DurationMs int64  `json:"durationMs"`
+	CreatedAt  string `json:"createdAt"`
 }
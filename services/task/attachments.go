@@ -0,0 +1,39 @@
+package task
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/storage"
+)
+
+// attachmentKeys obtiene las claves del contenido de los adjuntos de una
+// tarea, que deben leerse antes de eliminarla.
+func attachmentKeys(ctx context.Context, id int32) ([]string, error) {
+
+	attachments, err := dao.NewTaskAttachmentDAO().FindByTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		keys = append(keys, a.StorageKey)
+	}
+
+	return keys, nil
+}
+
+// removeAttachments elimina del almacenamiento el contenido de los adjuntos de
+// una tarea ya eliminada; los fallos solo dejan huérfanos y se registran.
+func removeAttachments(ctx context.Context, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	blobs := storage.Default()
+	for _, key := range keys {
+		if err := blobs.Delete(ctx, key); err != nil {
+			loggerf.WithError(err).WithField("key", key).Error("problems with removing attachment content")
+		}
+	}
+}
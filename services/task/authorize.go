@@ -0,0 +1,40 @@
+package task
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+)
+
+// owner devuelve el email del usuario de la solicitud, vacío sin usuario.
+func owner(ctx context.Context) string {
+	if au, ok := security.UserFromContext(ctx); ok {
+		return au.Email
+	}
+	return ""
+}
+
+// authorizeTask comprueba las reglas de atributos de act sobre la tarea ya
+// cargada. Las llamadas sin usuario, como taskctl sobre la base de datos y
+// los procesos internos, no se comprueban.
+func authorizeTask(ctx context.Context, act string, t md.Task) error {
+	au, ok := security.UserFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if !security.IsAuthorizedTask(au, act, security.TaskObject{Owner: t.Owner, State: t.State}) {
+		return errs.TaskForbidden
+	}
+	return nil
+}
+
+// authorizeCheck comprueba act sobre cada tarea de una operación masiva,
+// leída con bloqueo dentro de la transacción que la modifica.
+func authorizeCheck(ctx context.Context, act string) dao.TaskCheck {
+	return func(t md.Task) error {
+		return authorizeTask(ctx, act, t)
+	}
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func asRole(email, role string) context.Context {
+	return as(model.AuthenticatedUser{Email: email, CenterCode: "C1", Roles: []model.Role{{Code: role}}})
+}
+
+func as(au model.AuthenticatedUser) context.Context {
+	testenv.BasePath()
+	return security.WithUser(context.TODO(), au)
+}
+
+func TestTaskService_AttributeRules(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ts := TaskService{}
+	admin, ana, bob := asRole("admin@example.com", "ROL_1"), asRole("ana@example.com", "ROL_2"), asRole("bob@example.com", "ROL_2")
+
+	newTask := func(ctx context.Context, title string) model.Task {
+		saved, err := ts.SaveTask(ctx, SaveTaskRequest{Task: model.Task{Title: title, Description: title, DueDate: "2030-01-01T10:00:00", State: "PENDING"}})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		got, _ := ts.GetTask(ctx, GetTaskRequest{Id: saved.Id})
+		return got.Task
+	}
+
+	own := newTask(ana, "own")
+	assert.Equal(t, "ana@example.com", own.Owner)
+
+	// ROL_2 updates its own tasks only
+	own.Title = "own, edited"
+	_, err := ts.UpdateTask(ana, UpdateTaskRequest{Task: own})
+	assert.NoError(t, err)
+	_, err = ts.UpdateTask(bob, UpdateTaskRequest{Task: own})
+	assert.Equal(t, errs.TaskForbidden, err)
+
+	res, err := ts.BulkUpdateTasks(bob, BulkUpdateTasksRequest{Ids: []int32{own.Id}, State: "IN_PROGRESS"})
+	assert.NoError(t, err)
+	assert.Equal(t, errs.TaskForbidden.InternalCode, res.Results[0].Error.InternalCode)
+
+	// ROL_2 deletes nothing and nobody deletes a completed task
+	_, err = ts.DeleteTask(ana, DeleteTaskRequest{Id: own.Id})
+	assert.Equal(t, errs.TaskForbidden, err)
+
+	done := newTask(admin, "done")
+	// keeps the highest id, so that sqlite does not reuse the deleted ones
+	newTask(admin, "kept")
+	_, err = ts.BulkUpdateTasks(admin, BulkUpdateTasksRequest{Ids: []int32{done.Id}, State: "COMPLETED"})
+	assert.NoError(t, err)
+	_, err = ts.DeleteTask(admin, DeleteTaskRequest{Id: done.Id})
+	assert.Equal(t, errs.TaskForbidden, err)
+
+	res, err = ts.BulkDeleteTasks(admin, BulkDeleteTasksRequest{Ids: []int32{own.Id, done.Id}, AllOrNothing: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Failed)
+	assert.Equal(t, errs.BulkRolledBack.InternalCode, res.Results[0].Error.InternalCode)
+	assert.Equal(t, errs.TaskForbidden.InternalCode, res.Results[1].Error.InternalCode)
+
+	res, err = ts.BulkDeleteTasks(admin, BulkDeleteTasksRequest{Ids: []int32{own.Id, done.Id}})
+	assert.NoError(t, err)
+	assert.True(t, res.Results[0].Success)
+	assert.False(t, res.Results[1].Success)
+
+	// calls without user, like taskctl on the database, are not checked
+	_, err = ts.DeleteTask(context.TODO(), DeleteTaskRequest{Id: done.Id})
+	assert.NoError(t, err)
+}
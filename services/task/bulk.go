@@ -0,0 +1,328 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gorm.io/gorm"
+)
+
+// MaxBatchSize es el número máximo de elementos aceptados por una operación masiva.
+const MaxBatchSize = 500
+
+// BulkItemResult es el resultado de un elemento de una operación masiva.
+type BulkItemResult struct {
+	Index   int               `json:"index"`
+	Id      int32             `json:"id,omitempty"`
+	Success bool              `json:"success"`
+	Error   *errs.CustomError `json:"error,omitempty"`
+	// NextOccurrenceId es la tarea creada al completar una tarea recurrente.
+	NextOccurrenceId int32 `json:"nextOccurrenceId,omitempty"`
+}
+
+// BulkTasksResponse es la respuesta de las operaciones masivas.
+type BulkTasksResponse struct {
+	Results   []BulkItemResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// BulkSaveTasksRequest es la solicitud para BulkSaveTasks.
+type BulkSaveTasksRequest struct {
+	Tasks        []model.Task `json:"tasks"`
+	AllOrNothing bool         `json:"allOrNothing"`
+}
+
+// BulkUpdateTasksRequest es la solicitud para BulkUpdateTasks.
+type BulkUpdateTasksRequest struct {
+	Ids          []int32 `json:"ids"`
+	State        string  `json:"state"`
+	AllOrNothing bool    `json:"allOrNothing"`
+}
+
+// BulkDeleteTasksRequest es la solicitud para BulkDeleteTasks.
+type BulkDeleteTasksRequest struct {
+	Ids          []int32 `json:"ids"`
+	AllOrNothing bool    `json:"allOrNothing"`
+}
+
+// BulkSaveTasks crea varias tareas en una única transacción.
+func (ts TaskService) BulkSaveTasks(ctx context.Context, in BulkSaveTasksRequest) (BulkTasksResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "BulkSaveTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.BulkSaveTasks")
+	defer span.End()
+
+	if err := batchValidate(len(in.Tasks)); err != nil {
+		return BulkTasksResponse{}, err
+	}
+
+	results, err := saveTasks(ctx, in.Tasks, in.AllOrNothing, false)
+	if err != nil {
+		log.WithError(err).Error("problems with saving tasks")
+		return BulkTasksResponse{}, err
+	}
+
+	return summarize(results), nil
+}
+
+// saveTasks valida las tareas con las reglas de SaveTask y guarda las válidas
+// en una única transacción. En modo dryRun no se escribe nada.
+func saveTasks(ctx context.Context, tasks []model.Task, allOrNothing bool, dryRun bool) ([]BulkItemResult, error) {
+
+	results := make([]BulkItemResult, len(tasks))
+	valid := []md.Task{}
+	positions := []int{}
+
+	// Las tareas inválidas se informan sin llegar a la base de datos.
+	for i, t := range tasks {
+		task, err := validateNewTask(ctx, t)
+		if err == nil {
+			task.Labels, err = resolveLabels(ctx, t.Labels)
+		}
+		if err == nil {
+			err = parentValidate(ctx, t.ParentId)
+		}
+		if err != nil {
+			results[i] = failedItem(i, 0, err)
+			continue
+		}
+		valid = append(valid, task)
+		positions = append(positions, i)
+	}
+
+	if allOrNothing && len(valid) < len(tasks) {
+		for _, i := range positions {
+			results[i] = failedItem(i, 0, errs.BulkRolledBack)
+		}
+		return results, nil
+	}
+
+	if dryRun {
+		for _, i := range positions {
+			results[i] = BulkItemResult{Index: i, Success: true}
+		}
+		return results, nil
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	var daoResults []dao.BulkResult
+	err := base.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if daoResults, err = dao.NewTaskDAO().SaveAll(ctx, valid, allOrNothing); err != nil {
+			return err
+		}
+		for k, r := range daoResults {
+			if r.Err != nil {
+				continue
+			}
+			valid[k].Id = r.Id
+			if err := publishTask(ctx, events.TaskCreated, valid[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for k, r := range daoResults {
+		i := positions[k]
+		if r.Err != nil {
+			results[i] = failedItem(i, 0, r.Err)
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, Id: r.Id, Success: true}
+		metrics.TasksCreated.WithLabelValues(valid[k].State).Inc()
+		indexTask(ctx, valid[k])
+	}
+
+	return results, nil
+}
+
+// BulkUpdateTasks cambia el estado de varias tareas en una única transacción.
+func (ts TaskService) BulkUpdateTasks(ctx context.Context, in BulkUpdateTasksRequest) (BulkTasksResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "BulkUpdateTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.BulkUpdateTasks")
+	defer span.End()
+
+	if err := batchValidate(len(in.Ids)); err != nil {
+		return BulkTasksResponse{}, err
+	}
+
+	if err := stateValidate(in.State); err != nil {
+		return BulkTasksResponse{}, err
+	}
+
+	taskDAO := dao.NewTaskDAO()
+
+	// Los cambios, sus eventos y las siguientes ocurrencias se confirman juntos.
+	var daoResults []dao.BulkResult
+	next := make([]md.Task, len(in.Ids))
+	err := base.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		// Las reglas de la tarea se evalúan sobre la fila bloqueada.
+		if daoResults, err = taskDAO.UpdateStateAll(ctx, in.Ids, in.State, in.AllOrNothing, authorizeCheck(ctx, security.ActUpdate)); err != nil {
+			return err
+		}
+		for i, r := range daoResults {
+			if r.Err != nil {
+				continue
+			}
+			updated, err := taskDAO.Get(ctx, in.Ids[i])
+			if err != nil {
+				return err
+			}
+			if err := publishTask(ctx, events.TaskUpdated, updated); err != nil {
+				return err
+			}
+			if r.PreviousState != in.State {
+				if err := publishStateChanged(ctx, r.PreviousState, updated); err != nil {
+					return err
+				}
+			}
+			if in.State == enums.CompletedTaskStatus && r.PreviousState != enums.CompletedTaskStatus {
+				if next[i], err = nextOccurrence(ctx, updated); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("problems with updating tasks")
+		return BulkTasksResponse{}, err
+	}
+
+	results := make([]BulkItemResult, len(in.Ids))
+	for i, r := range daoResults {
+		if r.Err != nil {
+			results[i] = failedItem(i, in.Ids[i], r.Err)
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, Id: in.Ids[i], Success: true}
+		metrics.TasksUpdated.WithLabelValues(in.State).Inc()
+
+		if in.State == enums.CompletedTaskStatus && r.PreviousState != enums.CompletedTaskStatus {
+			metrics.TasksCompleted.Inc()
+		}
+		if next[i].Id != 0 {
+			metrics.TasksCreated.WithLabelValues(next[i].State).Inc()
+			indexTask(ctx, next[i])
+			results[i].NextOccurrenceId = next[i].Id
+		}
+	}
+
+	return summarize(results), nil
+}
+
+// BulkDeleteTasks elimina varias tareas en una única transacción.
+func (ts TaskService) BulkDeleteTasks(ctx context.Context, in BulkDeleteTasksRequest) (BulkTasksResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "BulkDeleteTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.BulkDeleteTasks")
+	defer span.End()
+
+	if err := batchValidate(len(in.Ids)); err != nil {
+		return BulkTasksResponse{}, err
+	}
+
+	var daoResults []dao.BulkResult
+	keys := make([][]string, len(in.Ids))
+	err := base.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		for i, id := range in.Ids {
+			if keys[i], err = attachmentKeys(ctx, id); err != nil {
+				return err
+			}
+		}
+		if daoResults, err = dao.NewTaskDAO().DeleteAll(ctx, in.Ids, in.AllOrNothing, authorizeCheck(ctx, security.ActDelete)); err != nil {
+			return err
+		}
+		for i, r := range daoResults {
+			if r.Err != nil {
+				continue
+			}
+			if err := publishDeleted(ctx, in.Ids[i], r.PreviousState); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("problems with deleting tasks")
+		return BulkTasksResponse{}, err
+	}
+
+	results := make([]BulkItemResult, len(in.Ids))
+	for i, r := range daoResults {
+		if r.Err != nil {
+			results[i] = failedItem(i, in.Ids[i], r.Err)
+			continue
+		}
+		results[i] = BulkItemResult{Index: i, Id: in.Ids[i], Success: true}
+		metrics.TasksDeleted.Inc()
+		unindexTask(ctx, in.Ids[i])
+		removeAttachments(ctx, keys[i])
+	}
+
+	return summarize(results), nil
+}
+
+// batchValidate comprueba el tamaño de una operación masiva.
+func batchValidate(n int) error {
+	if n == 0 {
+		return errs.BadRequest.SetMessage("Empty batch")
+	}
+	if n > MaxBatchSize {
+		return errs.BatchTooLarge.SetMessage(fmt.Sprintf("Batch too large, max %d items", MaxBatchSize))
+	}
+	return nil
+}
+
+// failedItem convierte el error de un elemento en un resultado fallido.
+func failedItem(i int, id int32, err error) BulkItemResult {
+	ce, ok := err.(errs.CustomError)
+	switch {
+	case ok:
+	case err == gorm.ErrRecordNotFound:
+		ce = errs.TasksNotFound
+	case err == dao.ErrBulkRolledBack:
+		ce = errs.BulkRolledBack
+	case err == dao.ErrTaskWithoutCenter:
+		ce = errs.TaskWithoutCenter
+	case err == dao.ErrIncompleteSubtasks, err == dao.ErrTaskBlocked:
+		ce = graphError(err)
+	default:
+		ce = errs.InternalError
+	}
+	return BulkItemResult{Index: i, Id: id, Success: false, Error: &ce}
+}
+
+// summarize cuenta los elementos correctos y fallidos.
+func summarize(results []BulkItemResult) BulkTasksResponse {
+	res := BulkTasksResponse{Results: results}
+	for _, r := range results {
+		if r.Success {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+	return res
+}
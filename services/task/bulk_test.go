@@ -0,0 +1,45 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkSaveTasks_BatchLimits(t *testing.T) {
+
+	_, err := TaskService{}.BulkSaveTasks(context.TODO(), BulkSaveTasksRequest{})
+	assert.Equal(t, errs.BadRequest.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = TaskService{}.BulkSaveTasks(context.TODO(), BulkSaveTasksRequest{Tasks: make([]model.Task, MaxBatchSize+1)})
+	assert.Equal(t, errs.BatchTooLarge.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = TaskService{}.BulkDeleteTasks(context.TODO(), BulkDeleteTasksRequest{Ids: make([]int32, MaxBatchSize+1)})
+	assert.Equal(t, errs.BatchTooLarge.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestBulkSaveTasks_AllOrNothingValidation(t *testing.T) {
+
+	res, err := TaskService{}.BulkSaveTasks(context.TODO(), BulkSaveTasksRequest{
+		AllOrNothing: true,
+		Tasks: []model.Task{
+			{Title: "Ok", Description: "Ok", DueDate: "2023-10-01T10:00:00", State: "PENDING"},
+			{Title: "Bad", Description: "Bad", DueDate: "2023-10-01T10:00:00", State: "UNKNOWN"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Succeeded)
+	assert.Equal(t, 2, res.Failed)
+	assert.Equal(t, errs.BulkRolledBack.InternalCode, res.Results[0].Error.InternalCode)
+	assert.Equal(t, errs.TaskStateInvalid.InternalCode, res.Results[1].Error.InternalCode)
+}
+
+func TestBulkUpdateTasks_InvalidState(t *testing.T) {
+
+	_, err := TaskService{}.BulkUpdateTasks(context.TODO(), BulkUpdateTasksRequest{Ids: []int32{1}, State: "DONE"})
+	assert.Equal(t, errs.TaskStateInvalid, err)
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+)
+
+// format es el formato heredado, sin zona horaria. Se sigue aceptando como
+// entrada y se interpreta en la zona horaria de la solicitud.
+var format = "2006-01-02T15:04:05"
+
+// DueDateFormat es el formato de las fechas devueltas (RFC 3339).
+const DueDateFormat = time.RFC3339
+
+type locationKey struct{}
+
+// WithLocation devuelve un contexto cuyas fechas se interpretan y se
+// devuelven en loc.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LoadLocation obtiene una zona horaria IANA, p. ej. "America/Santiago".
+func LoadLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		return nil, errs.TimeZoneInvalid.SetMessage("Invalid time zone: " + name)
+	}
+	return loc, nil
+}
+
+// location devuelve la zona horaria de la solicitud, UTC por defecto.
+func location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// parseDueDate acepta RFC 3339 con desplazamiento o el formato heredado en la
+// zona horaria de la solicitud, y devuelve la fecha en UTC.
+func parseDueDate(ctx context.Context, s string) (time.Time, error) {
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t, err = time.ParseInLocation(format, s, location(ctx))
+	}
+	if err != nil {
+		return time.Time{}, errs.BadRequest.SetMessage("Invalid date, use RFC 3339 (2006-01-02T15:04:05Z07:00)")
+	}
+
+	return t.UTC(), nil
+}
+
+// formatDueDate devuelve la fecha en la zona horaria de la solicitud.
+func formatDueDate(ctx context.Context, t time.Time) string {
+	return t.In(location(ctx)).Format(DueDateFormat)
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDueDate(t *testing.T) {
+
+	santiago, err := LoadLocation("America/Santiago")
+	assert.NoError(t, err)
+	ctx := WithLocation(context.TODO(), santiago)
+
+	// RFC 3339 ignora la zona de la solicitud
+	d, err := parseDueDate(ctx, "2023-10-01T10:00:00+02:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 10, 1, 8, 0, 0, 0, time.UTC), d)
+
+	// el formato heredado se interpreta en la zona de la solicitud (UTC-3)
+	d, err = parseDueDate(ctx, "2023-10-01T10:00:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 10, 1, 13, 0, 0, 0, time.UTC), d)
+
+	d, err = parseDueDate(context.TODO(), "2023-10-01T10:00:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 10, 1, 10, 0, 0, 0, time.UTC), d)
+
+	_, err = parseDueDate(ctx, "01/10/2023")
+	assert.Equal(t, errs.BadRequest.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = LoadLocation("Mars/Olympus")
+	assert.Equal(t, errs.TimeZoneInvalid.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestGetTask_RendersInRequestedZone(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	saved, err := TaskService{}.SaveTask(inCenter("C1"), SaveTaskRequest{Task: model.Task{
+		Title: "Llamada", Description: "Llamada", DueDate: "2023-10-01T23:30:00-03:00", State: "PENDING",
+	}})
+	assert.NoError(t, err)
+
+	res, err := TaskService{}.GetTask(context.TODO(), GetTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-10-02T02:30:00Z", res.Task.DueDate)
+
+	madrid, _ := LoadLocation("Europe/Madrid")
+	res, err = TaskService{}.GetTask(WithLocation(context.TODO(), madrid), GetTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-10-02T04:30:00+02:00", res.Task.DueDate)
+}
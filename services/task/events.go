@@ -0,0 +1,81 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+)
+
+// StateChange es el contenido de los eventos task.state_changed.
+type StateChange struct {
+	From string     `json:"from"`
+	To   string     `json:"to"`
+	Task model.Task `json:"task"`
+}
+
+// publishTask registra un evento con la tarea como contenido. Las fechas de
+// los eventos siempre van en UTC.
+func publishTask(ctx context.Context, eventType string, t md.Task) error {
+	return publish(ctx, eventType, t.Id, toTaskModel(WithLocation(ctx, time.UTC), t))
+}
+
+// publishStateChanged registra el paso de una tarea desde el estado from.
+func publishStateChanged(ctx context.Context, from string, t md.Task) error {
+	return publish(ctx, events.TaskStateChanged, t.Id, StateChange{
+		From: from,
+		To:   t.State,
+		Task: toTaskModel(WithLocation(ctx, time.UTC), t),
+	})
+}
+
+// Deleted es el contenido de los eventos task.deleted.
+type Deleted struct {
+	Id    int32  `json:"id"`
+	State string `json:"state"`
+	// CenterCode es el centro de la tarea, el del usuario que la eliminó.
+	CenterCode string `json:"centerCode,omitempty"`
+}
+
+// publishDeleted registra la eliminación de una tarea que estaba en state.
+func publishDeleted(ctx context.Context, id int32, state string) error {
+	center, _ := base.Tenant(ctx)
+	return publish(ctx, events.TaskDeleted, id, Deleted{Id: id, State: state, CenterCode: center})
+}
+
+// publish escribe el evento en el outbox y en el historial de la tarea. Debe
+// llamarse dentro de la transacción del cambio, así el evento existe si y
+// solo si el cambio se confirma; el relay lo entrega después a los destinos
+// configurados.
+func publish(ctx context.Context, eventType string, taskId int32, data interface{}) error {
+
+	e, err := events.New(eventType, taskId, data)
+	if err != nil {
+		loggerf.WithError(err).WithField("type", eventType).Error("problems with building event")
+		return err
+	}
+
+	row := md.OutboxEvent{
+		EventId:    e.Id,
+		Type:       e.Type,
+		TaskId:     e.TaskId,
+		Payload:    string(e.Data),
+		OccurredAt: e.OccurredAt,
+	}
+	if err := dao.NewOutboxDAO().Save(ctx, &row); err != nil {
+		return err
+	}
+
+	// el historial se guarda aparte porque el outbox borra los eventos publicados
+	return dao.NewTaskEventDAO().Save(ctx, &md.TaskEvent{
+		EventId:    row.EventId,
+		Type:       row.Type,
+		TaskId:     row.TaskId,
+		Payload:    row.Payload,
+		OccurredAt: row.OccurredAt,
+	})
+}
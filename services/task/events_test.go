@@ -0,0 +1,132 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// outboxOf obtiene los eventos pendientes de la tarea id, en orden.
+func outboxOf(t *testing.T, id int32) []md.OutboxEvent {
+	pending, err := dao.NewOutboxDAO().FindPending(context.TODO(), 10000)
+	assert.NoError(t, err)
+
+	out := []md.OutboxEvent{}
+	for _, e := range pending {
+		if e.TaskId == id {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestTaskService_WritesLifecycleEventsToOutbox(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	id := saveGraphTask(t, "Publicar", 0)
+	assert.NoError(t, complete(id))
+	_, err := TaskService{}.DeleteTask(context.TODO(), DeleteTaskRequest{Id: id})
+	assert.NoError(t, err)
+
+	types := []string{}
+	for _, e := range outboxOf(t, id) {
+		types = append(types, e.Type)
+
+		if e.Type == events.TaskStateChanged {
+			change := StateChange{}
+			assert.NoError(t, json.Unmarshal([]byte(e.Payload), &change))
+			assert.Equal(t, "PENDING", change.From)
+			assert.Equal(t, "COMPLETED", change.To)
+			assert.Equal(t, "2023-10-01T10:00:00Z", change.Task.DueDate)
+		}
+	}
+	assert.Equal(t, []string{events.TaskCreated, events.TaskUpdated, events.TaskStateChanged, events.TaskDeleted}, types)
+}
+
+func TestTaskService_FailedChangeWritesNoEvent(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	id := saveGraphTask(t, "Atómica", 0)
+	before := len(outboxOf(t, id))
+
+	// la etiqueta no existe: el cambio se rechaza y no deja eventos
+	_, err := TaskService{}.UpdateTask(context.TODO(), UpdateTaskRequest{Task: model.Task{
+		Id: id, Title: "Atómica", Description: "Atómica", DueDate: "2023-10-01T10:00:00", State: "COMPLETED",
+		Labels: []string{"no-existe"},
+	}})
+	assert.Error(t, err)
+	assert.Len(t, outboxOf(t, id), before)
+
+	// un error dentro de la transacción deshace también el evento ya escrito
+	err = base.Transaction(context.TODO(), func(ctx context.Context) error {
+		if err := publishDeleted(ctx, id, "PENDING"); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.Equal(t, assert.AnError, err)
+	assert.Len(t, outboxOf(t, id), before)
+}
+
+func TestTaskService_GetTaskHistory(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	id := saveGraphTask(t, "Historial", 0)
+	assert.NoError(t, complete(id))
+
+	res, err := TaskService{}.GetTaskHistory(context.TODO(), GetTaskHistoryRequest{Id: id, Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Events, 2) {
+		// del más reciente al más antiguo
+		assert.Equal(t, events.TaskStateChanged, res.Events[0].Type)
+		assert.Equal(t, events.TaskUpdated, res.Events[1].Type)
+		assert.Greater(t, res.Events[0].Seq, res.Events[1].Seq)
+	}
+
+	// el historial sigue cuando el outbox ya borró los eventos
+	all, err := TaskService{}.GetTaskHistory(context.TODO(), GetTaskHistoryRequest{Id: id})
+	assert.NoError(t, err)
+	assert.NoError(t, base.DB(context.TODO()).Where("task_id = ?", id).Delete(&md.OutboxEvent{}).Error)
+	kept, err := TaskService{}.GetTaskHistory(context.TODO(), GetTaskHistoryRequest{Id: id})
+	assert.NoError(t, err)
+	assert.Equal(t, all.Events, kept.Events)
+	assert.Len(t, kept.Events, 3)
+
+	_, err = TaskService{}.GetTaskHistory(context.TODO(), GetTaskHistoryRequest{Id: id, Limit: MaxHistoryLimit + 1})
+	assert.Equal(t, errs.BadRequest, err)
+}
+
+func TestTaskService_FindAllTasks_Paginated(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	for _, title := range []string{"Página 1", "Página 2", "Página 3"} {
+		saveGraphTask(t, title, 0)
+	}
+
+	all, err := TaskService{}.FindAllTasks(context.TODO(), FindAllTasksRequest{})
+	assert.NoError(t, err)
+	assert.Zero(t, all.Total)
+
+	res, err := TaskService{}.FindAllTasks(context.TODO(), FindAllTasksRequest{Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(all.Tasks)), res.Total)
+	if assert.Len(t, res.Tasks, 2) {
+		assert.Equal(t, all.Tasks[1].Id, res.Tasks[0].Id)
+	}
+
+	_, err = TaskService{}.FindAllTasks(context.TODO(), FindAllTasksRequest{Limit: MaxFindLimit + 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,254 @@
+package task
+
+import (
+	"context"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gorm.io/gorm"
+)
+
+// SetParentRequest es la solicitud para SetParent. ParentId 0 convierte la
+// tarea en una tarea de primer nivel.
+type SetParentRequest struct {
+	Id       int32 `json:"id"`
+	ParentId int32 `json:"parent_id"`
+}
+
+// SetParentResponse es la respuesta para SetParent.
+type SetParentResponse struct{}
+
+// SetParent convierte una tarea en subtarea de otra, sin permitir ciclos.
+func (ts TaskService) SetParent(ctx context.Context, in SetParentRequest) (SetParentResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "SetParent")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.SetParent")
+	defer span.End()
+
+	if in.Id == 0 {
+		return SetParentResponse{}, errs.BadRequest
+	}
+
+	if err := Exists(ctx, in.Id); err != nil {
+		return SetParentResponse{}, err
+	}
+
+	err := dao.NewTaskDAO().SetParent(ctx, in.Id, parentId(in.ParentId))
+	if err != nil {
+		log.WithError(err).Error("problems with setting parent")
+		return SetParentResponse{}, graphError(err)
+	}
+
+	return SetParentResponse{}, nil
+}
+
+// AddBlockerRequest es la solicitud para AddBlocker.
+type AddBlockerRequest struct {
+	Id        int32 `json:"id"`
+	BlockerId int32 `json:"blocker_id"`
+}
+
+// AddBlockerResponse es la respuesta para AddBlocker.
+type AddBlockerResponse struct{}
+
+// AddBlocker indica que la tarea BlockerId bloquea a la tarea Id, sin permitir ciclos.
+func (ts TaskService) AddBlocker(ctx context.Context, in AddBlockerRequest) (AddBlockerResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "AddBlocker")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.AddBlocker")
+	defer span.End()
+
+	if in.Id == 0 || in.BlockerId == 0 {
+		return AddBlockerResponse{}, errs.BadRequest
+	}
+
+	for _, id := range []int32{in.Id, in.BlockerId} {
+		if err := Exists(ctx, id); err != nil {
+			return AddBlockerResponse{}, err
+		}
+	}
+
+	if err := dao.NewTaskDAO().AddBlocker(ctx, in.Id, in.BlockerId); err != nil {
+		log.WithError(err).Error("problems with adding blocker")
+		return AddBlockerResponse{}, graphError(err)
+	}
+
+	return AddBlockerResponse{}, nil
+}
+
+// RemoveBlockerRequest es la solicitud para RemoveBlocker.
+type RemoveBlockerRequest struct {
+	Id        int32 `json:"id"`
+	BlockerId int32 `json:"blocker_id"`
+}
+
+// RemoveBlockerResponse es la respuesta para RemoveBlocker.
+type RemoveBlockerResponse struct{}
+
+// RemoveBlocker elimina el bloqueo de la tarea BlockerId sobre la tarea Id.
+func (ts TaskService) RemoveBlocker(ctx context.Context, in RemoveBlockerRequest) (RemoveBlockerResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "RemoveBlocker")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.RemoveBlocker")
+	defer span.End()
+
+	if in.Id == 0 || in.BlockerId == 0 {
+		return RemoveBlockerResponse{}, errs.BadRequest
+	}
+
+	if err := Exists(ctx, in.Id); err != nil {
+		return RemoveBlockerResponse{}, err
+	}
+
+	err := dao.NewTaskDAO().RemoveBlocker(ctx, in.Id, in.BlockerId)
+	if err == gorm.ErrRecordNotFound {
+		return RemoveBlockerResponse{}, errs.NotFound.SetMessage("Dependency not found")
+	} else if err != nil {
+		log.WithError(err).Error("problems with removing blocker")
+		return RemoveBlockerResponse{}, err
+	}
+
+	return RemoveBlockerResponse{}, nil
+}
+
+// GetTaskGraphRequest es la solicitud para GetTaskGraph.
+type GetTaskGraphRequest struct {
+	Id int32 `json:"id"`
+}
+
+// GetTaskGraphResponse es la respuesta para GetTaskGraph: la tarea, su tarea
+// padre, sus subtareas, las tareas que la bloquean y las que bloquea.
+type GetTaskGraphResponse struct {
+	Task     model.Task   `json:"task"`
+	Parent   *model.Task  `json:"parent,omitempty"`
+	Subtasks []model.Task `json:"subtasks"`
+	Blockers []model.Task `json:"blockers"`
+	Blocking []model.Task `json:"blocking"`
+}
+
+// GetTaskGraph obtiene las relaciones directas de una tarea.
+func (ts TaskService) GetTaskGraph(ctx context.Context, in GetTaskGraphRequest) (GetTaskGraphResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "GetTaskGraph")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.GetTaskGraph")
+	defer span.End()
+
+	if in.Id == 0 {
+		return GetTaskGraphResponse{}, errs.BadRequest
+	}
+
+	taskDAO := dao.NewTaskDAO()
+
+	v, err := taskDAO.Get(ctx, in.Id)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting task")
+		return GetTaskGraphResponse{}, err
+	} else if err == gorm.ErrRecordNotFound {
+		return GetTaskGraphResponse{}, errs.TasksNotFound
+	}
+
+	res := GetTaskGraphResponse{Task: toTaskModel(ctx, v)}
+
+	if v.ParentId != nil {
+		if parent, err := taskDAO.Get(ctx, *v.ParentId); err == nil {
+			p := toTaskModel(ctx, parent)
+			res.Parent = &p
+		}
+	}
+
+	children, err := taskDAO.Children(ctx, in.Id)
+	if err != nil {
+		return GetTaskGraphResponse{}, err
+	}
+	blockers, err := taskDAO.Blockers(ctx, in.Id)
+	if err != nil {
+		return GetTaskGraphResponse{}, err
+	}
+	blocking, err := taskDAO.Blocking(ctx, in.Id)
+	if err != nil {
+		return GetTaskGraphResponse{}, err
+	}
+
+	res.Subtasks = make([]model.Task, 0, len(children))
+	for _, t := range children {
+		res.Subtasks = append(res.Subtasks, toTaskModel(ctx, t))
+	}
+	res.Blockers = make([]model.Task, 0, len(blockers))
+	for _, t := range blockers {
+		res.Blockers = append(res.Blockers, toTaskModel(ctx, t))
+	}
+	res.Blocking = make([]model.Task, 0, len(blocking))
+	for _, t := range blocking {
+		res.Blocking = append(res.Blocking, toTaskModel(ctx, t))
+	}
+
+	return res, nil
+}
+
+// completeValidate comprueba que la tarea no tenga subtareas ni bloqueos sin
+// completar; dentro de una transacción los bloquea hasta que termine.
+func completeValidate(ctx context.Context, id int32) error {
+
+	switch err := dao.NewTaskDAO().Completable(ctx, id); err {
+	case nil:
+		return nil
+	case dao.ErrIncompleteSubtasks, dao.ErrTaskBlocked:
+		return graphError(err)
+	default:
+		return err
+	}
+}
+
+// parentValidate comprueba que exista la tarea padre de una nueva tarea.
+func parentValidate(ctx context.Context, id int32) error {
+
+	if id == 0 {
+		return nil
+	}
+
+	if err := Exists(ctx, id); err != nil {
+		return errs.TasksNotFound.SetMessage("Parent task not found")
+	}
+
+	return nil
+}
+
+// Exists devuelve TasksNotFound si no existe la tarea en el centro del
+// contexto. Lo usan también los servicios de comentarios y adjuntos.
+func Exists(ctx context.Context, id int32) error {
+
+	_, err := dao.NewTaskDAO().Get(ctx, id)
+	if err == gorm.ErrRecordNotFound {
+		return errs.TasksNotFound
+	}
+
+	return err
+}
+
+// parentId convierte el id de la tarea padre a la columna nullable, 0 es nulo.
+func parentId(id int32) *int32 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// graphError traduce los errores del grafo de tareas del DAO.
+func graphError(err error) errs.CustomError {
+	switch err {
+	case dao.ErrTaskCycle:
+		return errs.TaskDependencyCycle
+	case dao.ErrIncompleteSubtasks:
+		return errs.TaskHasIncompleteSubtasks
+	case dao.ErrTaskBlocked:
+		return errs.TaskBlocked
+	case gorm.ErrRecordNotFound:
+		return errs.TasksNotFound
+	}
+	if ce, ok := err.(errs.CustomError); ok {
+		return ce
+	}
+	return errs.InternalError
+}
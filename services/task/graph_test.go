@@ -0,0 +1,116 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func saveGraphTask(t *testing.T, title string, parentId int32) int32 {
+	res, err := TaskService{}.SaveTask(inCenter("C1"), SaveTaskRequest{Task: model.Task{
+		Title: title, Description: title, DueDate: "2023-10-01T10:00:00", State: "PENDING", ParentId: parentId,
+	}})
+	assert.NoError(t, err)
+	return res.Id
+}
+
+func complete(id int32) error {
+	_, err := TaskService{}.UpdateTask(context.TODO(), UpdateTaskRequest{Task: model.Task{
+		Id: id, Title: "Completada", Description: "Completada", DueDate: "2023-10-01T10:00:00", State: "COMPLETED",
+	}})
+	return err
+}
+
+func TestTaskGraph_Subtasks(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ts := TaskService{}
+
+	parent := saveGraphTask(t, "Mudanza", 0)
+	child := saveGraphTask(t, "Embalar", parent)
+	grandchild := saveGraphTask(t, "Comprar cajas", 0)
+
+	_, err := ts.SetParent(ctx, SetParentRequest{Id: grandchild, ParentId: child})
+	assert.NoError(t, err)
+
+	_, err = ts.SetParent(ctx, SetParentRequest{Id: parent, ParentId: grandchild})
+	assert.Equal(t, errs.TaskDependencyCycle, err)
+
+	_, err = ts.SetParent(ctx, SetParentRequest{Id: parent, ParentId: parent})
+	assert.Equal(t, errs.TaskDependencyCycle, err)
+
+	graph, err := ts.GetTaskGraph(ctx, GetTaskGraphRequest{Id: child})
+	assert.NoError(t, err)
+	assert.Equal(t, parent, graph.Parent.Id)
+	assert.Len(t, graph.Subtasks, 1)
+	assert.Equal(t, grandchild, graph.Subtasks[0].Id)
+
+	assert.Equal(t, errs.TaskHasIncompleteSubtasks, complete(parent))
+	assert.NoError(t, complete(grandchild))
+	assert.NoError(t, complete(child))
+	assert.NoError(t, complete(parent))
+}
+
+func TestTaskGraph_Blockers(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ts := TaskService{}
+
+	deploy := saveGraphTask(t, "Desplegar", 0)
+	review := saveGraphTask(t, "Revisar", 0)
+	build := saveGraphTask(t, "Compilar", 0)
+
+	_, err := ts.AddBlocker(ctx, AddBlockerRequest{Id: deploy, BlockerId: review})
+	assert.NoError(t, err)
+	_, err = ts.AddBlocker(ctx, AddBlockerRequest{Id: review, BlockerId: build})
+	assert.NoError(t, err)
+
+	_, err = ts.AddBlocker(ctx, AddBlockerRequest{Id: build, BlockerId: deploy})
+	assert.Equal(t, errs.TaskDependencyCycle, err)
+
+	graph, err := ts.GetTaskGraph(ctx, GetTaskGraphRequest{Id: review})
+	assert.NoError(t, err)
+	assert.Equal(t, build, graph.Blockers[0].Id)
+	assert.Equal(t, deploy, graph.Blocking[0].Id)
+
+	assert.Equal(t, errs.TaskBlocked, complete(deploy))
+
+	// en una misma operación masiva se completan en orden
+	res, err := ts.BulkUpdateTasks(ctx, BulkUpdateTasksRequest{Ids: []int32{build, review, deploy}, State: "COMPLETED", AllOrNothing: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Succeeded)
+
+	_, err = ts.RemoveBlocker(ctx, RemoveBlockerRequest{Id: deploy, BlockerId: review})
+	assert.NoError(t, err)
+	_, err = ts.RemoveBlocker(ctx, RemoveBlockerRequest{Id: deploy, BlockerId: review})
+	assert.Equal(t, errs.NotFound.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestBulkUpdateTasks_Blocked(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+
+	blocked := saveGraphTask(t, "Bloqueada", 0)
+	blocker := saveGraphTask(t, "Bloqueante", 0)
+	_, err := TaskService{}.AddBlocker(ctx, AddBlockerRequest{Id: blocked, BlockerId: blocker})
+	assert.NoError(t, err)
+
+	res, err := TaskService{}.BulkUpdateTasks(ctx, BulkUpdateTasksRequest{Ids: []int32{blocked}, State: "COMPLETED"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, errs.TaskBlocked.InternalCode, res.Results[0].Error.InternalCode)
+
+	_, err = TaskService{}.DeleteTask(ctx, DeleteTaskRequest{Id: blocker})
+	assert.NoError(t, err)
+	assert.NoError(t, complete(blocked))
+}
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+)
+
+// Límites de GetTaskHistory.
+const (
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 100
+)
+
+// GetTaskHistoryRequest es la solicitud para GetTaskHistory.
+type GetTaskHistoryRequest struct {
+	Id    int32 `json:"id"`
+	Limit int   `json:"limit" query:"limit"`
+}
+
+// GetTaskHistoryResponse es la respuesta para GetTaskHistory.
+type GetTaskHistoryResponse struct {
+	Events []model.TaskEvent `json:"events"`
+}
+
+// GetTaskHistory obtiene los últimos eventos de una tarea, del más reciente al
+// más antiguo. El historial no depende de la retención del outbox.
+func (ts TaskService) GetTaskHistory(ctx context.Context, in GetTaskHistoryRequest) (GetTaskHistoryResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "GetTaskHistory")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.GetTaskHistory")
+	defer span.End()
+
+	if in.Id == 0 || in.Limit < 0 || in.Limit > MaxHistoryLimit {
+		return GetTaskHistoryResponse{}, errs.BadRequest
+	}
+	if in.Limit == 0 {
+		in.Limit = DefaultHistoryLimit
+	}
+
+	rows, err := dao.NewTaskEventDAO().FindByTask(ctx, in.Id, in.Limit)
+	if err != nil {
+		log.WithError(err).Error("problems with getting task events")
+		return GetTaskHistoryResponse{}, err
+	}
+
+	res := GetTaskHistoryResponse{Events: []model.TaskEvent{}}
+	for _, r := range rows {
+		res.Events = append(res.Events, model.TaskEvent{
+			Seq:        r.Id,
+			Type:       r.Type,
+			OccurredAt: r.OccurredAt.In(location(ctx)).Format(time.RFC3339),
+			Data:       json.RawMessage(r.Payload),
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAllTasks_PriorityAndLabel(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := inCenter("C1")
+	assert.NoError(t, dao.NewLabelDAO().Save(ctx, &md.Label{Name: "filtro-prioridad"}))
+
+	save := func(title, priority string, labels ...string) int32 {
+		res, err := TaskService{}.SaveTask(ctx, SaveTaskRequest{Task: model.Task{
+			Title: title, Description: title, DueDate: "2023-10-01T10:00:00", State: "PENDING",
+			Priority: priority, Labels: labels,
+		}})
+		assert.NoError(t, err)
+		return res.Id
+	}
+
+	low := save("Baja", "LOW", "filtro-prioridad")
+	urgent := save("Urgente", "URGENT", "filtro-prioridad")
+	save("Sin etiqueta", "URGENT")
+
+	res, err := TaskService{}.FindAllTasks(ctx, FindAllTasksRequest{Label: "filtro-prioridad", Sort: "-priority"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Tasks, 2)
+	assert.Equal(t, urgent, res.Tasks[0].Id)
+	assert.Equal(t, low, res.Tasks[1].Id)
+	assert.Equal(t, []string{"filtro-prioridad"}, res.Tasks[0].Labels)
+
+	res, err = TaskService{}.FindAllTasks(ctx, FindAllTasksRequest{Label: "filtro-prioridad", Priority: "LOW"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Tasks, 1)
+	assert.Equal(t, low, res.Tasks[0].Id)
+
+	_, err = TaskService{}.FindAllTasks(ctx, FindAllTasksRequest{Sort: "title"})
+	assert.Equal(t, errs.BadRequest.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestSaveTask_PriorityAndLabelValidation(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	task := model.Task{Title: "Uno", Description: "Uno", DueDate: "2023-10-01T10:00:00", State: "PENDING"}
+
+	task.Priority = "CRITICAL"
+	_, err := TaskService{}.SaveTask(inCenter("C1"), SaveTaskRequest{Task: task})
+	assert.Equal(t, errs.TaskPriorityInvalid, err)
+
+	task.Priority = ""
+	task.Labels = []string{"no-existe"}
+	_, err = TaskService{}.SaveTask(inCenter("C1"), SaveTaskRequest{Task: task})
+	assert.Equal(t, errs.LabelNotFound.InternalCode, err.(errs.CustomError).InternalCode)
+}
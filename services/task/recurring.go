@@ -0,0 +1,151 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/recurrence"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gorm.io/gorm"
+)
+
+// Límites de ListOccurrences.
+const (
+	DefaultOccurrencesLimit = 10
+	MaxOccurrencesLimit     = 100
+)
+
+// RecurrenceNone en UpdateTask quita la recurrencia de la tarea; una
+// recurrencia vacía la conserva.
+const RecurrenceNone = "NONE"
+
+// ListOccurrencesRequest es la solicitud para ListOccurrences.
+type ListOccurrencesRequest struct {
+	Id    int32 `json:"id"`
+	Limit int   `json:"limit" query:"limit"`
+}
+
+// ListOccurrencesResponse es la respuesta para ListOccurrences.
+type ListOccurrencesResponse struct {
+	Recurrence  string   `json:"recurrence"`
+	Occurrences []string `json:"occurrences"`
+}
+
+// ListOccurrences calcula las próximas fechas de una tarea recurrente sin
+// crear las tareas.
+func (ts TaskService) ListOccurrences(ctx context.Context, in ListOccurrencesRequest) (ListOccurrencesResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "ListOccurrences")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.ListOccurrences")
+	defer span.End()
+
+	if in.Id == 0 || in.Limit < 0 || in.Limit > MaxOccurrencesLimit {
+		return ListOccurrencesResponse{}, errs.BadRequest
+	}
+	if in.Limit == 0 {
+		in.Limit = DefaultOccurrencesLimit
+	}
+
+	v, err := dao.NewTaskDAO().Get(ctx, in.Id)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting task")
+		return ListOccurrencesResponse{}, err
+	} else if err == gorm.ErrRecordNotFound {
+		return ListOccurrencesResponse{}, errs.TasksNotFound
+	}
+
+	res := ListOccurrencesResponse{Recurrence: v.Recurrence, Occurrences: []string{}}
+	if v.Recurrence == "" {
+		return res, nil
+	}
+
+	rule, err := recurrence.Parse(v.Recurrence)
+	if err != nil {
+		log.WithError(err).Error("stored recurrence rule is invalid")
+		return ListOccurrencesResponse{}, errs.TaskRecurrenceInvalid
+	}
+
+	// la regla se evalúa en la zona horaria de la serie y las fechas se
+	// devuelven en la de la solicitud
+	for _, o := range rule.Occurrences(v.DueDate.In(seriesLocation(v)), in.Limit) {
+		res.Occurrences = append(res.Occurrences, formatDueDate(ctx, o))
+	}
+
+	return res, nil
+}
+
+// recurrenceValidate valida una regla de recurrencia y la devuelve normalizada.
+func recurrenceValidate(rule string) (string, error) {
+
+	r, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", errs.TaskRecurrenceInvalid.SetMessage(err.Error())
+	}
+
+	return r.String(), nil
+}
+
+// seriesLocation devuelve la zona horaria guardada al crear la serie de t,
+// así la recurrencia no depende de quién complete la tarea. UTC si la tarea
+// no tiene zona horaria.
+func seriesLocation(t md.Task) *time.Location {
+	if t.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(t.TimeZone)
+	if err != nil {
+		loggerf.WithError(err).WithField("timeZone", t.TimeZone).Error("stored time zone is invalid")
+		return time.UTC
+	}
+	return loc
+}
+
+// nextOccurrence crea la siguiente tarea de la serie de una tarea recurrente
+// recién completada y registra su evento; debe llamarse dentro de la
+// transacción que la completa. Devuelve una tarea sin Id si la tarea no se
+// repite o la serie terminó.
+func nextOccurrence(ctx context.Context, t md.Task) (md.Task, error) {
+
+	if t.Recurrence == "" {
+		return md.Task{}, nil
+	}
+
+	rule, err := recurrence.Parse(t.Recurrence)
+	if err != nil {
+		return md.Task{}, errs.TaskRecurrenceInvalid
+	}
+
+	due, ok := rule.Next(t.DueDate.In(seriesLocation(t)))
+	if !ok {
+		return md.Task{}, nil
+	}
+
+	next := md.Task{
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     due.UTC(),
+		State:       enums.PendingTaskStatus,
+		Priority:    t.Priority,
+		ParentId:    t.ParentId,
+		Recurrence:  rule.Remaining().String(),
+		TimeZone:    t.TimeZone,
+		Owner:       t.Owner,
+		CenterCode:  t.CenterCode,
+		Labels:      t.Labels,
+	}
+
+	if err := dao.NewTaskDAO().Save(ctx, &next); err != nil {
+		return md.Task{}, err
+	}
+
+	if err := publishTask(ctx, events.TaskCreated, next); err != nil {
+		return md.Task{}, err
+	}
+
+	return next, nil
+}
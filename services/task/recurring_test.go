@@ -0,0 +1,164 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurringTask_NextOccurrence(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := inCenter("C1")
+	ts := TaskService{}
+
+	saved, err := ts.SaveTask(ctx, SaveTaskRequest{Task: model.Task{
+		Title: "Informe", Description: "Informe semanal", DueDate: "2023-10-02T09:00:00", State: "PENDING",
+		Priority: "HIGH", Recurrence: "freq=weekly;byday=MO,TH;count=3",
+	}})
+	assert.NoError(t, err)
+
+	occ, err := ts.ListOccurrences(ctx, ListOccurrencesRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=3;BYDAY=MO,TH", occ.Recurrence)
+	assert.Equal(t, []string{"2023-10-05T09:00:00Z", "2023-10-09T09:00:00Z"}, occ.Occurrences)
+
+	res, err := ts.UpdateTask(ctx, UpdateTaskRequest{Task: model.Task{
+		Id: saved.Id, Title: "Informe", Description: "Informe semanal", DueDate: "2023-10-02T09:00:00", State: "COMPLETED",
+	}})
+	assert.NoError(t, err)
+	assert.NotZero(t, res.NextOccurrenceId)
+
+	next, err := ts.GetTask(ctx, GetTaskRequest{Id: res.NextOccurrenceId})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-10-05T09:00:00Z", next.Task.DueDate)
+	assert.Equal(t, "PENDING", next.Task.State)
+	assert.Equal(t, "HIGH", next.Task.Priority)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=2;BYDAY=MO,TH", next.Task.Recurrence)
+
+	bulk, err := ts.BulkUpdateTasks(ctx, BulkUpdateTasksRequest{Ids: []int32{res.NextOccurrenceId}, State: "COMPLETED"})
+	assert.NoError(t, err)
+	last := bulk.Results[0].NextOccurrenceId
+	assert.NotZero(t, last)
+
+	// la última ocurrencia de la serie no genera otra
+	res, err = ts.UpdateTask(ctx, UpdateTaskRequest{Task: model.Task{
+		Id: last, Title: "Informe", Description: "Informe semanal", DueDate: "2023-10-09T09:00:00", State: "COMPLETED",
+	}})
+	assert.NoError(t, err)
+	assert.Zero(t, res.NextOccurrenceId)
+}
+
+func TestRecurringTask_ConcurrentCompletions(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := inCenter("C1")
+	ts := TaskService{}
+
+	saved, err := ts.SaveTask(ctx, SaveTaskRequest{Task: model.Task{
+		Title: "Respaldo", Description: "Respaldo diario", DueDate: "2023-10-02T09:00:00", State: "PENDING", Recurrence: "FREQ=DAILY",
+	}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// varias solicitudes completan la misma ocurrencia: solo una crea la siguiente
+	var wg sync.WaitGroup
+	next := make(chan int32, 8)
+	for i := 0; i < cap(next); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := ts.UpdateTask(ctx, UpdateTaskRequest{Task: model.Task{
+				Id: saved.Id, Title: "Respaldo", Description: "Respaldo diario", DueDate: "2023-10-02T09:00:00", State: "COMPLETED",
+			}})
+			if assert.NoError(t, err) && res.NextOccurrenceId != 0 {
+				next <- res.NextOccurrenceId
+			}
+		}()
+	}
+	wg.Wait()
+	close(next)
+
+	assert.Len(t, next, 1)
+}
+
+func TestSaveTask_InvalidRecurrence(t *testing.T) {
+
+	_, err := TaskService{}.SaveTask(inCenter("C1"), SaveTaskRequest{Task: model.Task{
+		Title: "Uno", Description: "Uno", DueDate: "2023-10-01T10:00:00", State: "PENDING", Recurrence: "FREQ=HOURLY",
+	}})
+
+	assert.Equal(t, errs.TaskRecurrenceInvalid.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestRecurringTask_SeriesTimeZone(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	santiago, _ := LoadLocation("America/Santiago")
+	madrid, _ := LoadLocation("Europe/Madrid")
+	ts := TaskService{}
+
+	// Santiago pasa a horario de verano el 2023-09-03: la serie sigue a las 09:00 de Santiago
+	saved, err := ts.SaveTask(WithLocation(inCenter("C1"), santiago), SaveTaskRequest{Task: model.Task{
+		Title: "Riego", Description: "Riego semanal", DueDate: "2023-08-27T09:00:00", State: "PENDING", Recurrence: "FREQ=WEEKLY",
+	}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// quien consulta o completa desde otra zona no cambia la serie
+	occ, err := ts.ListOccurrences(WithLocation(context.TODO(), madrid), ListOccurrencesRequest{Id: saved.Id, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2023-09-03T14:00:00+02:00"}, occ.Occurrences)
+
+	res, err := ts.UpdateTask(WithLocation(context.TODO(), madrid), UpdateTaskRequest{Task: model.Task{
+		Id: saved.Id, Title: "Riego", Description: "Riego semanal", DueDate: "2023-08-27T13:00:00Z", State: "COMPLETED",
+	}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	next, err := ts.GetTask(context.TODO(), GetTaskRequest{Id: res.NextOccurrenceId})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-09-03T12:00:00Z", next.Task.DueDate)
+}
+
+func TestRecurringTask_Clear(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ts := TaskService{}
+
+	saved, err := ts.SaveTask(inCenter("C1"), SaveTaskRequest{Task: model.Task{
+		Title: "Pago", Description: "Pago mensual", DueDate: "2023-10-31T09:00:00", State: "PENDING", Recurrence: "FREQ=MONTHLY",
+	}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// vacío conserva la recurrencia
+	_, err = ts.UpdateTask(context.TODO(), UpdateTaskRequest{Task: model.Task{
+		Id: saved.Id, Title: "Pago", Description: "Pago único", DueDate: "2023-10-31T09:00:00", State: "PENDING",
+	}})
+	assert.NoError(t, err)
+	got, _ := ts.GetTask(context.TODO(), GetTaskRequest{Id: saved.Id})
+	assert.Equal(t, "FREQ=MONTHLY", got.Task.Recurrence)
+
+	res, err := ts.UpdateTask(context.TODO(), UpdateTaskRequest{Task: model.Task{
+		Id: saved.Id, Title: "Pago", Description: "Pago único", DueDate: "2023-10-31T09:00:00", State: "COMPLETED", Recurrence: RecurrenceNone,
+	}})
+	assert.NoError(t, err)
+	assert.Zero(t, res.NextOccurrenceId)
+
+	got, _ = ts.GetTask(context.TODO(), GetTaskRequest{Id: saved.Id})
+	assert.Equal(t, "", got.Task.Recurrence)
+}
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/search"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+)
+
+// Límites de resultados de SearchTasks.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchTasksRequest es la solicitud para SearchTasks.
+type SearchTasksRequest struct {
+	Q     string `json:"q" query:"q"`
+	Limit int    `json:"limit" query:"limit"`
+}
+
+// SearchResult es una tarea encontrada junto a su relevancia.
+type SearchResult struct {
+	Task  model.Task `json:"task"`
+	Score float64    `json:"score"`
+}
+
+// SearchTasksResponse es la respuesta para SearchTasks.
+type SearchTasksResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// SearchTasks busca por palabras clave en el título y la descripción y
+// devuelve las tareas ordenadas por relevancia.
+func (ts TaskService) SearchTasks(ctx context.Context, in SearchTasksRequest) (SearchTasksResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "SearchTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.SearchTasks")
+	defer span.End()
+
+	if strings.TrimSpace(in.Q) == "" {
+		return SearchTasksResponse{}, errs.BadRequest.SetMessage("Missing search query")
+	}
+
+	if in.Limit <= 0 {
+		in.Limit = DefaultSearchLimit
+	}
+	if in.Limit > MaxSearchLimit {
+		in.Limit = MaxSearchLimit
+	}
+
+	hits, err := search.Default().Search(ctx, in.Q, in.Limit)
+	if err != nil {
+		log.WithError(err).Error("problems with searching tasks")
+		return SearchTasksResponse{}, err
+	}
+
+	ids := make([]int32, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.Id)
+	}
+
+	tasks, err := dao.NewTaskDAO().GetByIds(ctx, ids)
+	if err != nil {
+		log.WithError(err).Error("problems with getting tasks")
+		return SearchTasksResponse{}, err
+	}
+
+	byId := map[int32]md.Task{}
+	for _, t := range tasks {
+		byId[t.Id] = t
+	}
+
+	// Conserva el orden de relevancia del índice.
+	results := []SearchResult{}
+	for _, h := range hits {
+		if t, ok := byId[h.Id]; ok {
+			results = append(results, SearchResult{Task: toTaskModel(ctx, t), Score: h.Score})
+		}
+	}
+
+	return SearchTasksResponse{Results: results}, nil
+}
+
+// indexTask actualiza la tarea en el índice de búsqueda. Un fallo del índice
+// no hace fallar la operación que lo provoca.
+func indexTask(ctx context.Context, t md.Task) {
+	doc := search.Document{Id: t.Id, Title: t.Title, Description: t.Description, CenterCode: t.CenterCode}
+	if err := search.Default().Index(ctx, doc); err != nil {
+		loggerf.WithError(err).WithField("id", t.Id).Error("problems with indexing task")
+	}
+}
+
+// unindexTask quita la tarea del índice de búsqueda.
+func unindexTask(ctx context.Context, id int32) {
+	if err := search.Default().Remove(ctx, id); err != nil {
+		loggerf.WithError(err).WithField("id", id).Error("problems with unindexing task")
+	}
+}
@@ -0,0 +1,33 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTasks_KeepsIndexInSync(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := inCenter("C1")
+
+	saved, err := TaskService{}.SaveTask(ctx, SaveTaskRequest{Task: model.Task{
+		Title: "Migrar servidor", Description: "Migración del servidor de correo", DueDate: "2023-10-01T10:00:00", State: "PENDING",
+	}})
+	assert.NoError(t, err)
+
+	res, err := TaskService{}.SearchTasks(ctx, SearchTasksRequest{Q: "migracion"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Results, 1)
+	assert.Equal(t, saved.Id, res.Results[0].Task.Id)
+
+	_, err = TaskService{}.DeleteTask(ctx, DeleteTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+
+	res, err = TaskService{}.SearchTasks(ctx, SearchTasksRequest{Q: "migracion"})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Results)
+}
@@ -2,37 +2,69 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/Alonso-Arias/test-cleverit/db/base"
 	"github.com/Alonso-Arias/test-cleverit/db/dao"
 	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
 	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
 	"github.com/Alonso-Arias/test-cleverit/log"
-	"github.com/Alonso-Arias/test-cleverit/services/enums"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/security"
 	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
 	"gopkg.in/dealancer/validate.v2"
 	"gorm.io/gorm"
 )
 
 var loggerf = log.LoggerJSON().WithField("package", "services")
 
-var format = "2006-01-02T15:04:05"
-
 // TaskService contiene los métodos relacionados con las tareas.
 type TaskService struct{}
 
-// FindAllTasksResponse es la respuesta para FindAllTasks.
+// FindAllTasksRequest es la solicitud para FindAllTasks. Los filtros vacíos se ignoran.
+type FindAllTasksRequest struct {
+	State    string `json:"state" query:"state"`
+	Priority string `json:"priority" query:"priority"`
+	Label    string `json:"label" query:"label"`
+	DueFrom  string `json:"dueFrom" query:"dueFrom"`
+	DueTo    string `json:"dueTo" query:"dueTo"`
+	// Sort admite id, priority, -priority, due_date y -due_date.
+	Sort string `json:"sort" query:"sort"`
+	// Limit pagina el resultado, 0 devuelve todas las tareas.
+	Limit  int `json:"limit" query:"limit"`
+	Offset int `json:"offset" query:"offset"`
+}
+
+// FindAllTasksResponse es la respuesta para FindAllTasks. Total es el número
+// de tareas que cumplen los filtros y solo se informa al paginar.
 type FindAllTasksResponse struct {
 	Tasks []model.Task `json:"tasks"`
+	Total int64        `json:"total,omitempty"`
 }
 
+// MaxFindLimit es el tamaño máximo de una página de FindAllTasks.
+const MaxFindLimit = 500
+
 // FindAllTasks recupera todas las tareas.
-func (ts TaskService) FindAllTasks(ctx context.Context) (FindAllTasksResponse, error) {
+func (ts TaskService) FindAllTasks(ctx context.Context, in FindAllTasksRequest) (FindAllTasksResponse, error) {
 	log := loggerf.WithField("service", "TaskService").WithField("func", "FindAllTasks")
 
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.FindAllTasks")
+	defer span.End()
+
+	filter, err := taskFilter(ctx, in)
+	if err != nil {
+		log.WithError(err).Error("filter problems")
+		return FindAllTasksResponse{}, err
+	}
+
 	taskDAO := dao.NewTaskDAO()
 
-	tasks, err := taskDAO.FindAll(ctx)
+	tasks, err := taskDAO.Find(ctx, filter)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		log.WithError(err).Error("problems with getting tasks")
 		return FindAllTasksResponse{}, err
@@ -43,19 +75,19 @@ func (ts TaskService) FindAllTasks(ctx context.Context) (FindAllTasksResponse, e
 	results := []model.Task{}
 
 	for _, v := range tasks {
-		// Convierte la fecha de time.Time a una cadena en el formato especificado.
-		dueDateStr := v.DueDate.Format(format)
-		task := model.Task{
-			Id:          v.Id,
-			Title:       v.Title,
-			Description: v.Description,
-			DueDate:     dueDateStr,
-			State:       v.State,
+		results = append(results, toTaskModel(ctx, v))
+	}
+
+	res := FindAllTasksResponse{Tasks: results}
+
+	if filter.Limit > 0 {
+		if res.Total, err = taskDAO.Count(ctx, filter); err != nil {
+			log.WithError(err).Error("problems with counting tasks")
+			return FindAllTasksResponse{}, err
 		}
-		results = append(results, task)
 	}
 
-	return FindAllTasksResponse{Tasks: results}, nil
+	return res, nil
 }
 
 // GetTaskRequest es la solicitud para GetTask.
@@ -72,6 +104,9 @@ type GetTaskResponse struct {
 func (ts TaskService) GetTask(ctx context.Context, in GetTaskRequest) (GetTaskResponse, error) {
 	log := loggerf.WithField("service", "TaskService").WithField("func", "GetTask")
 
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.GetTask")
+	defer span.End()
+
 	if in.Id == 0 {
 		return GetTaskResponse{}, errs.BadRequest
 	}
@@ -86,18 +121,7 @@ func (ts TaskService) GetTask(ctx context.Context, in GetTaskRequest) (GetTaskRe
 		return GetTaskResponse{}, errs.TasksNotFound
 	}
 
-	// Convierte la fecha de time.Time a una cadena en el formato especificado.
-	dueDateStr := v.DueDate.Format(format)
-
-	task := model.Task{
-		Id:          v.Id,
-		Title:       v.Title,
-		Description: v.Description,
-		DueDate:     dueDateStr,
-		State:       v.State,
-	}
-
-	return GetTaskResponse{Task: task}, nil
+	return GetTaskResponse{Task: toTaskModel(ctx, v)}, nil
 }
 
 // DeleteTaskRequest es la solicitud para DeleteTask.
@@ -112,13 +136,16 @@ type DeleteTaskResponse struct{}
 func (ts TaskService) DeleteTask(ctx context.Context, in DeleteTaskRequest) (DeleteTaskResponse, error) {
 	log := loggerf.WithField("service", "TaskService").WithField("func", "DeleteTask")
 
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.DeleteTask")
+	defer span.End()
+
 	if in.Id == 0 {
 		return DeleteTaskResponse{}, errs.BadRequest
 	}
 
 	taskDAO := dao.NewTaskDAO()
 
-	_, err := taskDAO.Get(ctx, in.Id)
+	current, err := taskDAO.Get(ctx, in.Id)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		log.WithError(err).Error("problems with getting task")
 		return DeleteTaskResponse{}, err
@@ -126,11 +153,29 @@ func (ts TaskService) DeleteTask(ctx context.Context, in DeleteTaskRequest) (Del
 		return DeleteTaskResponse{}, errs.TasksNotFound
 	}
 
-	err = taskDAO.Delete(ctx, in.Id)
+	if err := authorizeTask(ctx, security.ActDelete, current); err != nil {
+		return DeleteTaskResponse{}, err
+	}
+
+	var keys []string
+	err = base.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if keys, err = attachmentKeys(ctx, in.Id); err != nil {
+			return err
+		}
+		if err := taskDAO.Delete(ctx, in.Id); err != nil {
+			return err
+		}
+		return publishDeleted(ctx, in.Id, current.State)
+	})
 	if err != nil {
 		return DeleteTaskResponse{}, err
 	}
 
+	metrics.TasksDeleted.Inc()
+	unindexTask(ctx, in.Id)
+	removeAttachments(ctx, keys)
+
 	return DeleteTaskResponse{}, nil
 }
 
@@ -139,13 +184,19 @@ type UpdateTaskRequest struct {
 	Task model.Task `json:"task"`
 }
 
-// UpdateTaskResponse es la respuesta para UpdateTask.
-type UpdateTaskResponse struct{}
+// UpdateTaskResponse es la respuesta para UpdateTask. NextOccurrenceId es la
+// tarea creada al completar una tarea recurrente.
+type UpdateTaskResponse struct {
+	NextOccurrenceId int32 `json:"nextOccurrenceId,omitempty"`
+}
 
 // UpdateTask actualiza una tarea.
 func (ts TaskService) UpdateTask(ctx context.Context, in UpdateTaskRequest) (UpdateTaskResponse, error) {
 	log := loggerf.WithField("service", "TaskService").WithField("func", "UpdateTask")
 
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.UpdateTask")
+	defer span.End()
+
 	// Valida la solicitud de entrada
 	if err := validate.Validate(in); err != nil {
 		log.WithError(err).Error("validation problems")
@@ -154,7 +205,7 @@ func (ts TaskService) UpdateTask(ctx context.Context, in UpdateTaskRequest) (Upd
 
 	taskDAO := dao.NewTaskDAO()
 
-	_, err := taskDAO.Get(ctx, in.Task.Id)
+	current, err := taskDAO.Get(ctx, in.Task.Id)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		log.WithError(err).Error("problems with getting task")
 		return UpdateTaskResponse{}, err
@@ -162,24 +213,152 @@ func (ts TaskService) UpdateTask(ctx context.Context, in UpdateTaskRequest) (Upd
 		return UpdateTaskResponse{}, errs.TasksNotFound
 	}
 
-	dateFormatted, err := time.Parse(format, in.Task.DueDate)
+	if err := authorizeTask(ctx, security.ActUpdate, current); err != nil {
+		return UpdateTaskResponse{}, err
+	}
+
+	dateFormatted, err := parseDueDate(ctx, in.Task.DueDate)
 	if err != nil {
 		log.WithError(err).Error("binding error")
-		return UpdateTaskResponse{}, errs.BadRequest
+		return UpdateTaskResponse{}, err
 	}
 
-	err = taskDAO.Update(ctx, md.Task(md.Task{
-		Id:          in.Task.Id,
-		Title:       in.Task.Title,
-		Description: in.Task.Description,
-		DueDate:     dateFormatted,
-		State:       in.Task.State,
-	}))
+	if in.Task.Priority != "" {
+		if err := priorityValidate(in.Task.Priority); err != nil {
+			return UpdateTaskResponse{}, err
+		}
+	}
+
+	clearRecurrence := in.Task.Recurrence == RecurrenceNone
+	if clearRecurrence {
+		in.Task.Recurrence = ""
+	}
+
+	if in.Task.Recurrence != "" {
+		if in.Task.Recurrence, err = recurrenceValidate(in.Task.Recurrence); err != nil {
+			return UpdateTaskResponse{}, err
+		}
+	}
+
+	// Labels nil conserva las etiquetas, una lista vacía las elimina.
+	var labels []md.Label
+	if in.Task.Labels != nil {
+		if labels, err = resolveLabels(ctx, in.Task.Labels); err != nil {
+			return UpdateTaskResponse{}, err
+		}
+	}
+
+	// El cambio, sus eventos y la siguiente ocurrencia se confirman juntos.
+	// La tarea se vuelve a leer con su fila bloqueada y las reglas se evalúan
+	// sobre esa lectura: dos actualizaciones de la misma tarea no se cruzan y
+	// ninguna ocurrencia se completa dos veces.
+	var updated, next md.Task
+	err = base.Transaction(ctx, func(ctx context.Context) error {
+
+		var err error
+		current, err = taskDAO.Lock(ctx, in.Task.Id)
+		if err == gorm.ErrRecordNotFound {
+			return errs.TasksNotFound
+		} else if err != nil {
+			log.WithError(err).Error("problems with getting task")
+			return err
+		}
+
+		if err := authorizeTask(ctx, security.ActUpdate, current); err != nil {
+			return err
+		}
+
+		// Una serie nueva se evalúa en la zona horaria de quien la define.
+		var timeZone string
+		if in.Task.Recurrence != "" && current.TimeZone == "" {
+			timeZone = location(ctx).String()
+		}
+
+		// Una tarea solo se completa cuando sus subtareas y bloqueos están
+		// completados; quedan bloqueados hasta confirmar el cambio.
+		if in.Task.State == enums.CompletedTaskStatus && current.State != enums.CompletedTaskStatus {
+			if err := completeValidate(ctx, in.Task.Id); err != nil {
+				return err
+			}
+		}
+
+		err = taskDAO.Update(ctx, md.Task(md.Task{
+			Id:          in.Task.Id,
+			Title:       in.Task.Title,
+			Description: in.Task.Description,
+			DueDate:     dateFormatted,
+			State:       in.Task.State,
+			Priority:    in.Task.Priority,
+			Recurrence:  in.Task.Recurrence,
+			TimeZone:    timeZone,
+		}))
+		if err != nil {
+			return err
+		}
+
+		if clearRecurrence {
+			if err := taskDAO.ClearRecurrence(ctx, in.Task.Id); err != nil {
+				return err
+			}
+		}
+
+		if in.Task.Labels != nil {
+			if err := taskDAO.ReplaceLabels(ctx, in.Task.Id, labels); err != nil {
+				return err
+			}
+		}
+
+		// Con otra fecha el planificador vuelve a avisar.
+		if !dateFormatted.Equal(current.DueDate) {
+			if err := taskDAO.ResetReminders(ctx, in.Task.Id); err != nil {
+				return err
+			}
+		}
+
+		if updated, err = taskDAO.Get(ctx, in.Task.Id); err != nil {
+			return err
+		}
+
+		if err := publishTask(ctx, events.TaskUpdated, updated); err != nil {
+			return err
+		}
+		if updated.State != current.State {
+			if err := publishStateChanged(ctx, current.State, updated); err != nil {
+				return err
+			}
+		}
+
+		if updated.State == enums.CompletedTaskStatus && current.State != enums.CompletedTaskStatus {
+			if next, err = nextOccurrence(ctx, updated); err != nil {
+				log.WithError(err).Error("problems with creating next occurrence")
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return UpdateTaskResponse{}, err
 	}
 
-	return UpdateTaskResponse{}, nil
+	metrics.TasksUpdated.WithLabelValues(updated.State).Inc()
+	if updated.State == enums.CompletedTaskStatus && current.State != enums.CompletedTaskStatus {
+		metrics.TasksCompleted.Inc()
+	}
+
+	if in.Task.Title != "" || in.Task.Description != "" {
+		indexTask(ctx, updated)
+	}
+
+	res := UpdateTaskResponse{}
+
+	if next.Id != 0 {
+		metrics.TasksCreated.WithLabelValues(next.State).Inc()
+		indexTask(ctx, next)
+		res.NextOccurrenceId = next.Id
+	}
+
+	return res, nil
 }
 
 // SaveTaskRequest es la solicitud para SaveTask.
@@ -188,46 +367,166 @@ type SaveTaskRequest struct {
 }
 
 // SaveTaskResponse es la respuesta para SaveTask.
-type SaveTaskResponse struct{}
+type SaveTaskResponse struct {
+	Id int32 `json:"id"`
+}
 
 // SaveTask guarda una nueva tarea.
 func (ts TaskService) SaveTask(ctx context.Context, in SaveTaskRequest) (SaveTaskResponse, error) {
 	log := loggerf.WithField("service", "TaskService").WithField("func", "SaveTask")
 
-	err := stateValidate(ctx, in)
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.SaveTask")
+	defer span.End()
+
+	task, err := validateNewTask(ctx, in.Task)
 	if err != nil {
+		log.WithError(err).Error("validation problems")
 		return SaveTaskResponse{}, err
 	}
-	// Valida la solicitud de entrada
-	if err := validate.Validate(in); err != nil {
-		log.WithError(err).Error("validation problems")
-		return SaveTaskResponse{}, errs.BadRequest
+
+	if task.Labels, err = resolveLabels(ctx, in.Task.Labels); err != nil {
+		return SaveTaskResponse{}, err
+	}
+
+	if err := parentValidate(ctx, in.Task.ParentId); err != nil {
+		return SaveTaskResponse{}, err
 	}
 
 	taskDAO := dao.NewTaskDAO()
 
-	dateFormatted, err := time.Parse(format, in.Task.DueDate)
+	err = base.Transaction(ctx, func(ctx context.Context) error {
+		if err := taskDAO.Save(ctx, &task); err != nil {
+			return err
+		}
+		return publishTask(ctx, events.TaskCreated, task)
+	})
+	if err == dao.ErrTaskWithoutCenter {
+		log.WithError(err).Error("task without center")
+		return SaveTaskResponse{}, errs.TaskWithoutCenter
+	}
 	if err != nil {
-		log.WithError(err).Error("binding error")
-		return SaveTaskResponse{}, errs.BadRequest
+		return SaveTaskResponse{}, err
 	}
 
-	err = taskDAO.Save(ctx, md.Task(md.Task{
-		Title:       in.Task.Title,
-		Description: in.Task.Description,
-		DueDate:     dateFormatted,
-		State:       in.Task.State,
-	}))
+	metrics.TasksCreated.WithLabelValues(in.Task.State).Inc()
+	indexTask(ctx, task)
+
+	return SaveTaskResponse{Id: task.Id}, nil
+}
+
+// toTaskModel convierte una tarea de base de datos al modelo del servicio.
+func toTaskModel(ctx context.Context, v md.Task) model.Task {
+	task := model.Task{
+		Id:          v.Id,
+		Title:       v.Title,
+		Description: v.Description,
+		// Convierte la fecha a la zona horaria de la solicitud.
+		DueDate:    formatDueDate(ctx, v.DueDate),
+		State:      v.State,
+		Priority:   v.Priority,
+		Recurrence: v.Recurrence,
+		Owner:      v.Owner,
+		CenterCode: v.CenterCode,
+		Overdue:    v.State != enums.CompletedTaskStatus && v.DueDate.Before(time.Now()),
+	}
+	if v.ParentId != nil {
+		task.ParentId = *v.ParentId
+	}
+	for _, l := range v.Labels {
+		task.Labels = append(task.Labels, l.Name)
+	}
+	return task
+}
+
+// taskFilter convierte los filtros de la solicitud al filtro del DAO.
+func taskFilter(ctx context.Context, in FindAllTasksRequest) (dao.TaskFilter, error) {
+
+	filter := dao.TaskFilter{State: in.State, Priority: in.Priority, Label: in.Label, Sort: in.Sort, Limit: in.Limit, Offset: in.Offset}
+
+	if in.Limit < 0 || in.Limit > MaxFindLimit || in.Offset < 0 {
+		return dao.TaskFilter{}, errs.BadRequest.SetMessage(fmt.Sprintf("Invalid limit or offset, max limit %d", MaxFindLimit))
+	}
+
+	if in.State != "" {
+		if err := stateValidate(in.State); err != nil {
+			return dao.TaskFilter{}, err
+		}
+	}
+
+	if in.Priority != "" {
+		if err := priorityValidate(in.Priority); err != nil {
+			return dao.TaskFilter{}, err
+		}
+	}
+
+	if _, ok := dao.TaskSorts[in.Sort]; in.Sort != "" && !ok {
+		return dao.TaskFilter{}, errs.BadRequest.SetMessage("Invalid sort")
+	}
+
+	var err error
+	if in.DueFrom != "" {
+		if filter.DueFrom, err = parseDueDate(ctx, in.DueFrom); err != nil {
+			return dao.TaskFilter{}, err
+		}
+	}
+	if in.DueTo != "" {
+		if filter.DueTo, err = parseDueDate(ctx, in.DueTo); err != nil {
+			return dao.TaskFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+// validateNewTask aplica las reglas de SaveTask a una tarea y la convierte al
+// modelo de base de datos.
+func validateNewTask(ctx context.Context, t model.Task) (md.Task, error) {
+
+	if err := stateValidate(t.State); err != nil {
+		return md.Task{}, err
+	}
+
+	if t.Priority == "" {
+		t.Priority = enums.MediumTaskPriority
+	}
+	if err := priorityValidate(t.Priority); err != nil {
+		return md.Task{}, err
+	}
+
+	if t.Recurrence != "" {
+		var err error
+		if t.Recurrence, err = recurrenceValidate(t.Recurrence); err != nil {
+			return md.Task{}, err
+		}
+	}
+
+	// Valida la solicitud de entrada
+	if err := validate.Validate(t); err != nil {
+		return md.Task{}, errs.BadRequest
+	}
+
+	dateFormatted, err := parseDueDate(ctx, t.DueDate)
 	if err != nil {
-		return SaveTaskResponse{}, err
+		return md.Task{}, err
 	}
 
-	return SaveTaskResponse{}, nil
+	return md.Task{
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     dateFormatted,
+		State:       t.State,
+		Priority:    t.Priority,
+		ParentId:    parentId(t.ParentId),
+		Recurrence:  t.Recurrence,
+		TimeZone:    location(ctx).String(),
+		Owner:       owner(ctx),
+	}, nil
 }
 
-func stateValidate(ctx context.Context, in SaveTaskRequest) error {
+// stateValidate comprueba que el estado sea uno de los estados de tarea conocidos.
+func stateValidate(state string) error {
 
-	var flag bool
+	flag := true
 	taskStatuses := []string{
 		enums.PendingTaskStatus,
 		enums.InProgressTaskStatus,
@@ -235,8 +534,8 @@ func stateValidate(ctx context.Context, in SaveTaskRequest) error {
 	}
 
 	for _, v := range taskStatuses {
-		if in.Task.State != v {
-			flag = true
+		if state == v {
+			flag = false
 		}
 	}
 
@@ -247,3 +546,37 @@ func stateValidate(ctx context.Context, in SaveTaskRequest) error {
 	return nil
 
 }
+
+// priorityValidate comprueba que la prioridad sea una de las prioridades conocidas.
+func priorityValidate(priority string) error {
+
+	for _, v := range enums.TaskPriorities {
+		if priority == v {
+			return nil
+		}
+	}
+
+	return errs.TaskPriorityInvalid
+
+}
+
+// resolveLabels obtiene las etiquetas por nombre; todas deben existir.
+func resolveLabels(ctx context.Context, names []string) ([]md.Label, error) {
+
+	labels, err := dao.NewLabelDAO().GetByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, l := range labels {
+		found[l.Name] = true
+	}
+	for _, n := range names {
+		if !found[n] {
+			return nil, errs.LabelNotFound.SetMessage("Label not found: " + n)
+		}
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func inCenter(center string) context.Context {
+	return as(model.AuthenticatedUser{Email: "admin@" + center, CenterCode: center, Roles: []model.Role{{Code: "ROL_1"}}})
+}
+
+func TestTaskService_TenantIsolation(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ts := TaskService{}
+	c1, c2 := inCenter("C1"), inCenter("C2")
+
+	saved, err := ts.SaveTask(c1, SaveTaskRequest{Task: model.Task{Title: "c1", Description: "c1", DueDate: "2030-01-01T10:00:00", State: "PENDING"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	other, err := ts.SaveTask(c2, SaveTaskRequest{Task: model.Task{Title: "c2", Description: "c2", DueDate: "2030-01-01T10:00:00", State: "PENDING"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	got, err := ts.GetTask(c1, GetTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "C1", got.Task.CenterCode)
+
+	// C2 neither reads nor changes the tasks of C1
+	_, err = ts.GetTask(c2, GetTaskRequest{Id: saved.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	all, err := ts.FindAllTasks(c2, FindAllTasksRequest{})
+	assert.NoError(t, err)
+	for _, task := range all.Tasks {
+		assert.Equal(t, "C2", task.CenterCode)
+	}
+
+	found, err := ts.SearchTasks(c2, SearchTasksRequest{Q: "c1 c2"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, found.Results)
+	for _, r := range found.Results {
+		assert.Equal(t, "C2", r.Task.CenterCode)
+	}
+
+	_, err = ts.UpdateTask(c2, UpdateTaskRequest{Task: model.Task{Id: saved.Id, Title: "taken", Description: "taken", State: "PENDING"}})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	_, err = ts.SetParent(c2, SetParentRequest{Id: other.Id, ParentId: saved.Id})
+	assert.Error(t, err)
+
+	_, err = ts.AddBlocker(c2, AddBlockerRequest{Id: other.Id, BlockerId: saved.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	blocker, err := ts.SaveTask(c1, SaveTaskRequest{Task: model.Task{Title: "c1 blocker", Description: "c1", DueDate: "2030-01-01T10:00:00", State: "PENDING"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = ts.AddBlocker(c1, AddBlockerRequest{Id: saved.Id, BlockerId: blocker.Id})
+	assert.NoError(t, err)
+	_, err = ts.RemoveBlocker(c2, RemoveBlockerRequest{Id: saved.Id, BlockerId: blocker.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+	_, err = ts.RemoveBlocker(c1, RemoveBlockerRequest{Id: saved.Id, BlockerId: blocker.Id})
+	assert.NoError(t, err)
+
+	res, err := ts.BulkUpdateTasks(c2, BulkUpdateTasksRequest{Ids: []int32{saved.Id}, State: "COMPLETED"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Failed)
+
+	_, err = ts.DeleteTask(c2, DeleteTaskRequest{Id: saved.Id})
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	got, err = ts.GetTask(c1, GetTaskRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", got.Task.Title)
+	assert.Equal(t, "PENDING", got.Task.State)
+
+	// calls without user, like the workers, see every center
+	_, err = ts.GetTask(context.TODO(), GetTaskRequest{Id: other.Id})
+	assert.NoError(t, err)
+}
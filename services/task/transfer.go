@@ -0,0 +1,507 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+)
+
+// Formatos soportados por la importación y exportación de tareas.
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// MaxImportRows es el número máximo de filas aceptadas por una importación.
+const MaxImportRows = 5000
+
+// MaxImportSize es el tamaño máximo en bytes del cuerpo de una importación.
+const MaxImportSize = 16 << 20
+
+// exportFlushEvery es cada cuántas filas se envía al cliente lo exportado.
+const exportFlushEvery = 100
+
+var csvHeader = []string{"id", "title", "description", "due_date", "state", "priority", "labels", "recurrence"}
+
+// csvLabelSeparator separa las etiquetas dentro de la columna labels.
+const csvLabelSeparator = "|"
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv",
+	FormatJSON:   "application/json",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// ContentType devuelve el tipo MIME de un formato.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FormatFromContentType devuelve el formato asociado a un tipo MIME, o vacío.
+func FormatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	for f, ct := range contentTypes {
+		if ct == mediaType {
+			return f
+		}
+	}
+	return ""
+}
+
+// formatValidate comprueba que el formato sea uno de los soportados.
+func formatValidate(format string) error {
+	if _, ok := contentTypes[format]; !ok {
+		return errs.BadRequest.SetMessage("Unsupported format, use csv, json or ndjson")
+	}
+	return nil
+}
+
+// ExportTasksRequest es la solicitud para ExportTasks.
+type ExportTasksRequest struct {
+	FindAllTasksRequest
+	Format string `json:"format" query:"format"`
+}
+
+// ExportTasks escribe en w las tareas que cumplen los filtros, sin cargarlas
+// todas en memoria. Si w implementa Flush se invoca periódicamente.
+func (ts TaskService) ExportTasks(ctx context.Context, in ExportTasksRequest, w io.Writer) error {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "ExportTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.ExportTasks")
+	defer span.End()
+
+	if err := formatValidate(in.Format); err != nil {
+		return err
+	}
+
+	filter, err := taskFilter(ctx, in.FindAllTasksRequest)
+	if err != nil {
+		return err
+	}
+
+	enc := newTaskEncoder(in.Format, w)
+	flusher, _ := w.(interface{ Flush() })
+
+	if err := enc.Begin(); err != nil {
+		return err
+	}
+
+	rows := 0
+	err = dao.NewTaskDAO().Stream(ctx, filter, func(t md.Task) error {
+		if err := enc.Encode(toTaskModel(ctx, t)); err != nil {
+			return err
+		}
+		rows++
+		if flusher != nil && rows%exportFlushEvery == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("problems with exporting tasks")
+		return err
+	}
+
+	if err := enc.End(); err != nil {
+		return err
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	log.WithField("rows", rows).Info("tasks exported")
+
+	return nil
+}
+
+// ImportTasksRequest es la solicitud para ImportTasks.
+type ImportTasksRequest struct {
+	Format       string `json:"format" query:"format"`
+	DryRun       bool   `json:"dryRun" query:"dryRun"`
+	AllOrNothing bool   `json:"allOrNothing" query:"allOrNothing"`
+}
+
+// ImportRowResult es el resultado de una fila importada. Row empieza en 1 y
+// no cuenta la cabecera CSV.
+type ImportRowResult struct {
+	Row     int               `json:"row"`
+	Id      int32             `json:"id,omitempty"`
+	Success bool              `json:"success"`
+	Error   *errs.CustomError `json:"error,omitempty"`
+}
+
+// ImportTasksResponse es la respuesta para ImportTasks.
+type ImportTasksResponse struct {
+	DryRun   bool              `json:"dryRun"`
+	Total    int               `json:"total"`
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// ImportTasks lee tareas desde r, valida cada fila con las reglas de SaveTask
+// y guarda las válidas en una única transacción, salvo en modo DryRun.
+func (ts TaskService) ImportTasks(ctx context.Context, in ImportTasksRequest, r io.Reader) (ImportTasksResponse, error) {
+	log := loggerf.WithField("service", "TaskService").WithField("func", "ImportTasks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "TaskService.ImportTasks")
+	defer span.End()
+
+	if err := formatValidate(in.Format); err != nil {
+		return ImportTasksResponse{}, err
+	}
+
+	rows, err := decodeTasks(in.Format, r)
+	if err != nil {
+		log.WithError(err).Error("decoding problems")
+		return ImportTasksResponse{}, err
+	}
+
+	if len(rows) == 0 {
+		return ImportTasksResponse{}, errs.BadRequest.SetMessage("No rows to import")
+	}
+	if len(rows) > MaxImportRows {
+		return ImportTasksResponse{}, errs.BatchTooLarge.SetMessage(fmt.Sprintf("Too many rows, max %d", MaxImportRows))
+	}
+
+	res := ImportTasksResponse{DryRun: in.DryRun, Total: len(rows), Rows: make([]ImportRowResult, len(rows))}
+
+	tasks := []model.Task{}
+	positions := []int{}
+	for i, row := range rows {
+		if row.err != nil {
+			ce := errs.BadRequest.SetMessage(row.err.Error())
+			res.Rows[i] = ImportRowResult{Row: i + 1, Error: &ce}
+			continue
+		}
+		tasks = append(tasks, row.task)
+		positions = append(positions, i)
+	}
+
+	// Con errores de lectura y todo-o-nada sólo se validan las filas restantes.
+	rollback := in.AllOrNothing && len(tasks) < len(rows)
+
+	if len(tasks) > 0 {
+		results, err := saveTasks(ctx, tasks, in.AllOrNothing, in.DryRun || rollback)
+		if err != nil {
+			log.WithError(err).Error("problems with saving tasks")
+			return ImportTasksResponse{}, err
+		}
+
+		for k, r := range results {
+			i := positions[k]
+			if r.Success && rollback {
+				ce := errs.BulkRolledBack
+				res.Rows[i] = ImportRowResult{Row: i + 1, Error: &ce}
+				continue
+			}
+			res.Rows[i] = ImportRowResult{Row: i + 1, Id: r.Id, Success: r.Success, Error: r.Error}
+		}
+	}
+
+	for _, r := range res.Rows {
+		if r.Success {
+			res.Imported++
+		} else {
+			res.Failed++
+		}
+	}
+
+	log.WithField("total", res.Total).WithField("failed", res.Failed).WithField("dryRun", in.DryRun).Info("tasks imported")
+
+	return res, nil
+}
+
+// taskEncoder escribe tareas en un formato de exportación.
+type taskEncoder interface {
+	Begin() error
+	Encode(t model.Task) error
+	Flush() error
+	End() error
+}
+
+func newTaskEncoder(format string, w io.Writer) taskEncoder {
+	switch format {
+	case FormatCSV:
+		return &csvTaskEncoder{w: csv.NewWriter(w)}
+	case FormatJSON:
+		return &jsonTaskEncoder{w: w}
+	default:
+		return &ndjsonTaskEncoder{enc: json.NewEncoder(w)}
+	}
+}
+
+type csvTaskEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvTaskEncoder) Begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvTaskEncoder) Encode(t model.Task) error {
+	return e.w.Write([]string{fmt.Sprint(t.Id), t.Title, t.Description, t.DueDate, t.State, t.Priority,
+		strings.Join(t.Labels, csvLabelSeparator), t.Recurrence})
+}
+
+func (e *csvTaskEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvTaskEncoder) End() error {
+	return e.Flush()
+}
+
+type jsonTaskEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonTaskEncoder) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonTaskEncoder) Encode(t model.Task) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonTaskEncoder) Flush() error {
+	return nil
+}
+
+func (e *jsonTaskEncoder) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type ndjsonTaskEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonTaskEncoder) Begin() error {
+	return nil
+}
+
+func (e *ndjsonTaskEncoder) Encode(t model.Task) error {
+	return e.enc.Encode(t)
+}
+
+func (e *ndjsonTaskEncoder) Flush() error {
+	return nil
+}
+
+func (e *ndjsonTaskEncoder) End() error {
+	return nil
+}
+
+// decodedRow es una fila leída de un archivo de importación.
+type decodedRow struct {
+	task model.Task
+	err  error
+}
+
+// decodeTasks lee las filas de r hasta MaxImportRows+1, así una importación
+// demasiado grande se rechaza sin leerla entera. Los errores de una fila se
+// devuelven en la propia fila; sólo los errores que impiden seguir leyendo se
+// devuelven como error.
+func decodeTasks(format string, r io.Reader) ([]decodedRow, error) {
+
+	er := &errReader{r: r}
+
+	var rows []decodedRow
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = decodeCSV(er)
+	case FormatJSON:
+		rows, err = decodeJSON(er)
+	default:
+		rows, err = decodeNDJSON(er)
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(er.err, &tooLarge) {
+		return nil, errs.ImportTooLarge.SetMessage(fmt.Sprintf("Import too large, max %d bytes", tooLarge.Limit))
+	}
+
+	return rows, err
+}
+
+// errReader guarda el último error de lectura de r, que los decodificadores
+// sólo devuelven como texto.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF {
+		er.err = err
+	}
+	return n, err
+}
+
+func decodeCSV(r io.Reader) ([]decodedRow, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, errs.BadRequest.SetMessage("Invalid CSV header")
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errs.BadRequest.SetMessage("CSV header must contain a title column")
+	}
+
+	value := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	rows := []decodedRow{}
+	for len(rows) <= MaxImportRows {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				rows = append(rows, decodedRow{err: err})
+				continue
+			}
+			return nil, errs.BadRequest.SetMessage(fmt.Sprintf("Invalid CSV: %v", err))
+		}
+		if len(record) != len(header) {
+			rows = append(rows, decodedRow{err: fmt.Errorf("expected %d columns, got %d", len(header), len(record))})
+			continue
+		}
+		task := model.Task{
+			Title:       value(record, "title"),
+			Description: value(record, "description"),
+			DueDate:     value(record, "due_date"),
+			State:       value(record, "state"),
+			Priority:    value(record, "priority"),
+			Recurrence:  value(record, "recurrence"),
+		}
+		for _, l := range strings.Split(value(record, "labels"), csvLabelSeparator) {
+			if l = strings.TrimSpace(l); l != "" {
+				task.Labels = append(task.Labels, l)
+			}
+		}
+		rows = append(rows, decodedRow{task: task})
+	}
+
+	return rows, nil
+}
+
+func decodeJSON(r io.Reader) ([]decodedRow, error) {
+
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if delim, ok := tok.(json.Delim); err != nil || !ok || delim != '[' {
+		return nil, errs.BadRequest.SetMessage("JSON import must be an array of tasks")
+	}
+
+	rows := []decodedRow{}
+	for dec.More() {
+		if len(rows) > MaxImportRows {
+			return rows, nil
+		}
+		t := model.Task{}
+		if err := dec.Decode(&t); err != nil {
+			var te *json.UnmarshalTypeError
+			if errors.As(err, &te) {
+				rows = append(rows, decodedRow{err: err})
+				continue
+			}
+			return nil, errs.BadRequest.SetMessage(fmt.Sprintf("Invalid JSON at row %d: %v", len(rows)+1, err))
+		}
+		t.Id = 0
+		rows = append(rows, decodedRow{task: t})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, errs.BadRequest.SetMessage("Invalid JSON, unterminated array")
+	}
+
+	return rows, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]decodedRow, error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	rows := []decodedRow{}
+	for len(rows) <= MaxImportRows && scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		t := model.Task{}
+		if err := json.Unmarshal(line, &t); err != nil {
+			rows = append(rows, decodedRow{err: err})
+			continue
+		}
+		t.Id = 0
+		rows = append(rows, decodedRow{task: t})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errs.BadRequest.SetMessage(fmt.Sprintf("Invalid NDJSON: %v", err))
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+var transferTasks = []model.Task{
+	{Id: 1, Title: "Uno", Description: "Primera, con coma", DueDate: "2023-10-01T10:00:00", State: "PENDING",
+		Priority: "HIGH", Labels: []string{"casa", "urgente"}},
+	{Id: 2, Title: "Dos", Description: "Segunda \"citada\"", DueDate: "2023-10-02T10:00:00", State: "COMPLETED",
+		Priority: "LOW", Recurrence: "FREQ=WEEKLY;BYDAY=MO"},
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+
+	for _, format := range []string{FormatCSV, FormatJSON, FormatNDJSON} {
+		buf := &bytes.Buffer{}
+		enc := newTaskEncoder(format, buf)
+
+		assert.NoError(t, enc.Begin())
+		for _, task := range transferTasks {
+			assert.NoError(t, enc.Encode(task))
+		}
+		assert.NoError(t, enc.End())
+
+		rows, err := decodeTasks(format, buf)
+		assert.NoError(t, err, format)
+		assert.Len(t, rows, len(transferTasks), format)
+
+		for i, row := range rows {
+			assert.NoError(t, row.err, format)
+			expected := transferTasks[i]
+			expected.Id = 0
+			assert.Equal(t, expected, row.task, format)
+		}
+	}
+}
+
+func TestDecodeCSV_RowErrors(t *testing.T) {
+
+	input := "title,description,due_date,state\n" +
+		"Uno,Primera,2023-10-01T10:00:00,PENDING\n" +
+		"Dos,Segunda\n" +
+		"Tres,Tercera,2023-10-03T10:00:00,COMPLETED\n"
+
+	rows, err := decodeTasks(FormatCSV, strings.NewReader(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.NoError(t, rows[0].err)
+	assert.Error(t, rows[1].err)
+	assert.Equal(t, "Tres", rows[2].task.Title)
+}
+
+func TestDecodeJSON_NotAnArray(t *testing.T) {
+
+	_, err := decodeTasks(FormatJSON, strings.NewReader(`{"title":"Uno"}`))
+
+	assert.Error(t, err)
+}
+
+func TestImportTasks_DryRun(t *testing.T) {
+
+	input := `{"title":"Uno","description":"Primera","due_date":"2023-10-01T10:00:00","state":"PENDING"}
+{"title":"Dos","description":"Segunda","due_date":"2023-10-02T10:00:00","state":"DONE"}
+{"title":3}
+`
+
+	res, err := TaskService{}.ImportTasks(context.TODO(), ImportTasksRequest{Format: FormatNDJSON, DryRun: true}, strings.NewReader(input))
+
+	assert.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, 3, res.Total)
+	assert.Equal(t, 1, res.Imported)
+	assert.Equal(t, 2, res.Failed)
+	assert.True(t, res.Rows[0].Success)
+	assert.Equal(t, errs.TaskStateInvalid.InternalCode, res.Rows[1].Error.InternalCode)
+	assert.Equal(t, errs.BadRequest.InternalCode, res.Rows[2].Error.InternalCode)
+}
+
+func TestDecodeTasks_StopsAfterMaxRows(t *testing.T) {
+
+	input := strings.Repeat(`{"title":"Uno"}`+"\n", MaxImportRows+100)
+
+	rows, err := decodeTasks(FormatNDJSON, strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Len(t, rows, MaxImportRows+1)
+
+	_, err = TaskService{}.ImportTasks(context.TODO(), ImportTasksRequest{Format: FormatNDJSON, DryRun: true}, strings.NewReader(input))
+	assert.Equal(t, errs.BatchTooLarge.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestDecodeTasks_TooLarge(t *testing.T) {
+
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`[{"title":"Uno"},{"title":"Dos"}]`)), 10)
+
+	_, err := decodeTasks(FormatJSON, body)
+
+	assert.Equal(t, errs.ImportTooLarge.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestImportTasks_UnsupportedFormat(t *testing.T) {
+
+	_, err := TaskService{}.ImportTasks(context.TODO(), ImportTasksRequest{Format: "xml"}, strings.NewReader(""))
+
+	assert.Equal(t, errs.BadRequest.InternalCode, err.(errs.CustomError).InternalCode)
+}
+
+func TestExportTasks_Sorted(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	// más tareas que un lote de lectura, varias con la misma fecha
+	ctx := inCenter("EXPORT")
+	for i := 0; i < 205; i++ {
+		_, err := TaskService{}.SaveTask(ctx, SaveTaskRequest{Task: model.Task{
+			Title: "Exportada", Description: "Exportada", DueDate: fmt.Sprintf("2030-01-%02dT10:00:00", i%28+1), State: "PENDING",
+		}})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	want, err := TaskService{}.FindAllTasks(ctx, FindAllTasksRequest{Sort: "-due_date"})
+	assert.NoError(t, err)
+
+	out := bytes.Buffer{}
+	err = TaskService{}.ExportTasks(ctx, ExportTasksRequest{FindAllTasksRequest: FindAllTasksRequest{Sort: "-due_date"}, Format: FormatNDJSON}, &out)
+	assert.NoError(t, err)
+
+	rows, err := decodeTasks(FormatNDJSON, &out)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, len(want.Tasks)) {
+		for i, r := range rows {
+			assert.Equal(t, want.Tasks[i].Title, r.task.Title)
+			assert.Equal(t, want.Tasks[i].DueDate, r.task.DueDate)
+		}
+	}
+}
+
+func TestFormatFromContentType(t *testing.T) {
+
+	assert.Equal(t, FormatCSV, FormatFromContentType("text/csv; charset=utf-8"))
+	assert.Equal(t, FormatNDJSON, FormatFromContentType("application/x-ndjson"))
+	assert.Equal(t, "", FormatFromContentType("text/plain"))
+}
@@ -0,0 +1,487 @@
+package user
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gopkg.in/dealancer/validate.v2"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// MaxLoginAttempts es el número de inicios de sesión fallidos seguidos tras
+// el que se bloquea el usuario durante LoginLockDuration.
+const MaxLoginAttempts = 5
+
+// LoginLockDuration es el tiempo que un usuario queda bloqueado tras
+// MaxLoginAttempts intentos fallidos, de LOGIN_LOCK_DURATION (15m por
+// defecto).
+var LoginLockDuration = loginLockDurationFromEnv()
+
+// UserService contiene los métodos relacionados con los usuarios y sus roles.
+type UserService struct{}
+
+// GetUserRequest es la solicitud para GetUser.
+type GetUserRequest struct {
+	Email string `json:"email" validate:"empty=false"`
+}
+
+// GetUserResponse es la respuesta para GetUser.
+type GetUserResponse struct {
+	User model.User `json:"user"`
+}
+
+// GetUser obtiene un usuario con sus roles.
+func (us UserService) GetUser(ctx context.Context, in GetUserRequest) (GetUserResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "GetUser")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return GetUserResponse{}, errs.BadRequest
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	u, err := getUser(ctx, userDAO, in.Email)
+	if err != nil {
+		return GetUserResponse{}, err
+	}
+
+	roles, err := userDAO.Roles(ctx, in.Email)
+	if err != nil {
+		log.WithError(err).Error("problems with getting roles")
+		return GetUserResponse{}, err
+	}
+
+	return GetUserResponse{User: toUserModel(u, roles)}, nil
+}
+
+// SaveUserRequest es la solicitud para SaveUser. Los roles del usuario se
+// indican por su código.
+type SaveUserRequest struct {
+	User     model.User `json:"user"`
+	Password string     `json:"password" validate:"empty=false"`
+}
+
+// SaveUserResponse es la respuesta para SaveUser.
+type SaveUserResponse struct{}
+
+// SaveUser crea un usuario activo con sus roles; la contraseña debe cumplir
+// la política y se guarda su hash.
+func (us UserService) SaveUser(ctx context.Context, in SaveUserRequest) (SaveUserResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "SaveUser")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.SaveUser")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil || in.User.Email == "" || in.User.CenterCode == "" {
+		log.WithError(err).Error("validation problems")
+		return SaveUserResponse{}, errs.BadRequest
+	}
+
+	if _, err := (security.PasswordPolicyImpl{}).Validate(in.Password); err != nil {
+		return SaveUserResponse{}, err
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	_, err := userDAO.Get(ctx, in.User.Email)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.WithError(err).Error("problems with getting user")
+		return SaveUserResponse{}, err
+	} else if err == nil {
+		return SaveUserResponse{}, errs.UserAlreadyExists
+	}
+
+	for _, r := range in.User.Roles {
+		if err := existsRole(ctx, r.Code); err != nil {
+			return SaveUserResponse{}, err
+		}
+	}
+
+	hash, err := security.PasswordHashImpl{}.Hash(in.Password)
+	if err != nil {
+		return SaveUserResponse{}, err
+	}
+
+	err = base.Transaction(ctx, func(ctx context.Context) error {
+
+		u := md.User{
+			Email:      in.User.Email,
+			CenterCode: in.User.CenterCode,
+			FullName:   in.User.FullName,
+			Password:   hash,
+			Status:     enums.ActiveUserStatus,
+		}
+		if err := userDAO.Save(ctx, &u); err != nil {
+			return err
+		}
+
+		for _, r := range in.User.Roles {
+			if err := userDAO.AddRole(ctx, u.Email, r.Code); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("problems with saving user")
+		return SaveUserResponse{}, err
+	}
+
+	return SaveUserResponse{}, nil
+}
+
+// LoginRequest es la solicitud para Login.
+type LoginRequest struct {
+	Email    string `json:"email" validate:"empty=false"`
+	Password string `json:"password" validate:"empty=false"`
+}
+
+// LoginResponse es la respuesta para Login. Token se envía en
+// "Authorization: Bearer ..." hasta ExpiresAt.
+type LoginResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// Login comprueba la contraseña de un usuario activo y emite un token con su
+// centro y sus roles, válido durante security.TokenTTL. Tras MaxLoginAttempts
+// intentos fallidos seguidos el usuario queda bloqueado durante
+// LoginLockDuration. Mientras no se haya comprobado la contraseña solo se
+// responde InvalidCredentials, así no se revela si el usuario existe o está
+// bloqueado.
+func (us UserService) Login(ctx context.Context, in LoginRequest) (LoginResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "Login")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.Login")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return LoginResponse{}, errs.BadRequest
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	u, err := userDAO.Get(ctx, in.Email)
+	if err == gorm.ErrRecordNotFound {
+		return LoginResponse{}, errs.InvalidCredentials
+	} else if err != nil {
+		log.WithError(err).Error("problems with getting user")
+		return LoginResponse{}, err
+	}
+
+	// durante el bloqueo la contraseña no se evalúa
+	now := time.Now()
+	if u.LockedUntil != nil && now.Before(*u.LockedUntil) {
+		return LoginResponse{}, errs.InvalidCredentials
+	}
+
+	if valid, _ := (security.PasswordHashImpl{}).Compare(in.Password, u.Password); !valid {
+		if err := userDAO.FailLogin(ctx, u.Email, MaxLoginAttempts, now.Add(LoginLockDuration)); err != nil {
+			log.WithError(err).Error("problems with counting failed login")
+		}
+		return LoginResponse{}, errs.InvalidCredentials
+	}
+
+	if u.Status == enums.LockedUserStatus {
+		return LoginResponse{}, errs.UserLocked
+	}
+
+	if u.Attempts > 0 || u.LockedUntil != nil {
+		if err := userDAO.ResetLogins(ctx, u.Email); err != nil {
+			log.WithError(err).Error("problems with resetting failed logins")
+		}
+	}
+
+	roles, err := userDAO.Roles(ctx, u.Email)
+	if err != nil {
+		log.WithError(err).Error("problems with getting roles")
+		return LoginResponse{}, err
+	}
+
+	au := model.AuthenticatedUser{Email: u.Email, CenterCode: u.CenterCode}
+	for _, r := range roles {
+		au.Roles = append(au.Roles, model.Role{Code: r.Code})
+	}
+
+	expiresAt := time.Now().Add(security.TokenTTL)
+	token, err := security.IssueToken(au, security.TokenTTL)
+	if err != nil {
+		log.WithError(err).Error("problems with issuing token")
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{Token: token, ExpiresAt: expiresAt.UTC().Format(time.RFC3339)}, nil
+}
+
+// LockUserRequest es la solicitud para LockUser y UnlockUser.
+type LockUserRequest struct {
+	Email string `json:"email" validate:"empty=false"`
+}
+
+// LockUserResponse es la respuesta para LockUser y UnlockUser.
+type LockUserResponse struct{}
+
+// LockUser bloquea un usuario, que ya no puede iniciar sesión.
+func (us UserService) LockUser(ctx context.Context, in LockUserRequest) (LockUserResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.LockUser")
+	defer span.End()
+
+	return LockUserResponse{}, setStatus(ctx, "LockUser", in, enums.LockedUserStatus)
+}
+
+// UnlockUser desbloquea un usuario y reinicia sus intentos fallidos.
+func (us UserService) UnlockUser(ctx context.Context, in LockUserRequest) (LockUserResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.UnlockUser")
+	defer span.End()
+
+	return LockUserResponse{}, setStatus(ctx, "UnlockUser", in, enums.ActiveUserStatus)
+}
+
+// ResetPasswordRequest es la solicitud para ResetPassword.
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"empty=false"`
+	Password string `json:"password" validate:"empty=false"`
+}
+
+// ResetPasswordResponse es la respuesta para ResetPassword.
+type ResetPasswordResponse struct{}
+
+// ResetPassword reemplaza la contraseña de un usuario; la nueva debe cumplir
+// la política.
+func (us UserService) ResetPassword(ctx context.Context, in ResetPasswordRequest) (ResetPasswordResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "ResetPassword")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return ResetPasswordResponse{}, errs.BadRequest
+	}
+
+	if _, err := (security.PasswordPolicyImpl{}).Validate(in.Password); err != nil {
+		return ResetPasswordResponse{}, err
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	if _, err := getUser(ctx, userDAO, in.Email); err != nil {
+		return ResetPasswordResponse{}, err
+	}
+
+	hash, err := security.PasswordHashImpl{}.Hash(in.Password)
+	if err != nil {
+		return ResetPasswordResponse{}, err
+	}
+
+	if err := userDAO.UpdatePassword(ctx, in.Email, hash); err != nil {
+		log.WithError(err).Error("problems with updating password")
+		return ResetPasswordResponse{}, err
+	}
+
+	return ResetPasswordResponse{}, nil
+}
+
+// AssignRoleRequest es la solicitud para AssignRole y RevokeRole.
+type AssignRoleRequest struct {
+	Email    string `json:"email" validate:"empty=false"`
+	RoleCode string `json:"roleCode" validate:"empty=false"`
+}
+
+// AssignRoleResponse es la respuesta para AssignRole y RevokeRole.
+type AssignRoleResponse struct{}
+
+// AssignRole asigna un rol a un usuario.
+func (us UserService) AssignRole(ctx context.Context, in AssignRoleRequest) (AssignRoleResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "AssignRole")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.AssignRole")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return AssignRoleResponse{}, errs.BadRequest
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	if _, err := getUser(ctx, userDAO, in.Email); err != nil {
+		return AssignRoleResponse{}, err
+	}
+
+	if err := existsRole(ctx, in.RoleCode); err != nil {
+		return AssignRoleResponse{}, err
+	}
+
+	if err := userDAO.AddRole(ctx, in.Email, in.RoleCode); err != nil {
+		log.WithError(err).Error("problems with assigning role")
+		return AssignRoleResponse{}, err
+	}
+
+	return AssignRoleResponse{}, nil
+}
+
+// RevokeRole quita un rol a un usuario.
+func (us UserService) RevokeRole(ctx context.Context, in AssignRoleRequest) (AssignRoleResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "RevokeRole")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.RevokeRole")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return AssignRoleResponse{}, errs.BadRequest
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	if _, err := getUser(ctx, userDAO, in.Email); err != nil {
+		return AssignRoleResponse{}, err
+	}
+
+	if err := userDAO.RemoveRole(ctx, in.Email, in.RoleCode); err != nil {
+		log.WithError(err).Error("problems with revoking role")
+		return AssignRoleResponse{}, err
+	}
+
+	return AssignRoleResponse{}, nil
+}
+
+// FindAllRolesResponse es la respuesta para FindAllRoles.
+type FindAllRolesResponse struct {
+	Roles []model.Role `json:"roles"`
+}
+
+// FindAllRoles recupera todos los roles ordenados por código.
+func (us UserService) FindAllRoles(ctx context.Context) (FindAllRolesResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "FindAllRoles")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.FindAllRoles")
+	defer span.End()
+
+	roles, err := dao.NewRoleDAO().FindAll(ctx)
+	if err != nil {
+		log.WithError(err).Error("problems with getting roles")
+		return FindAllRolesResponse{}, err
+	}
+
+	return FindAllRolesResponse{Roles: toRoleModels(roles)}, nil
+}
+
+// SaveRoleRequest es la solicitud para SaveRole.
+type SaveRoleRequest struct {
+	Code        string `json:"code" validate:"empty=false"`
+	Name        string `json:"name" validate:"empty=false"`
+	Description string `json:"description"`
+}
+
+// SaveRoleResponse es la respuesta para SaveRole.
+type SaveRoleResponse struct{}
+
+// SaveRole crea un rol o actualiza su nombre y descripción. Sus permisos
+// se definen en la política de casbin.
+func (us UserService) SaveRole(ctx context.Context, in SaveRoleRequest) (SaveRoleResponse, error) {
+	log := loggerf.WithField("service", "UserService").WithField("func", "SaveRole")
+
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.SaveRole")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return SaveRoleResponse{}, errs.BadRequest
+	}
+
+	if err := dao.NewRoleDAO().Save(ctx, md.Role{Code: in.Code, Name: in.Name, Description: in.Description}); err != nil {
+		log.WithError(err).Error("problems with saving role")
+		return SaveRoleResponse{}, err
+	}
+
+	return SaveRoleResponse{}, nil
+}
+
+// setStatus cambia el estado del usuario y reinicia sus intentos fallidos.
+func setStatus(ctx context.Context, fn string, in LockUserRequest, status string) error {
+	log := loggerf.WithField("service", "UserService").WithField("func", fn)
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return errs.BadRequest
+	}
+
+	userDAO := dao.NewUserDAO()
+
+	if _, err := getUser(ctx, userDAO, in.Email); err != nil {
+		return err
+	}
+
+	if err := userDAO.UpdateStatus(ctx, in.Email, status, 0); err != nil {
+		log.WithError(err).Error("problems with updating user status")
+		return err
+	}
+
+	return nil
+}
+
+// getUser obtiene el usuario o errs.UserNotFound.
+func getUser(ctx context.Context, userDAO dao.UserDAO, email string) (md.User, error) {
+	u, err := userDAO.Get(ctx, email)
+	if err == gorm.ErrRecordNotFound {
+		return md.User{}, errs.UserNotFound
+	} else if err != nil {
+		loggerf.WithField("service", "UserService").WithError(err).Error("problems with getting user")
+		return md.User{}, err
+	}
+	return u, nil
+}
+
+// existsRole devuelve errs.RoleNotFound si el rol no existe.
+func existsRole(ctx context.Context, code string) error {
+	_, err := dao.NewRoleDAO().Get(ctx, code)
+	if err == gorm.ErrRecordNotFound {
+		return errs.RoleNotFound.SetMessage("Role not found: " + code)
+	}
+	return err
+}
+
+func toUserModel(u md.User, roles []md.Role) model.User {
+	return model.User{
+		FullName:   u.FullName,
+		Email:      u.Email,
+		CenterCode: u.CenterCode,
+		Lock:       u.Status == enums.LockedUserStatus,
+		Roles:      toRoleModels(roles),
+	}
+}
+
+func toRoleModels(roles []md.Role) []model.Role {
+	results := []model.Role{}
+	for _, r := range roles {
+		results = append(results, model.Role{Code: r.Code, Name: r.Name})
+	}
+	return results
+}
+
+func loginLockDurationFromEnv() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("LOGIN_LOCK_DURATION")); err == nil && v > 0 {
+		return v
+	}
+	return 15 * time.Minute
+}
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/security"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserService_Lifecycle(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	us := UserService{}
+
+	_, err := us.SaveRole(ctx, SaveRoleRequest{Code: "ROL_1", Name: "Administrador"})
+	assert.NoError(t, err)
+
+	user := model.User{FullName: "Ana", Email: "ana@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_1"}}}
+
+	_, err = us.SaveUser(ctx, SaveUserRequest{User: user, Password: "short"})
+	assert.Equal(t, errs.LenPassPolicy, err)
+
+	_, err = us.SaveUser(ctx, SaveUserRequest{User: model.User{Email: "x@example.com", CenterCode: "C1", Roles: []model.Role{{Code: "ROL_9"}}}, Password: "Secret123"})
+	assert.Equal(t, errs.RoleNotFound.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = us.SaveUser(ctx, SaveUserRequest{User: user, Password: "Secret123"})
+	assert.NoError(t, err)
+	_, err = us.SaveUser(ctx, SaveUserRequest{User: user, Password: "Secret123"})
+	assert.Equal(t, errs.UserAlreadyExists, err)
+
+	got, err := us.GetUser(ctx, GetUserRequest{Email: user.Email})
+	assert.NoError(t, err)
+	assert.False(t, got.User.Lock)
+	assert.Equal(t, []model.Role{{Code: "ROL_1", Name: "Administrador"}}, got.User.Roles)
+
+	_, err = us.LockUser(ctx, LockUserRequest{Email: user.Email})
+	assert.NoError(t, err)
+	got, _ = us.GetUser(ctx, GetUserRequest{Email: user.Email})
+	assert.True(t, got.User.Lock)
+
+	_, err = us.UnlockUser(ctx, LockUserRequest{Email: user.Email})
+	assert.NoError(t, err)
+
+	_, err = us.ResetPassword(ctx, ResetPasswordRequest{Email: user.Email, Password: "Another456"})
+	assert.NoError(t, err)
+	u, _ := getUser(ctx, dao.NewUserDAO(), user.Email)
+	ok, _ := security.PasswordHashImpl{}.Compare("Another456", u.Password)
+	assert.True(t, ok)
+
+	_, err = us.RevokeRole(ctx, AssignRoleRequest{Email: user.Email, RoleCode: "ROL_1"})
+	assert.NoError(t, err)
+	got, _ = us.GetUser(ctx, GetUserRequest{Email: user.Email})
+	assert.Empty(t, got.User.Roles)
+
+	_, err = us.AssignRole(ctx, AssignRoleRequest{Email: "nobody@example.com", RoleCode: "ROL_1"})
+	assert.Equal(t, errs.UserNotFound, err)
+}
+
+func TestUserService_Login(t *testing.T) {
+
+	testenv.SQLite(t)
+	security.TokenSecret = []byte("test-secret")
+	defer func() { security.TokenSecret = nil }()
+
+	ctx := context.TODO()
+	us := UserService{}
+
+	_, err := us.SaveRole(ctx, SaveRoleRequest{Code: "ROL_2", Name: "Usuario"})
+	assert.NoError(t, err)
+	user := model.User{FullName: "Luis", Email: "luis@example.com", CenterCode: "C2", Roles: []model.Role{{Code: "ROL_2"}}}
+	_, err = us.SaveUser(ctx, SaveUserRequest{User: user, Password: "Secret123"})
+	assert.NoError(t, err)
+
+	_, err = us.Login(ctx, LoginRequest{Email: "nobody@example.com", Password: "Secret123"})
+	assert.Equal(t, errs.InvalidCredentials, err)
+
+	res, err := us.Login(ctx, LoginRequest{Email: user.Email, Password: "Secret123"})
+	if assert.NoError(t, err) {
+		au, err := security.ParseToken(res.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, user.Email, au.Email)
+		assert.Equal(t, "C2", au.CenterCode)
+		assert.Equal(t, []model.Role{{Code: "ROL_2"}}, au.Roles)
+	}
+
+	// failed logins in parallel are all counted and lock the user for a while,
+	// during which even the right password gets InvalidCredentials
+	wg := sync.WaitGroup{}
+	for i := 0; i < MaxLoginAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := us.Login(ctx, LoginRequest{Email: user.Email, Password: "Wrong123"})
+			assert.Equal(t, errs.InvalidCredentials, err)
+		}()
+	}
+	wg.Wait()
+	_, err = us.Login(ctx, LoginRequest{Email: user.Email, Password: "Secret123"})
+	assert.Equal(t, errs.InvalidCredentials, err)
+
+	// the lock expires
+	past := time.Now().Add(-time.Minute)
+	assert.NoError(t, base.DB(ctx).Model(&md.User{}).Where("email = ?", user.Email).Update("locked_until", past).Error)
+	_, err = us.Login(ctx, LoginRequest{Email: user.Email, Password: "Secret123"})
+	assert.NoError(t, err)
+
+	// a user locked by an operator is told so only with the right password
+	_, err = us.LockUser(ctx, LockUserRequest{Email: user.Email})
+	assert.NoError(t, err)
+	_, err = us.Login(ctx, LoginRequest{Email: user.Email, Password: "Wrong123"})
+	assert.Equal(t, errs.InvalidCredentials, err)
+	_, err = us.Login(ctx, LoginRequest{Email: user.Email, Password: "Secret123"})
+	assert.Equal(t, errs.UserLocked, err)
+}
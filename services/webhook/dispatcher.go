@@ -0,0 +1,276 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/metrics"
+	"github.com/Alonso-Arias/test-cleverit/stream"
+)
+
+// Cabeceras de las entregas. La firma es "sha256=" seguido del HMAC-SHA256
+// en hexadecimal de Timestamp + "." + cuerpo, con el secreto del webhook.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderId        = "X-Webhook-Id"
+	HeaderAttempt   = "X-Webhook-Attempt"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Config es la configuración del Dispatcher.
+type Config struct {
+	// Workers es el número de entregas simultáneas.
+	Workers int
+	// MaxAttempts es el número de intentos por entrega, incluido el primero.
+	MaxAttempts int
+	// Backoff es la espera antes del primer reintento; se duplica en cada uno.
+	Backoff time.Duration
+	// Timeout es el tiempo máximo de cada petición.
+	Timeout time.Duration
+	// QueueSize es el número de entregas pendientes; las que no caben se descartan.
+	QueueSize int
+}
+
+// ConfigFromEnv construye una Config con las variables WEBHOOK_*.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Workers:     4,
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		Timeout:     10 * time.Second,
+		QueueSize:   1000,
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_WORKERS")); err == nil && v > 0 {
+		cfg.Workers = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && v > 0 {
+		cfg.MaxAttempts = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_BACKOFF")); err == nil && v > 0 {
+		cfg.Backoff = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && v > 0 {
+		cfg.Timeout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_QUEUE_SIZE")); err == nil && v > 0 {
+		cfg.QueueSize = v
+	}
+
+	return cfg
+}
+
+// Dispatcher entrega los eventos de tareas a los webhooks suscritos, de forma
+// asíncrona y con reintentos. Cada intento queda en el registro de entregas.
+type Dispatcher struct {
+	cfg        Config
+	client     *http.Client
+	webhookDAO dao.WebhookDAO
+	jobs       chan job
+	wg         sync.WaitGroup
+}
+
+// job es un evento pendiente. Sin webhook se reparte entre los suscritos del
+// centro de la tarea del evento.
+type job struct {
+	event   events.Event
+	center  string
+	webhook *md.Webhook
+	attempt int
+}
+
+// NewDispatcher obtiene un Dispatcher con la configuración cfg.
+func NewDispatcher(cfg Config) *Dispatcher {
+	return &Dispatcher{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		webhookDAO: dao.NewWebhookDAO(),
+		jobs:       make(chan job, cfg.QueueSize),
+	}
+}
+
+// Start se suscribe a bus y entrega sus eventos hasta que termine ctx.
+func (d *Dispatcher) Start(ctx context.Context, bus *events.Bus) {
+
+	unsubscribe := bus.Subscribe(func(_ context.Context, e events.Event) {
+		d.push(job{event: e, center: stream.Center(e), attempt: 1})
+	})
+
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go d.work(ctx)
+	}
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+}
+
+// Wait espera a que terminen los workers tras cancelar el contexto de Start.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// push encola j sin bloquear al publicador.
+func (d *Dispatcher) push(j job) {
+	select {
+	case d.jobs <- j:
+	default:
+		loggerf.WithField("type", j.event.Type).WithField("eventId", j.event.Id).Warn("webhook queue full, event dropped")
+		metrics.WebhookDeliveries.WithLabelValues(j.event.Type, "dropped").Inc()
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-d.jobs:
+			if j.webhook == nil {
+				d.fanOut(ctx, j)
+			} else {
+				d.deliver(ctx, j)
+			}
+		}
+	}
+}
+
+// fanOut entrega el evento de j a cada webhook activo de su centro suscrito a
+// su tipo.
+func (d *Dispatcher) fanOut(ctx context.Context, j job) {
+
+	webhooks, err := d.webhookDAO.FindActive(base.WithTenant(ctx, j.center), j.event.Type)
+	if err != nil {
+		loggerf.WithError(err).WithField("eventId", j.event.Id).Error("problems with getting webhooks")
+		return
+	}
+
+	for i := range webhooks {
+		d.deliver(ctx, job{event: j.event, center: j.center, webhook: &webhooks[i], attempt: 1})
+	}
+}
+
+// deliver hace un intento de entrega y programa el siguiente si falla.
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	log := loggerf.WithField("webhookId", j.webhook.Id).WithField("eventId", j.event.Id).WithField("attempt", j.attempt)
+
+	// un reintento usa la configuración actual y se descarta si el webhook
+	// se eliminó o desactivó mientras tanto
+	if j.attempt > 1 {
+		w, err := d.webhookDAO.Get(base.WithTenant(ctx, j.center), j.webhook.Id)
+		if err != nil || w.Disabled || !dao.Subscribed(w, j.event.Type) {
+			log.Info("webhook removed or disabled, retry dropped")
+			return
+		}
+		j.webhook = &w
+	}
+
+	start := time.Now()
+	status, err := d.post(ctx, j)
+	delivery := md.WebhookDelivery{
+		WebhookId:  j.webhook.Id,
+		EventId:    j.event.Id,
+		EventType:  j.event.Type,
+		Attempt:    j.attempt,
+		StatusCode: status,
+		Success:    err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if saveErr := d.webhookDAO.SaveDelivery(ctx, &delivery); saveErr != nil {
+		log.WithError(saveErr).Error("problems with saving delivery")
+	}
+
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues(j.event.Type, "success").Inc()
+		return
+	}
+	metrics.WebhookDeliveries.WithLabelValues(j.event.Type, "failure").Inc()
+
+	if j.attempt >= d.cfg.MaxAttempts {
+		log.WithError(err).Warn("webhook delivery failed, no attempts left")
+		return
+	}
+
+	delay := d.cfg.Backoff << (j.attempt - 1)
+	log.WithError(err).WithField("retryIn", delay.String()).Info("webhook delivery failed")
+
+	next := j
+	next.attempt++
+	time.AfterFunc(delay, func() {
+		if ctx.Err() == nil {
+			d.push(next)
+		}
+	})
+}
+
+// post envía el evento firmado; cualquier respuesta distinta de 2xx es un error.
+func (d *Dispatcher) post(ctx context.Context, j job) (int, error) {
+
+	body, err := json.Marshal(j.event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, j.event.Type)
+	req.Header.Set(HeaderId, j.event.Id)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(j.attempt))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(j.webhook.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign calcula la cabecera de firma de una entrega.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify comprueba la firma de una entrega recibida, para los receptores.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
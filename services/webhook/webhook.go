@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	md "github.com/Alonso-Arias/test-cleverit/db/model"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/tracing"
+	"gopkg.in/dealancer/validate.v2"
+	"gorm.io/gorm"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "services")
+
+// Límites de ListDeliveries.
+const (
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 500
+)
+
+// WebhookService contiene los métodos relacionados con los webhooks.
+type WebhookService struct{}
+
+// FindAllWebhooksResponse es la respuesta para FindAllWebhooks.
+type FindAllWebhooksResponse struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
+
+// FindAllWebhooks recupera todos los webhooks, sin sus secretos.
+func (ws WebhookService) FindAllWebhooks(ctx context.Context) (FindAllWebhooksResponse, error) {
+	log := loggerf.WithField("service", "WebhookService").WithField("func", "FindAllWebhooks")
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.FindAllWebhooks")
+	defer span.End()
+
+	webhooks, err := dao.NewWebhookDAO().FindAll(ctx)
+	if err != nil {
+		log.WithError(err).Error("problems with getting webhooks")
+		return FindAllWebhooksResponse{}, err
+	}
+
+	results := []model.Webhook{}
+	for _, v := range webhooks {
+		results = append(results, toWebhookModel(v))
+	}
+
+	return FindAllWebhooksResponse{Webhooks: results}, nil
+}
+
+// GetWebhookRequest es la solicitud para GetWebhook.
+type GetWebhookRequest struct {
+	Id int32 `json:"id"`
+}
+
+// GetWebhookResponse es la respuesta para GetWebhook.
+type GetWebhookResponse struct {
+	Webhook model.Webhook `json:"webhook"`
+}
+
+// GetWebhook obtiene un webhook por su ID, sin su secreto.
+func (ws WebhookService) GetWebhook(ctx context.Context, in GetWebhookRequest) (GetWebhookResponse, error) {
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.GetWebhook")
+	defer span.End()
+
+	if in.Id == 0 {
+		return GetWebhookResponse{}, errs.BadRequest
+	}
+
+	v, err := getWebhook(ctx, in.Id)
+	if err != nil {
+		return GetWebhookResponse{}, err
+	}
+
+	return GetWebhookResponse{Webhook: toWebhookModel(v)}, nil
+}
+
+// SaveWebhookRequest es la solicitud para SaveWebhook.
+type SaveWebhookRequest struct {
+	Webhook model.Webhook `json:"webhook"`
+}
+
+// SaveWebhookResponse es la respuesta para SaveWebhook. Secret es la clave
+// con la que se firman las entregas y no se vuelve a mostrar.
+type SaveWebhookResponse struct {
+	Id     int32  `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// SaveWebhook crea un webhook; si no se indica un secreto se genera uno.
+func (ws WebhookService) SaveWebhook(ctx context.Context, in SaveWebhookRequest) (SaveWebhookResponse, error) {
+	log := loggerf.WithField("service", "WebhookService").WithField("func", "SaveWebhook")
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.SaveWebhook")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil {
+		log.WithError(err).Error("validation problems")
+		return SaveWebhookResponse{}, errs.BadRequest
+	}
+
+	webhook, err := toWebhookDB(in.Webhook)
+	if err != nil {
+		return SaveWebhookResponse{}, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = newSecret()
+	}
+
+	if err := dao.NewWebhookDAO().Save(ctx, &webhook); err != nil {
+		log.WithError(err).Error("problems with saving webhook")
+		return SaveWebhookResponse{}, err
+	}
+
+	return SaveWebhookResponse{Id: webhook.Id, Secret: webhook.Secret}, nil
+}
+
+// UpdateWebhookRequest es la solicitud para UpdateWebhook.
+type UpdateWebhookRequest struct {
+	Webhook model.Webhook `json:"webhook"`
+}
+
+// UpdateWebhookResponse es la respuesta para UpdateWebhook.
+type UpdateWebhookResponse struct{}
+
+// UpdateWebhook reemplaza la url, los eventos y el estado de un webhook. El
+// secreto solo cambia si se indica uno nuevo.
+func (ws WebhookService) UpdateWebhook(ctx context.Context, in UpdateWebhookRequest) (UpdateWebhookResponse, error) {
+	log := loggerf.WithField("service", "WebhookService").WithField("func", "UpdateWebhook")
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.UpdateWebhook")
+	defer span.End()
+
+	if err := validate.Validate(in); err != nil || in.Webhook.Id == 0 {
+		log.WithError(err).Error("validation problems")
+		return UpdateWebhookResponse{}, errs.BadRequest
+	}
+
+	webhook, err := toWebhookDB(in.Webhook)
+	if err != nil {
+		return UpdateWebhookResponse{}, err
+	}
+
+	if _, err := getWebhook(ctx, in.Webhook.Id); err != nil {
+		return UpdateWebhookResponse{}, err
+	}
+
+	if err := dao.NewWebhookDAO().Update(ctx, webhook); err != nil {
+		return UpdateWebhookResponse{}, err
+	}
+
+	return UpdateWebhookResponse{}, nil
+}
+
+// DeleteWebhookRequest es la solicitud para DeleteWebhook.
+type DeleteWebhookRequest struct {
+	Id int32 `json:"id"`
+}
+
+// DeleteWebhookResponse es la respuesta para DeleteWebhook.
+type DeleteWebhookResponse struct{}
+
+// DeleteWebhook elimina un webhook y su registro de entregas.
+func (ws WebhookService) DeleteWebhook(ctx context.Context, in DeleteWebhookRequest) (DeleteWebhookResponse, error) {
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	if in.Id == 0 {
+		return DeleteWebhookResponse{}, errs.BadRequest
+	}
+
+	if _, err := getWebhook(ctx, in.Id); err != nil {
+		return DeleteWebhookResponse{}, err
+	}
+
+	if err := dao.NewWebhookDAO().Delete(ctx, in.Id); err != nil {
+		return DeleteWebhookResponse{}, err
+	}
+
+	return DeleteWebhookResponse{}, nil
+}
+
+// ListDeliveriesRequest es la solicitud para ListDeliveries.
+type ListDeliveriesRequest struct {
+	Id    int32 `json:"id"`
+	Limit int   `json:"limit" query:"limit"`
+}
+
+// ListDeliveriesResponse es la respuesta para ListDeliveries.
+type ListDeliveriesResponse struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+}
+
+// ListDeliveries obtiene los últimos intentos de entrega de un webhook, del
+// más reciente al más antiguo.
+func (ws WebhookService) ListDeliveries(ctx context.Context, in ListDeliveriesRequest) (ListDeliveriesResponse, error) {
+	log := loggerf.WithField("service", "WebhookService").WithField("func", "ListDeliveries")
+
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	if in.Id == 0 || in.Limit < 0 || in.Limit > MaxDeliveriesLimit {
+		return ListDeliveriesResponse{}, errs.BadRequest
+	}
+	if in.Limit == 0 {
+		in.Limit = DefaultDeliveriesLimit
+	}
+
+	if _, err := getWebhook(ctx, in.Id); err != nil {
+		return ListDeliveriesResponse{}, err
+	}
+
+	deliveries, err := dao.NewWebhookDAO().FindDeliveries(ctx, in.Id, in.Limit)
+	if err != nil {
+		log.WithError(err).Error("problems with getting deliveries")
+		return ListDeliveriesResponse{}, err
+	}
+
+	res := ListDeliveriesResponse{Deliveries: []model.WebhookDelivery{}}
+	for _, d := range deliveries {
+		res.Deliveries = append(res.Deliveries, model.WebhookDelivery{
+			Id:         d.Id,
+			EventId:    d.EventId,
+			EventType:  d.EventType,
+			Attempt:    d.Attempt,
+			StatusCode: d.StatusCode,
+			Error:      d.Error,
+			Success:    d.Success,
+			DurationMs: d.DurationMs,
+			CreatedAt:  d.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return res, nil
+}
+
+// getWebhook devuelve WebhookNotFound si no existe el webhook.
+func getWebhook(ctx context.Context, id int32) (md.Webhook, error) {
+
+	v, err := dao.NewWebhookDAO().Get(ctx, id)
+	if err == gorm.ErrRecordNotFound {
+		return md.Webhook{}, errs.WebhookNotFound
+	}
+
+	return v, err
+}
+
+// toWebhookDB valida la url y los tipos de evento de un webhook.
+func toWebhookDB(v model.Webhook) (md.Webhook, error) {
+
+	u, err := url.Parse(v.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return md.Webhook{}, errs.WebhookUrlInvalid
+	}
+
+	for _, e := range v.Events {
+		if !knownEvent(e) {
+			return md.Webhook{}, errs.WebhookEventInvalid.SetMessage("Unknown event type " + e)
+		}
+	}
+
+	return md.Webhook{
+		Id:       v.Id,
+		Url:      v.Url,
+		Secret:   v.Secret,
+		Events:   strings.Join(v.Events, ","),
+		Disabled: v.Disabled,
+	}, nil
+}
+
+func knownEvent(eventType string) bool {
+	for _, t := range events.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func toWebhookModel(v md.Webhook) model.Webhook {
+	w := model.Webhook{
+		Id:       v.Id,
+		Url:      v.Url,
+		Disabled: v.Disabled,
+	}
+	if v.Events != "" {
+		w.Events = strings.Split(v.Events, ",")
+	}
+	return w
+}
+
+// newSecret genera un secreto aleatorio de 256 bits.
+func newSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookService_CRUD(t *testing.T) {
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ws := WebhookService{}
+
+	_, err := ws.SaveWebhook(ctx, SaveWebhookRequest{Webhook: model.Webhook{Url: "ftp://example.com"}})
+	assert.Equal(t, errs.WebhookUrlInvalid, err)
+
+	_, err = ws.SaveWebhook(ctx, SaveWebhookRequest{Webhook: model.Webhook{Url: "http://example.com", Events: []string{"task.moved"}}})
+	assert.IsType(t, errs.CustomError{}, err)
+	assert.Equal(t, errs.WebhookEventInvalid.InternalCode, err.(errs.CustomError).InternalCode)
+
+	saved, err := ws.SaveWebhook(ctx, SaveWebhookRequest{Webhook: model.Webhook{Url: "http://example.com/hook"}})
+	assert.NoError(t, err)
+	assert.NotZero(t, saved.Id)
+	assert.Len(t, saved.Secret, 64)
+
+	_, err = ws.UpdateWebhook(ctx, UpdateWebhookRequest{Webhook: model.Webhook{
+		Id: saved.Id, Url: "https://example.com/hook", Events: []string{events.TaskDeleted}, Disabled: true,
+	}})
+	assert.NoError(t, err)
+
+	got, err := ws.GetWebhook(ctx, GetWebhookRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", got.Webhook.Url)
+	assert.Equal(t, []string{events.TaskDeleted}, got.Webhook.Events)
+	assert.True(t, got.Webhook.Disabled)
+	assert.Empty(t, got.Webhook.Secret)
+
+	_, err = ws.DeleteWebhook(ctx, DeleteWebhookRequest{Id: saved.Id})
+	assert.NoError(t, err)
+
+	_, err = ws.GetWebhook(ctx, GetWebhookRequest{Id: saved.Id})
+	assert.Equal(t, errs.WebhookNotFound, err)
+}
+
+// receiver registra las entregas con firma válida y falla las primeras fails.
+type receiver struct {
+	mu        sync.Mutex
+	secret    string
+	fails     int
+	requests  int
+	delivered []events.Event
+	invalid   int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	if !Verify(r.secret, req.Header.Get(HeaderTimestamp), body, req.Header.Get(HeaderSignature)) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.requests <= r.fails {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	e := events.Event{}
+	json.Unmarshal(body, &e)
+	r.delivered = append(r.delivered, e)
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.delivered)
+}
+
+func TestDispatcher_SignedDeliveryWithRetries(t *testing.T) {
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ws := WebhookService{}
+
+	rcv := &receiver{fails: 2}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	saved, err := ws.SaveWebhook(ctx, SaveWebhookRequest{Webhook: model.Webhook{
+		Url: srv.URL, Events: []string{events.TaskCreated},
+	}})
+	assert.NoError(t, err)
+	rcv.secret = saved.Secret
+	defer ws.DeleteWebhook(ctx, DeleteWebhookRequest{Id: saved.Id})
+
+	bus := events.NewBus()
+	runCtx, cancel := context.WithCancel(context.Background())
+	d := NewDispatcher(Config{Workers: 2, MaxAttempts: 5, Backoff: 10 * time.Millisecond, Timeout: time.Second, QueueSize: 10})
+	d.Start(runCtx, bus)
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+
+	// no está suscrito a task.deleted
+	deleted, _ := events.New(events.TaskDeleted, 7, map[string]int32{"id": 7})
+	bus.Publish(ctx, deleted)
+
+	created, _ := events.New(events.TaskCreated, 7, map[string]string{"title": "informe"})
+	bus.Publish(ctx, created)
+
+	assert.Eventually(t, func() bool { return rcv.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, created.Id, rcv.delivered[0].Id)
+	assert.JSONEq(t, `{"title":"informe"}`, string(rcv.delivered[0].Data))
+	assert.Zero(t, rcv.invalid)
+
+	res, err := ws.ListDeliveries(ctx, ListDeliveriesRequest{Id: saved.Id})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Deliveries, 3) {
+		assert.Equal(t, 3, res.Deliveries[0].Attempt)
+		assert.True(t, res.Deliveries[0].Success)
+		assert.Equal(t, http.StatusOK, res.Deliveries[0].StatusCode)
+		assert.Equal(t, 1, res.Deliveries[2].Attempt)
+		assert.False(t, res.Deliveries[2].Success)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Deliveries[2].StatusCode)
+		for _, dl := range res.Deliveries {
+			assert.Equal(t, events.TaskCreated, dl.EventType)
+		}
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	testenv.SQLite(t)
+
+	ctx := context.TODO()
+	ws := WebhookService{}
+
+	rcv := &receiver{fails: 100}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	saved, err := ws.SaveWebhook(ctx, SaveWebhookRequest{Webhook: model.Webhook{Url: srv.URL}})
+	assert.NoError(t, err)
+	rcv.secret = saved.Secret
+	defer ws.DeleteWebhook(ctx, DeleteWebhookRequest{Id: saved.Id})
+
+	bus := events.NewBus()
+	runCtx, cancel := context.WithCancel(context.Background())
+	d := NewDispatcher(Config{Workers: 1, MaxAttempts: 3, Backoff: time.Millisecond, Timeout: time.Second, QueueSize: 10})
+	d.Start(runCtx, bus)
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+
+	e, _ := events.New(events.TaskUpdated, 1, map[string]string{})
+	bus.Publish(ctx, e)
+
+	assert.Eventually(t, func() bool {
+		res, _ := ws.ListDeliveries(ctx, ListDeliveriesRequest{Id: saved.Id})
+		return len(res.Deliveries) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	res, _ := ws.ListDeliveries(ctx, ListDeliveriesRequest{Id: saved.Id})
+	assert.Len(t, res.Deliveries, 3)
+	assert.Zero(t, rcv.count())
+}
+
+func TestDispatcher_OnlyCenterWebhooks(t *testing.T) {
+	testenv.SQLite(t)
+
+	c1, c2 := base.WithTenant(context.TODO(), "C1"), base.WithTenant(context.TODO(), "C2")
+	ws := WebhookService{}
+
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	saved, err := ws.SaveWebhook(c1, SaveWebhookRequest{Webhook: model.Webhook{Url: srv.URL}})
+	assert.NoError(t, err)
+	rcv.secret = saved.Secret
+	defer ws.DeleteWebhook(c1, DeleteWebhookRequest{Id: saved.Id})
+
+	// the webhooks of C1 are not seen from C2
+	_, err = ws.GetWebhook(c2, GetWebhookRequest{Id: saved.Id})
+	assert.Equal(t, errs.WebhookNotFound, err)
+	_, err = ws.DeleteWebhook(c2, DeleteWebhookRequest{Id: saved.Id})
+	assert.Equal(t, errs.WebhookNotFound, err)
+
+	bus := events.NewBus()
+	runCtx, cancel := context.WithCancel(context.Background())
+	d := NewDispatcher(Config{Workers: 1, MaxAttempts: 1, Backoff: time.Millisecond, Timeout: time.Second, QueueSize: 10})
+	d.Start(runCtx, bus)
+	defer func() {
+		cancel()
+		d.Wait()
+	}()
+
+	other, _ := events.New(events.TaskCreated, 8, map[string]string{"centerCode": "C2"})
+	bus.Publish(context.TODO(), other)
+	own, _ := events.New(events.TaskCreated, 7, map[string]string{"centerCode": "C1"})
+	bus.Publish(context.TODO(), own)
+
+	assert.Eventually(t, func() bool { return rcv.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, rcv.count())
+	assert.Equal(t, own.Id, rcv.delivered[0].Id)
+}
+
+func TestSign(t *testing.T) {
+	sig := Sign("secret", "1700000000", []byte(`{"id":"1"}`))
+	assert.Equal(t, "sha256=", sig[:7])
+	assert.True(t, Verify("secret", "1700000000", []byte(`{"id":"1"}`), sig))
+	assert.False(t, Verify("other", "1700000000", []byte(`{"id":"1"}`), sig))
+	assert.False(t, Verify("secret", "1700000001", []byte(`{"id":"1"}`), sig))
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage - keeps every object in a bucket of an S3-compatible service
+// (AWS S3, MinIO, ...) using path-style requests
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Storage - gets an S3Storage for the bucket of cfg; the bucket must exist
+func NewS3Storage(cfg Config) (*S3Storage, error) {
+
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("storage: S3_ENDPOINT and S3_BUCKET are required")
+	}
+
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure:       cfg.S3UseSSL,
+		Region:       cfg.S3Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Storage{client: client, bucket: cfg.S3Bucket}, nil
+}
+
+// Name -
+func (s *S3Storage) Name() string {
+	return DriverS3
+}
+
+// Put -
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// Get - the object is requested before returning, so a missing key is
+// reported here and not on the first read
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, s3Error(err)
+	}
+
+	return obj, nil
+}
+
+// Delete -
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+// s3Error - maps missing objects to ErrNotFound
+func s3Error(err error) error {
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
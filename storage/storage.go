@@ -0,0 +1,117 @@
+// Package storage keeps the contents of task attachments in a blob store,
+// the local filesystem or an S3-compatible bucket, while their metadata
+// lives in the task_attachments table.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/Alonso-Arias/test-cleverit/log"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "storage")
+
+// Drivers selected with STORAGE_DRIVER.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound - the object does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage - blob store addressed by keys such as "tasks/12/<uuid>"
+type Storage interface {
+	Name() string
+	// Put stores size bytes of r under key, replacing any previous object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object under key, ErrNotFound when it does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key; missing objects are not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Config - blob store settings
+type Config struct {
+	Driver string
+	// Dir is the root directory of the local driver
+	Dir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// ConfigFromEnv - reads STORAGE_DRIVER (local by default), STORAGE_DIR
+// (attachments) and the S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY,
+// S3_SECRET_KEY and S3_USE_SSL settings of the s3 driver
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:      os.Getenv("STORAGE_DRIVER"),
+		Dir:         os.Getenv("STORAGE_DIR"),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = DriverLocal
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "attachments"
+	}
+	if cfg.S3Region == "" {
+		cfg.S3Region = "us-east-1"
+	}
+	return cfg
+}
+
+// New - builds the blob store of cfg
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		return NewLocalStorage(cfg.Dir)
+	case DriverS3:
+		return NewS3Storage(cfg)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+var (
+	defaultStorage Storage
+	defaultMu      sync.Mutex
+)
+
+// Default - gets the process-wide blob store, built from the environment on
+// first use
+func Default() Storage {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultStorage == nil {
+		s, err := New(ConfigFromEnv())
+		if err != nil {
+			loggerf.WithError(err).Fatal("Failed to init storage")
+		}
+		defaultStorage = s
+	}
+	return defaultStorage
+}
+
+// SetDefault - replaces the process-wide blob store
+func SetDefault(s Storage) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultStorage = s
+}
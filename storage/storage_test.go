@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 - in-memory stand-in for the object API of an S3-compatible service
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readBody - decodes the aws-chunked bodies signed chunk by chunk that
+// clients send over plain http
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(strings.SplitN(strings.TrimSpace(line), ";", 2)[0], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestStorage_PutGetDelete(t *testing.T) {
+
+	srv := httptest.NewServer(&fakeS3{objects: map[string][]byte{}, types: map[string]string{}})
+	defer srv.Close()
+
+	local, err := NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	s3, err := New(Config{
+		Driver:      DriverS3,
+		S3Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+		S3Region:    "us-east-1",
+		S3Bucket:    "attachments",
+		S3AccessKey: "access",
+		S3SecretKey: "secret",
+	})
+	assert.NoError(t, err)
+
+	for _, s := range []Storage{local, s3} {
+		t.Run(s.Name(), func(t *testing.T) {
+			ctx := context.TODO()
+			content := []byte("spec v1")
+
+			_, err := s.Get(ctx, "tasks/1/missing")
+			assert.Equal(t, ErrNotFound, err)
+
+			assert.NoError(t, s.Put(ctx, "tasks/1/spec", bytes.NewReader(content), int64(len(content)), "text/plain"))
+
+			r, err := s.Get(ctx, "tasks/1/spec")
+			if assert.NoError(t, err) {
+				got, err := io.ReadAll(r)
+				r.Close()
+				assert.NoError(t, err)
+				assert.Equal(t, content, got)
+			}
+
+			assert.NoError(t, s.Delete(ctx, "tasks/1/spec"))
+			assert.NoError(t, s.Delete(ctx, "tasks/1/spec"))
+
+			_, err = s.Get(ctx, "tasks/1/spec")
+			assert.Equal(t, ErrNotFound, err)
+		})
+	}
+}
+
+func TestLocalStorage_RejectsKeysOutsideDir(t *testing.T) {
+
+	s, err := NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/", "../secret", "tasks/../../secret"} {
+		assert.Error(t, s.Put(context.TODO(), key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+}
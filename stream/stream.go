@@ -0,0 +1,180 @@
+// Package stream feeds task events to long-lived client connections (SSE,
+// WebSocket). A client that reconnects with the Seq of the last event it got
+// first receives the published events it missed from the outbox, then the
+// live events of the bus. When some of them were already purged it first
+// gets an events.StreamResync.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/log"
+	"github.com/Alonso-Arias/test-cleverit/outbox"
+)
+
+var loggerf = log.LoggerJSON().WithField("package", "stream")
+
+const (
+	// bufferSize - live events a client may lag behind before it is
+	// disconnected; it resumes from the outbox when it reconnects
+	bufferSize = 256
+	// replayBatchSize - outbox events loaded per query while replaying
+	replayBatchSize = 500
+)
+
+// Filter - selects the events sent to a client
+type Filter struct {
+	// States keeps the events of tasks in one of these states, every event
+	// when empty. A state change matches both its from and to states.
+	States []string
+	// Allow is called for every event that passes States, nil allows all.
+	Allow func(e events.Event) bool
+}
+
+// Match - true when e must be sent
+func (f Filter) Match(e events.Event) bool {
+	if len(f.States) > 0 && !f.matchState(e) {
+		return false
+	}
+	return f.Allow == nil || f.Allow(e)
+}
+
+func (f Filter) matchState(e events.Event) bool {
+	payload := struct {
+		State string `json:"state"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+	}{}
+	if err := json.Unmarshal(e.Data, &payload); err != nil {
+		return false
+	}
+	for _, s := range f.States {
+		if s == payload.State || s == payload.From || s == payload.To {
+			return true
+		}
+	}
+	return false
+}
+
+// Center - center of the task of e, empty for the tasks of no center
+func Center(e events.Event) string {
+	payload := struct {
+		CenterCode string `json:"centerCode"`
+		Task       struct {
+			CenterCode string `json:"centerCode"`
+		} `json:"task"`
+	}{}
+	if err := json.Unmarshal(e.Data, &payload); err != nil {
+		return ""
+	}
+	if payload.CenterCode != "" {
+		return payload.CenterCode
+	}
+	return payload.Task.CenterCode
+}
+
+// Open - subscribes to bus and returns the channel of the events that match
+// filter, starting after the event with seq lastSeq (0 only sends new events).
+// An events.StreamResync goes first when the events after lastSeq are no
+// longer all in the outbox. The channel is closed when ctx ends or when the
+// client falls too far behind, so it has to reconnect.
+func Open(ctx context.Context, bus *events.Bus, lastSeq int64, filter Filter) <-chan events.Event {
+
+	ctx, cancel := context.WithCancel(ctx)
+	live := make(chan events.Event, bufferSize)
+	out := make(chan events.Event)
+
+	unsubscribe := bus.Subscribe(func(_ context.Context, e events.Event) {
+		select {
+		case live <- e:
+		default:
+			loggerf.WithField("eventId", e.Id).Warn("stream client too slow, disconnecting")
+			cancel()
+		}
+	})
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		defer cancel()
+
+		send := func(e events.Event) bool {
+			if !filter.Match(e) {
+				return true
+			}
+			select {
+			case out <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// events published before the subscription, from the outbox
+		last := lastSeq
+		if lastSeq > 0 {
+			first, err := dao.NewOutboxDAO().FirstSeqAfter(ctx, lastSeq)
+			if err != nil {
+				loggerf.WithError(err).Error("problems with replaying events")
+				return
+			}
+			if first > lastSeq+1 {
+				select {
+				case out <- resync(lastSeq+1, first-1):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		for lastSeq > 0 {
+			rows, err := dao.NewOutboxDAO().FindPublishedAfter(ctx, last, replayBatchSize)
+			if err != nil {
+				loggerf.WithError(err).Error("problems with replaying events")
+				return
+			}
+			for _, row := range rows {
+				if !send(outbox.ToEvent(row)) {
+					return
+				}
+				last = *row.Seq
+			}
+			if len(rows) < replayBatchSize {
+				break
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-live:
+				// already sent by the replay
+				if e.Seq != 0 && e.Seq <= last {
+					continue
+				}
+				if !send(e) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// resync - the events.StreamResync of a client that missed the events from
+// firstMissed to lastMissed
+func resync(firstMissed int64, lastMissed int64) events.Event {
+	data, _ := json.Marshal(map[string]int64{"firstMissed": firstMissed, "lastMissed": lastMissed})
+	return events.Event{
+		Id:         events.NewId(),
+		Seq:        lastMissed,
+		Type:       events.StreamResync,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
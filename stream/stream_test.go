@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/db/dao"
+	"github.com/Alonso-Arias/test-cleverit/db/model"
+	"github.com/Alonso-Arias/test-cleverit/events"
+	"github.com/Alonso-Arias/test-cleverit/outbox"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// published escribe un evento en el outbox ya publicado y lo devuelve con su seq.
+func published(t *testing.T, eventType string, taskId int32, data interface{}) events.Event {
+	e, _ := events.New(eventType, taskId, data)
+	row := model.OutboxEvent{EventId: e.Id, Type: e.Type, TaskId: e.TaskId, Payload: string(e.Data), OccurredAt: e.OccurredAt}
+	assert.NoError(t, dao.NewOutboxDAO().Save(context.TODO(), &row))
+	seq, err := dao.NewOutboxDAO().AssignSeq(context.TODO(), row.Id)
+	assert.NoError(t, err)
+	assert.NoError(t, dao.NewOutboxDAO().MarkPublished(context.TODO(), row.Id, time.Now()))
+	row.Seq = &seq
+	return outbox.ToEvent(row)
+}
+
+func receive(t *testing.T, ch <-chan events.Event) events.Event {
+	select {
+	case e, ok := <-ch:
+		assert.True(t, ok, "stream closed")
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+		return events.Event{}
+	}
+}
+
+func TestOpen_ResumesThenFollowsLiveEvents(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	seen := published(t, events.TaskCreated, 1, map[string]string{"state": "PENDING"})
+	missed := published(t, events.TaskUpdated, 1, map[string]string{"state": "PENDING"})
+	hidden := published(t, events.TaskUpdated, 2, map[string]string{"state": "PENDING"})
+	completed := published(t, events.TaskStateChanged, 1, map[string]string{"from": "PENDING", "to": "COMPLETED"})
+
+	bus := events.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := Open(ctx, bus, seen.Seq, Filter{
+		States: []string{"PENDING"},
+		Allow:  func(e events.Event) bool { return e.TaskId != 2 },
+	})
+
+	assert.Equal(t, missed.Id, receive(t, ch).Id)
+	assert.Equal(t, completed.Id, receive(t, ch).Id)
+
+	// el relay vuelve a entregar un evento ya enviado: se descarta
+	bus.Publish(ctx, completed)
+	bus.Publish(ctx, hidden)
+
+	other, _ := events.New(events.TaskCreated, 3, map[string]string{"state": "COMPLETED"})
+	other.Seq = completed.Seq + 100
+	bus.Publish(ctx, other)
+
+	live, _ := events.New(events.TaskDeleted, 1, map[string]interface{}{"id": 1, "state": "PENDING"})
+	live.Seq = completed.Seq + 101
+	bus.Publish(ctx, live)
+
+	assert.Equal(t, live.Id, receive(t, ch).Id)
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestOpen_ResyncsAfterPurgedEvents(t *testing.T) {
+
+	testenv.SQLite(t)
+
+	seen := published(t, events.TaskCreated, 1, map[string]string{"state": "PENDING"})
+	purged := published(t, events.TaskUpdated, 1, map[string]string{"state": "PENDING"})
+	kept := published(t, events.TaskUpdated, 1, map[string]string{"state": "PENDING"})
+	assert.NoError(t, base.GetDB().Where("event_id = ?", purged.Id).Delete(&model.OutboxEvent{}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := Open(ctx, events.NewBus(), seen.Seq, Filter{States: []string{"PENDING"}})
+
+	e := receive(t, ch)
+	assert.Equal(t, events.StreamResync, e.Type)
+	assert.Equal(t, purged.Seq, e.Seq)
+	assert.JSONEq(t, fmt.Sprintf(`{"firstMissed":%d,"lastMissed":%d}`, purged.Seq, purged.Seq), string(e.Data))
+	assert.Equal(t, kept.Id, receive(t, ch).Id)
+
+	// sin eventos perdidos no hay resync
+	ch = Open(ctx, events.NewBus(), purged.Seq, Filter{})
+	assert.Equal(t, kept.Id, receive(t, ch).Id)
+}
+
+func TestOpen_DisconnectsSlowClients(t *testing.T) {
+
+	bus := events.NewBus()
+	ch := Open(context.Background(), bus, 0, Filter{})
+
+	for i := 0; i <= bufferSize+1; i++ {
+		e, _ := events.New(events.TaskCreated, 1, nil)
+		bus.Publish(context.TODO(), e)
+	}
+
+	// lo ya encolado puede leerse, luego se cierra
+	closed := false
+	for i := 0; i <= bufferSize+2 && !closed; i++ {
+		select {
+		case _, ok := <-ch:
+			closed = !ok
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream not closed")
+		}
+	}
+	assert.True(t, closed)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/pkg/client"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/task"
+)
+
+// taskBackend - where the tasks commands run, the REST API or the database
+type taskBackend interface {
+	List(ctx context.Context, filter client.TaskFilter) (client.FindAllTasksResponse, error)
+	Get(ctx context.Context, id int32) (model.Task, error)
+	Create(ctx context.Context, t model.Task) (int32, error)
+	Update(ctx context.Context, t model.Task) error
+	Delete(ctx context.Context, id int32) error
+	Transition(ctx context.Context, id int32, state string) error
+}
+
+// newTaskBackend - REST backend when opts.api is set, database otherwise
+func newTaskBackend(opts *options) (taskBackend, error) {
+	if opts.api != "" {
+		return restBackend{client.New(client.Config{BaseURL: opts.api, Token: opts.token, TimeZone: opts.timeZone})}, nil
+	}
+
+	b := dbBackend{center: opts.center}
+	if opts.timeZone != "" {
+		loc, err := task.LoadLocation(opts.timeZone)
+		if err != nil {
+			return nil, err
+		}
+		b.loc = loc
+	}
+	return b, nil
+}
+
+// restBackend - tasks through the REST API
+type restBackend struct {
+	c *client.Client
+}
+
+func (b restBackend) List(ctx context.Context, filter client.TaskFilter) (client.FindAllTasksResponse, error) {
+	return b.c.FindAllTasks(ctx, filter)
+}
+
+func (b restBackend) Get(ctx context.Context, id int32) (model.Task, error) {
+	out, err := b.c.GetTask(ctx, id)
+	return out.Task, err
+}
+
+func (b restBackend) Create(ctx context.Context, t model.Task) (int32, error) {
+	out, err := b.c.SaveTask(ctx, client.SaveTaskRequest{Task: t})
+	return out.Id, err
+}
+
+func (b restBackend) Update(ctx context.Context, t model.Task) error {
+	_, err := b.c.UpdateTask(ctx, client.UpdateTaskRequest{Task: t})
+	return err
+}
+
+func (b restBackend) Delete(ctx context.Context, id int32) error {
+	return b.c.DeleteTask(ctx, id)
+}
+
+// Transition - changes the state alone, with the rules of the bulk update
+func (b restBackend) Transition(ctx context.Context, id int32, state string) error {
+	out, err := b.c.BulkUpdateTasks(ctx, client.BulkUpdateTasksRequest{Ids: []int32{id}, State: state, AllOrNothing: true})
+	if err != nil {
+		return err
+	}
+	if len(out.Results) == 1 && out.Results[0].Error != nil {
+		return *out.Results[0].Error
+	}
+	return nil
+}
+
+// dbBackend - tasks on the database through TaskService
+type dbBackend struct {
+	ts task.TaskService
+	// center - tenant of the commands, every center when empty
+	center string
+	// loc - time zone of the due dates, UTC when nil
+	loc *time.Location
+}
+
+// ctx - sets the center and the time zone of the commands on ctx
+func (b dbBackend) ctx(ctx context.Context) context.Context {
+	if b.center != "" {
+		ctx = base.WithTenant(ctx, b.center)
+	}
+	if b.loc != nil {
+		ctx = task.WithLocation(ctx, b.loc)
+	}
+	return ctx
+}
+
+func (b dbBackend) List(ctx context.Context, filter client.TaskFilter) (client.FindAllTasksResponse, error) {
+	out, err := b.ts.FindAllTasks(b.ctx(ctx), task.FindAllTasksRequest{
+		State:    filter.State,
+		Priority: filter.Priority,
+		Label:    filter.Label,
+		DueFrom:  filter.DueFrom,
+		DueTo:    filter.DueTo,
+		Sort:     filter.Sort,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	})
+	return client.FindAllTasksResponse{Tasks: out.Tasks, Total: out.Total}, err
+}
+
+func (b dbBackend) Get(ctx context.Context, id int32) (model.Task, error) {
+	out, err := b.ts.GetTask(b.ctx(ctx), task.GetTaskRequest{Id: id})
+	return out.Task, err
+}
+
+func (b dbBackend) Create(ctx context.Context, t model.Task) (int32, error) {
+	out, err := b.ts.SaveTask(b.ctx(ctx), task.SaveTaskRequest{Task: t})
+	return out.Id, err
+}
+
+func (b dbBackend) Update(ctx context.Context, t model.Task) error {
+	_, err := b.ts.UpdateTask(b.ctx(ctx), task.UpdateTaskRequest{Task: t})
+	return err
+}
+
+func (b dbBackend) Delete(ctx context.Context, id int32) error {
+	_, err := b.ts.DeleteTask(b.ctx(ctx), task.DeleteTaskRequest{Id: id})
+	return err
+}
+
+// Transition - changes the state alone, with the rules of the bulk update
+func (b dbBackend) Transition(ctx context.Context, id int32, state string) error {
+	out, err := b.ts.BulkUpdateTasks(b.ctx(ctx), task.BulkUpdateTasksRequest{Ids: []int32{id}, State: state, AllOrNothing: true})
+	if err != nil {
+		return err
+	}
+	if len(out.Results) == 1 && out.Results[0].Error != nil {
+		return *out.Results[0].Error
+	}
+	return nil
+}
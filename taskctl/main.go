@@ -0,0 +1,59 @@
+// Command taskctl manages the tasks through the REST API, or directly on the
+// database through the services when no API is given, and the users, roles
+// and schema on the database.
+//
+// The database is the one configured for the API, with DB_DRIVER,
+// MYSQL_CONNECTION and SQLITE_PATH.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// options - global flags
+type options struct {
+	// api - base URL of the REST API, the database is used when empty
+	api      string
+	token    string
+	timeZone string
+	// center - center of the tasks managed on the database
+	center string
+	json   bool
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:          "taskctl",
+		Short:        "Manages tasks, users, roles and migrations",
+		SilenceUsage: true,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.api, "api", os.Getenv("TASKCTL_API"), "base URL of the REST API, tasks are managed on the database when empty (TASKCTL_API)")
+	flags.StringVar(&opts.token, "token", os.Getenv("TASKCTL_TOKEN"), "bearer token for the REST API (TASKCTL_TOKEN)")
+	flags.StringVar(&opts.timeZone, "tz", os.Getenv("TASKCTL_TZ"), "IANA time zone of the due dates, UTC when empty (TASKCTL_TZ)")
+	flags.BoolVar(&opts.json, "json", false, "prints the results as JSON")
+
+	root.AddCommand(newTasksCmd(opts), newUserCmd(opts), newRoleCmd(opts), newMigrateCmd())
+
+	return root
+}
+
+// printJSON - writes v indented
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	errs "github.com/Alonso-Arias/test-cleverit/errors"
+	"github.com/Alonso-Arias/test-cleverit/testenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// run - executes taskctl with args and stdin, returning its output
+func run(t *testing.T, stdin string, args ...string) (string, error) {
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetArgs(args)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetOut(&out)
+	root.SetErr(&out)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestTasks_Database(t *testing.T) {
+	testenv.SQLite(t)
+	t.Setenv("TASKCTL_API", "")
+
+	_, err := run(t, "", "tasks", "create", "--title", "cli", "--description", "from taskctl", "--due", "2030-01-01T10:00:00")
+	assert.Equal(t, errs.TaskWithoutCenter, err)
+
+	out, err := run(t, "", "tasks", "create", "--center", "C1", "--title", "cli", "--description", "from taskctl", "--due", "2030-01-01T10:00:00", "--tz", "America/Santiago")
+	if !assert.NoError(t, err) {
+		return
+	}
+	id := strings.TrimSpace(out)
+
+	_, err = run(t, "", "tasks", "get", id, "--center", "C2")
+	assert.Error(t, err)
+
+	_, err = run(t, "", "tasks", "update", id, "--priority", "HIGH")
+	assert.NoError(t, err)
+
+	_, err = run(t, "", "tasks", "transition", id, "in_progress")
+	assert.NoError(t, err)
+
+	out, err = run(t, "", "tasks", "get", id, "--json", "--tz", "America/Santiago")
+	assert.NoError(t, err)
+	got := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &got))
+	assert.Equal(t, "cli", got["title"])
+	assert.Equal(t, "HIGH", got["priority"])
+	assert.Equal(t, "IN_PROGRESS", got["state"])
+	assert.Equal(t, "2030-01-01T10:00:00-03:00", got["due_date"])
+
+	out, err = run(t, "", "tasks", "list", "--state", "IN_PROGRESS")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, "cli")
+
+	_, err = run(t, "", "tasks", "transition", id, "NOPE")
+	assert.Equal(t, errs.TaskStateInvalid.InternalCode, err.(errs.CustomError).InternalCode)
+
+	_, err = run(t, "", "tasks", "delete", id)
+	assert.NoError(t, err)
+	_, err = run(t, "", "tasks", "get", id)
+	assert.Equal(t, errs.TasksNotFound, err)
+}
+
+func TestTasks_REST(t *testing.T) {
+	var auth, bulk string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/task/7":
+			w.Write([]byte(`{"task":{"id":7,"title":"remote","description":"d","state":"PENDING"}}`))
+		case "PUT /api/v1/task/bulk":
+			var b bytes.Buffer
+			b.ReadFrom(r.Body)
+			bulk = b.String()
+			w.Write([]byte(`{"results":[{"index":0,"id":7,"success":false,"error":{"message":"Task is blocked by incomplete tasks","code":409,"internalCode":"TASK_BLOCKED"}}],"failed":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Tasks not found","code":404,"internalCode":"TASKS_NOT_FOUND"}`))
+		}
+	}))
+	defer srv.Close()
+
+	out, err := run(t, "", "--api", srv.URL, "--token", "secret", "tasks", "get", "7")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "remote")
+	assert.Equal(t, "Bearer secret", auth)
+
+	_, err = run(t, "", "--api", srv.URL, "tasks", "transition", "7", "COMPLETED")
+	assert.Equal(t, errs.TaskBlocked, err)
+	assert.JSONEq(t, `{"ids":[7],"state":"COMPLETED","allOrNothing":true}`, bulk)
+
+	_, err = run(t, "", "--api", srv.URL, "tasks", "delete", "8")
+	assert.Equal(t, errs.TasksNotFound, err)
+
+	_, err = run(t, "", "--api", srv.URL, "tasks", "get", "x")
+	assert.Error(t, err)
+}
+
+func TestUsersAndRoles(t *testing.T) {
+	testenv.SQLite(t)
+
+	out, err := run(t, "", "migrate")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "schema up to date")
+
+	_, err = run(t, "", "role", "create", "ROL_2", "--name", "Lector")
+	assert.NoError(t, err)
+
+	_, err = run(t, "Secret123\n", "user", "create", "cli@example.com", "--center", "C1", "--name", "Cli", "--role", "ROL_2")
+	assert.NoError(t, err)
+
+	_, err = run(t, "", "user", "lock", "cli@example.com")
+	assert.NoError(t, err)
+	out, err = run(t, "", "user", "get", "cli@example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "true")
+	assert.Contains(t, out, "ROL_2")
+
+	_, err = run(t, "", "user", "unlock", "cli@example.com")
+	assert.NoError(t, err)
+
+	_, err = run(t, "", "user", "reset-password", "cli@example.com", "--password", "weak")
+	assert.Equal(t, errs.LenPassPolicy, err)
+
+	_, err = run(t, "", "role", "assign", "cli@example.com", "ROL_9")
+	assert.Equal(t, errs.RoleNotFound.InternalCode, err.(errs.CustomError).InternalCode)
+
+	out, err = run(t, "", "role", "list", "--json")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"code": "ROL_2"`)
+}
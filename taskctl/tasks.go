@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Alonso-Arias/test-cleverit/enums"
+	"github.com/Alonso-Arias/test-cleverit/pkg/client"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/spf13/cobra"
+)
+
+func newTasksCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "Lists, creates, updates, deletes and transitions tasks",
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.center, "center", os.Getenv("TASKCTL_CENTER"), "center of the tasks on the database, every center when empty; required to create tasks (TASKCTL_CENTER)")
+
+	cmd.AddCommand(
+		newTasksListCmd(opts),
+		newTasksGetCmd(opts),
+		newTasksCreateCmd(opts),
+		newTasksUpdateCmd(opts),
+		newTasksDeleteCmd(opts),
+		newTasksTransitionCmd(opts),
+	)
+
+	return cmd
+}
+
+func newTasksListCmd(opts *options) *cobra.Command {
+	filter := client.TaskFilter{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the tasks matching the filters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			out, err := b.List(cmd.Context(), filter)
+			if err != nil {
+				return err
+			}
+			if opts.json {
+				return printJSON(cmd.OutOrStdout(), out)
+			}
+			return printTasks(cmd.OutOrStdout(), out.Tasks)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&filter.State, "state", "", "state of the tasks")
+	f.StringVar(&filter.Priority, "priority", "", "priority of the tasks")
+	f.StringVar(&filter.Label, "label", "", "label name of the tasks")
+	f.StringVar(&filter.DueFrom, "due-from", "", "tasks due from this date")
+	f.StringVar(&filter.DueTo, "due-to", "", "tasks due up to this date")
+	f.StringVar(&filter.Sort, "sort", "", "id, priority, -priority, due_date or -due_date")
+	f.IntVar(&filter.Limit, "limit", 0, "page size, every task when 0")
+	f.IntVar(&filter.Offset, "offset", 0, "tasks skipped")
+
+	return cmd
+}
+
+func newTasksGetCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Shows a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			t, err := b.Get(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			if opts.json {
+				return printJSON(cmd.OutOrStdout(), t)
+			}
+			return printTasks(cmd.OutOrStdout(), []model.Task{t})
+		},
+	}
+}
+
+// taskFlags - flags of create and update, update only sets the changed ones
+func taskFlags(cmd *cobra.Command, t *model.Task) {
+	f := cmd.Flags()
+	f.StringVar(&t.Title, "title", "", "title")
+	f.StringVar(&t.Description, "description", "", "description")
+	f.StringVar(&t.State, "state", enums.PendingTaskStatus, "state")
+	f.StringVar(&t.Priority, "priority", "", "priority")
+	f.StringVar(&t.DueDate, "due", "", "due date, in the time zone of --tz when it has no offset")
+	f.StringVar(&t.Recurrence, "recurrence", "", "RRULE of a recurring task, NONE removes it on update")
+	f.StringSliceVar(&t.Labels, "label", nil, "label names, repeated or comma separated")
+}
+
+func newTasksCreateCmd(opts *options) *cobra.Command {
+	t := model.Task{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Creates a task and prints its id",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			id, err := b.Create(cmd.Context(), t)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), id)
+			return nil
+		},
+	}
+	taskFlags(cmd, &t)
+
+	return cmd
+}
+
+func newTasksUpdateCmd(opts *options) *cobra.Command {
+	changes := model.Task{}
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Updates the given fields of a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			t, err := b.Get(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			f := cmd.Flags()
+			set := func(name string, dst *string, v string) {
+				if f.Changed(name) {
+					*dst = v
+				}
+			}
+			set("title", &t.Title, changes.Title)
+			set("description", &t.Description, changes.Description)
+			set("state", &t.State, changes.State)
+			set("priority", &t.Priority, changes.Priority)
+			set("due", &t.DueDate, changes.DueDate)
+			set("recurrence", &t.Recurrence, changes.Recurrence)
+			if f.Changed("label") {
+				t.Labels = changes.Labels
+			}
+
+			return b.Update(cmd.Context(), t)
+		},
+	}
+	taskFlags(cmd, &changes)
+
+	return cmd
+}
+
+func newTasksDeleteCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Deletes a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			return b.Delete(cmd.Context(), id)
+		},
+	}
+}
+
+func newTasksTransitionCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "transition ID STATE",
+		Short: "Changes the state of a task",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := newTaskBackend(opts)
+			if err != nil {
+				return err
+			}
+			return b.Transition(cmd.Context(), id, strings.ToUpper(args[1]))
+		},
+	}
+}
+
+func parseId(s string) (int32, error) {
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid task id %q", s)
+	}
+	return int32(id), nil
+}
+
+// printTasks - writes the tasks as a table
+func printTasks(w io.Writer, tasks []model.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tSTATE\tPRIORITY\tDUE\tLABELS")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", t.Id, t.Title, t.State, t.Priority, t.DueDate, strings.Join(t.Labels, ","))
+	}
+	return tw.Flush()
+}
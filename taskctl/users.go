@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+	"github.com/Alonso-Arias/test-cleverit/services/model"
+	"github.com/Alonso-Arias/test-cleverit/services/user"
+	"github.com/spf13/cobra"
+)
+
+// Users, roles and migrations are always managed on the database, the REST
+// API has no endpoints for them.
+
+func newUserCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Creates, shows, locks and unlocks users and resets their passwords",
+	}
+
+	cmd.AddCommand(
+		newUserCreateCmd(),
+		newUserGetCmd(opts),
+		newUserStatusCmd("lock", "Locks a user", user.UserService.LockUser),
+		newUserStatusCmd("unlock", "Unlocks a user and resets its failed logins", user.UserService.UnlockUser),
+		newUserResetPasswordCmd(),
+	)
+
+	return cmd
+}
+
+func newUserCreateCmd() *cobra.Command {
+	in := user.SaveUserRequest{}
+	var roles []string
+
+	cmd := &cobra.Command{
+		Use:   "create EMAIL",
+		Short: "Creates an active user, the password is read from stdin without --password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in.User.Email = args[0]
+			for _, r := range roles {
+				in.User.Roles = append(in.User.Roles, model.Role{Code: r})
+			}
+			if err := readPassword(cmd, &in.Password); err != nil {
+				return err
+			}
+			_, err := user.UserService{}.SaveUser(cmd.Context(), in)
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&in.User.FullName, "name", "", "full name")
+	f.StringVar(&in.User.CenterCode, "center", "", "code of the center of the user")
+	f.StringVar(&in.Password, "password", "", "password")
+	f.StringSliceVar(&roles, "role", nil, "role codes, repeated or comma separated")
+	cmd.MarkFlagRequired("center")
+
+	return cmd
+}
+
+func newUserGetCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get EMAIL",
+		Short: "Shows a user and its roles",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := user.UserService{}.GetUser(cmd.Context(), user.GetUserRequest{Email: args[0]})
+			if err != nil {
+				return err
+			}
+			if opts.json {
+				return printJSON(cmd.OutOrStdout(), out.User)
+			}
+
+			codes := []string{}
+			for _, r := range out.User.Roles {
+				codes = append(codes, r.Code)
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "EMAIL\tNAME\tCENTER\tLOCKED\tROLES")
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", out.User.Email, out.User.FullName, out.User.CenterCode, out.User.Lock, strings.Join(codes, ","))
+			return tw.Flush()
+		},
+	}
+}
+
+func newUserStatusCmd(use, short string, fn func(user.UserService, context.Context, user.LockUserRequest) (user.LockUserResponse, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " EMAIL",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := fn(user.UserService{}, cmd.Context(), user.LockUserRequest{Email: args[0]})
+			return err
+		},
+	}
+}
+
+func newUserResetPasswordCmd() *cobra.Command {
+	in := user.ResetPasswordRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "reset-password EMAIL",
+		Short: "Replaces the password of a user, read from stdin without --password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in.Email = args[0]
+			if err := readPassword(cmd, &in.Password); err != nil {
+				return err
+			}
+			_, err := user.UserService{}.ResetPassword(cmd.Context(), in)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&in.Password, "password", "", "new password")
+
+	return cmd
+}
+
+// readPassword - reads the first line of stdin when the password flag is empty
+func readPassword(cmd *cobra.Command, password *string) error {
+	if *password != "" {
+		return nil
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	*password = strings.TrimRight(line, "\r\n")
+	return nil
+}
+
+func newRoleCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role",
+		Short: "Lists and creates roles and assigns them to users",
+	}
+
+	cmd.AddCommand(
+		newRoleListCmd(opts),
+		newRoleCreateCmd(),
+		newRoleAssignCmd("assign", "Assigns a role to a user", user.UserService.AssignRole),
+		newRoleAssignCmd("revoke", "Revokes a role of a user", user.UserService.RevokeRole),
+	)
+
+	return cmd
+}
+
+func newRoleListCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists the roles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := user.UserService{}.FindAllRoles(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if opts.json {
+				return printJSON(cmd.OutOrStdout(), out.Roles)
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CODE\tNAME")
+			for _, r := range out.Roles {
+				fmt.Fprintf(tw, "%s\t%s\n", r.Code, r.Name)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newRoleCreateCmd() *cobra.Command {
+	in := user.SaveRoleRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "create CODE",
+		Short: "Creates a role or updates its name and description, its permissions are in the casbin policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in.Code = args[0]
+			_, err := user.UserService{}.SaveRole(cmd.Context(), in)
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&in.Name, "name", "", "name")
+	f.StringVar(&in.Description, "description", "", "description")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func newRoleAssignCmd(use, short string, fn func(user.UserService, context.Context, user.AssignRoleRequest) (user.AssignRoleResponse, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " EMAIL ROLE",
+		Short: short,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := fn(user.UserService{}, cmd.Context(), user.AssignRoleRequest{Email: args[0], RoleCode: args[1]})
+			return err
+		},
+	}
+}
+
+func newMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Creates or updates the database schema from the models",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := base.Migrate(cmd.Context()); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "schema up to date")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,32 @@
+// Package testenv prepares the environment shared by the tests of the
+// repository.
+package testenv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Alonso-Arias/test-cleverit/db/base"
+)
+
+// SQLite - connects base to the in-memory sqlite database, failing t when it
+// cannot
+func SQLite(t testing.TB) {
+	t.Helper()
+
+	if err := base.Connect(base.DriverSQLite, ""); err != nil {
+		t.Fatalf("fails to connect to sqlite: %v", err)
+	}
+}
+
+// BasePath - sets BASE_PATH, under which the policy files are read, to the
+// repository root unless it is already set
+func BasePath() {
+	if os.Getenv("BASE_PATH") != "" {
+		return
+	}
+	_, file, _, _ := runtime.Caller(0)
+	os.Setenv("BASE_PATH", filepath.Dir(filepath.Dir(file)))
+}
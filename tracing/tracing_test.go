@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_PropagatesTraceContext(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/task/:id", func(c echo.Context) error {
+		_, span := Tracer().Start(c.Request().Context(), "TaskService.GetTask")
+		span.End()
+		return c.JSON(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/task/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	service, server := spans[0], spans[1]
+	assert.Equal(t, "GET /api/v1/task/:id", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+}
+
+func TestMiddleware_RedactsAccessToken(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/task/events", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/task/events?access_token=secret&type=task.created", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		target := ""
+		for _, a := range spans[0].Attributes() {
+			if a.Key == "http.target" {
+				target = a.Value.AsString()
+			}
+		}
+		assert.Equal(t, "/api/v1/task/events?access_token=REDACTED&type=task.created", target)
+	}
+}
+
+func TestInit_FileExporter(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Init(context.Background(), Config{Exporter: ExporterFile, File: file, ServiceName: "test", SampleRatio: 1})
+	assert.NoError(t, err)
+
+	_, span := Tracer().Start(context.Background(), "TaskService.FindAllTasks")
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	b, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "TaskService.FindAllTasks")
+}
+
+func TestInit_UnknownExporter(t *testing.T) {
+
+	_, err := Init(context.Background(), Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}